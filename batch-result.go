@@ -0,0 +1,115 @@
+package jonson
+
+import "strconv"
+
+// BatchResult allows methods processing multiple items (e.g. bulk updates)
+// to report the success or failure of each item instead of failing the whole call.
+// Item errors carry the item's path, e.g. ["items", "[2]"], and can be inspected
+// using Errors and the ErrorInspector:
+//
+//	func (s *System) BulkUpdateV1(ctx *jonson.Context, params *BulkUpdateV1Params) (*jonson.BatchResult[*Profile], error) {
+//		res := jonson.NewBatchResult[*Profile](ctx, "items")
+//		for _, item := range params.Items {
+//			if !res.Validate(item) {
+//				continue
+//			}
+//			profile, err := s.update(item)
+//			if err != nil {
+//				res.Fail(err)
+//				continue
+//			}
+//			res.Add(profile)
+//		}
+//		return res, nil
+//	}
+type BatchResult[T any] struct {
+	Items  []*BatchItem[T] `json:"items"`
+	Failed int             `json:"failed"`
+
+	secret   Secret
+	basePath []string
+}
+
+// BatchItem is the outcome of a single item; failed items carry an Error.
+// Result is always sent: zero results (e.g. 0 or false) are valid results.
+type BatchItem[T any] struct {
+	Result T      `json:"result"`
+	Error  *Error `json:"error,omitempty"`
+}
+
+// NewBatchResult returns a new batch result. Item paths start
+// with the given base path (e.g. the params' field holding the items)
+// followed by the item's index.
+func NewBatchResult[T any](ctx *Context, basePath ...string) *BatchResult[T] {
+	return &BatchResult[T]{
+		Items:    []*BatchItem[T]{},
+		secret:   RequireSecret(ctx),
+		basePath: basePath,
+	}
+}
+
+// path returns the path of the next item
+func (b *BatchResult[T]) path() []string {
+	path := make([]string, 0, len(b.basePath)+1)
+	path = append(path, b.basePath...)
+	return append(path, "["+strconv.Itoa(len(b.Items))+"]")
+}
+
+// Add adds a successfully processed item
+func (b *BatchResult[T]) Add(result T) {
+	b.Items = append(b.Items, &BatchItem[T]{
+		Result: result,
+	})
+}
+
+// Fail adds a failed item. Errors other than *Error will be
+// reported as ErrInternal, passing the error as debug information.
+// The error's path will be prefixed with the item's path.
+func (b *BatchResult[T]) Fail(err error) {
+	path := b.path()
+	e, ok := err.(*Error)
+	if !ok {
+		e = ErrInternal.CloneWithData(&ErrorData{
+			Debug: b.secret.Encode(err.Error()),
+		})
+	}
+	if e.Data != nil {
+		path = append(path, e.Data.Path...)
+	}
+	b.fail(e.WithPath(path...))
+}
+
+// Validate validates the next item; in case the validation fails,
+// the item will be added as failed (reporting all validation errors
+// with paths relative to the item) and false will be returned
+func (b *BatchResult[T]) Validate(item ValidatedParams) bool {
+	path := b.path()
+	err := Validate(b.secret, item, path...)
+	if err == nil {
+		return true
+	}
+	b.fail(err.WithPath(path...))
+	return false
+}
+
+func (b *BatchResult[T]) fail(err *Error) {
+	b.Items = append(b.Items, &BatchItem[T]{
+		Error: err,
+	})
+	b.Failed++
+}
+
+// Errors returns ErrPartialFailure carrying the errors of all
+// failed items as details; in case no item failed, nil will be returned
+func (b *BatchResult[T]) Errors() *Error {
+	if b.Failed == 0 {
+		return nil
+	}
+	details := make([]*Error, 0, b.Failed)
+	for _, v := range b.Items {
+		if v.Error != nil {
+			details = append(details, v.Error)
+		}
+	}
+	return ErrPartialFailure.WithDetails(details...)
+}
@@ -0,0 +1,128 @@
+package jonson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bulkUpdateV1Item struct {
+	Name string `json:"name"`
+}
+
+func (i *bulkUpdateV1Item) JonsonValidate(v *Validator) {
+	if i.Name == "" {
+		v.Path("name").Message("name missing")
+	}
+}
+
+type bulkUpdateV1Params struct {
+	Params
+	Items []*bulkUpdateV1Item `json:"items"`
+}
+
+func bulkUpdate(ctx *Context, params *bulkUpdateV1Params) (*BatchResult[string], error) {
+	res := NewBatchResult[string](ctx, "items")
+	for _, item := range params.Items {
+		if !res.Validate(item) {
+			continue
+		}
+		switch item.Name {
+		case "taken":
+			res.Fail(ErrConflict.WithPath("name"))
+		case "broken":
+			res.Fail(errors.New("database unavailable"))
+		default:
+			res.Add("updated " + item.Name)
+		}
+	}
+	return res, nil
+}
+
+func TestBatchResult(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterMethod(&MethodDefinition{
+		System:      "profile",
+		Method:      "bulk-update",
+		Version:     1,
+		HandlerFunc: bulkUpdate,
+	})
+	items := []*bulkUpdateV1Item{{Name: "alice"}, {Name: ""}, {Name: "taken"}, {Name: "broken"}}
+
+	t.Run("answers with per item results and errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(w, newHttpRpcRequest("profile/bulk-update.v1", &bulkUpdateV1Params{
+			Items: items,
+		}))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d", w.Code)
+		}
+		var res BatchResult[string]
+		if errResp, err := parseHttpRpcResponse(w, &res); err != nil || errResp != nil {
+			t.Fatalf("expected call to succeed, got: %v, %v", errResp, err)
+		}
+		if len(res.Items) != 4 || res.Failed != 3 {
+			t.Fatalf("expected 4 items with 3 failures, got: %d items, %d failures", len(res.Items), res.Failed)
+		}
+		if res.Items[0].Result != "updated alice" || res.Items[0].Error != nil {
+			t.Fatalf("expected first item to succeed, got: %v", res.Items[0])
+		}
+		for i, code := range []int{ErrInvalidParams.Code, ErrConflict.Code, ErrInternal.Code} {
+			if e := res.Items[i+1].Error; e == nil || e.Code != code {
+				t.Fatalf("expected item %d to fail with %d, got: %v", i+1, code, e)
+			}
+		}
+	})
+
+	t.Run("reports item errors with the item's path", func(t *testing.T) {
+		ctx := NewContext(context.Background(), methodHandler.factory, methodHandler)
+		ctx.StoreValue(TypeSecret, NewDebugSecret())
+		res, err := bulkUpdate(ctx, &bulkUpdateV1Params{Items: items})
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs := res.Errors()
+		if errs == nil || errs.Code != ErrPartialFailure.Code {
+			t.Fatalf("expected partial failure, got: %v", errs)
+		}
+		inspector := func() *ErrorInspector { return NewErrorInspector(errs) }
+		if inspector().WithPath("items", "[1]", "name").WithMessage("name missing").FindFirst() == nil {
+			t.Fatalf("expected validation error of item 1, got: %s", errs)
+		}
+		if inspector().WithPath("items", "[2]", "name").WithCode(ErrConflict.Code).FindFirst() == nil {
+			t.Fatalf("expected conflict of item 2, got: %s", errs)
+		}
+		if inspector().WithPath("items", "[3]").WithCode(ErrInternal.Code).FindFirst() == nil {
+			t.Fatalf("expected internal error of item 3, got: %s", errs)
+		}
+	})
+
+	t.Run("returns no errors in case all items succeeded", func(t *testing.T) {
+		ctx := NewContext(context.Background(), methodHandler.factory, methodHandler)
+		ctx.StoreValue(TypeSecret, NewDebugSecret())
+		res, _ := bulkUpdate(ctx, &bulkUpdateV1Params{Items: items[:1]})
+		if res.Errors() != nil {
+			t.Fatalf("expected no errors, got: %s", res.Errors())
+		}
+		b, _ := json.Marshal(res)
+		if string(b) != `{"items":[{"result":"updated alice"}],"failed":0}` {
+			t.Fatalf("unexpected encoding: %s", b)
+		}
+	})
+
+	t.Run("keeps zero results", func(t *testing.T) {
+		ctx := NewContext(context.Background(), methodHandler.factory, methodHandler)
+		ctx.StoreValue(TypeSecret, NewDebugSecret())
+		res := NewBatchResult[int](ctx, "items")
+		res.Add(0)
+		res.Fail(ErrConflict)
+		b, _ := json.Marshal(res)
+		if !strings.HasPrefix(string(b), `{"items":[{"result":0},{"result":0,"error":{`) {
+			t.Fatalf("expected zero result to be sent, got: %s", b)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package jonson
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// errBreakerPanic will be reported for calls which panicked
+var errBreakerPanic = errors.New("jonson: circuit breaker call panicked")
+
+// CircuitBreakerProvider provides process-wide circuit breakers
+// protecting calls to external dependencies. Once a circuit reached
+// threshold consecutive failures, it opens and calls fail fast with
+// ErrServiceUnavailable. After the cooldown, a single trial call will
+// be let through: in case it succeeds, the circuit closes again,
+// otherwise it opens for another cooldown.
+// The cooldown is based on the Time provider, hence a TimeProvider
+// needs to be registered as well.
+//
+//	fac.RegisterProvider(jonson.NewTimeProvider())
+//	fac.RegisterProvider(jonson.NewCircuitBreakerProvider(5, time.Second*30))
+type CircuitBreakerProvider struct {
+	threshold int
+	cooldown  time.Duration
+
+	mux      sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	failures  int
+	open      bool
+	openUntil time.Time
+	trial     bool
+}
+
+// NewCircuitBreakerProvider returns a new circuit breaker provider
+func NewCircuitBreakerProvider(threshold int, cooldown time.Duration) *CircuitBreakerProvider {
+	return &CircuitBreakerProvider{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  map[string]*circuit{},
+	}
+}
+
+func (c *CircuitBreakerProvider) NewBreaker(ctx *Context) *Breaker {
+	return &Breaker{
+		provider: c,
+		time:     RequireTime(ctx),
+	}
+}
+
+// Breaker gives access to the process-wide circuit breakers.
+// The breaker is safe for concurrent use.
+type Breaker struct {
+	Shareable
+	ShareableAcrossImpersonation
+
+	provider *CircuitBreakerProvider
+	time     Time
+}
+
+var TypeBreaker = reflect.TypeOf((**Breaker)(nil)).Elem()
+
+// RequireBreaker returns the circuit breaker
+func RequireBreaker(ctx *Context) *Breaker {
+	if v := ctx.Require(TypeBreaker); v != nil {
+		return v.(*Breaker)
+	}
+	return nil
+}
+
+// Do calls fn in case the circuit with the given name is closed
+// and returns fn's error. In case the circuit is open,
+// ErrServiceUnavailable will be returned without calling fn.
+// Panics of fn count as failures and will be passed on.
+func (b *Breaker) Do(name string, fn func() error) (err error) {
+	if !b.allow(name) {
+		return ErrServiceUnavailable
+	}
+	returned := false
+	defer func() {
+		if !returned {
+			// fn panicked: a trial call must not keep the circuit open forever
+			b.report(name, errBreakerPanic)
+		}
+	}()
+	err = fn()
+	returned = true
+	b.report(name, err)
+	return err
+}
+
+// allow returns true in case a call may pass the circuit
+func (b *Breaker) allow(name string) bool {
+	b.provider.mux.Lock()
+	defer b.provider.mux.Unlock()
+
+	c, ok := b.provider.circuits[name]
+	if !ok {
+		c = &circuit{}
+		b.provider.circuits[name] = c
+	}
+	if !c.open {
+		return true
+	}
+	// only a single trial call is allowed once the cooldown passed
+	if c.trial || b.time.Now().Before(c.openUntil) {
+		return false
+	}
+	c.trial = true
+	return true
+}
+
+// report records the result of a call
+func (b *Breaker) report(name string, err error) {
+	b.provider.mux.Lock()
+	defer b.provider.mux.Unlock()
+
+	c := b.provider.circuits[name]
+	if err == nil {
+		*c = circuit{}
+		return
+	}
+	c.failures++
+	if c.trial || c.failures >= b.provider.threshold {
+		c.open = true
+		c.openUntil = b.time.Now().Add(b.provider.cooldown)
+		c.trial = false
+	}
+}
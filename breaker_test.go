@@ -0,0 +1,105 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	tm := newMockTime(time.Now())
+	fac := NewFactory()
+	fac.RegisterProvider(NewTimeProvider(func() Time {
+		return tm
+	}))
+	fac.RegisterProvider(NewCircuitBreakerProvider(2, time.Minute))
+
+	errFailed := errors.New("failed")
+	calls := 0
+	fail := func() error {
+		calls++
+		return errFailed
+	}
+	succeed := func() error {
+		calls++
+		return nil
+	}
+
+	ctx := NewContext(context.Background(), fac, nil)
+	breaker := RequireBreaker(ctx)
+
+	t.Run("opens after reaching the failure threshold", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if err := breaker.Do("external", fail); err != errFailed {
+				t.Fatalf("expected call to fail, got: %v", err)
+			}
+		}
+		if err := breaker.Do("external", succeed); err != ErrServiceUnavailable {
+			t.Fatalf("expected ErrServiceUnavailable, got: %v", err)
+		}
+		if calls != 2 {
+			t.Fatalf("expected open circuit not to call fn, got %d calls", calls)
+		}
+	})
+
+	t.Run("circuits are independent of each other", func(t *testing.T) {
+		if err := breaker.Do("other", succeed); err != nil {
+			t.Fatalf("expected call to succeed, got: %v", err)
+		}
+	})
+
+	t.Run("reopens in case the trial call fails", func(t *testing.T) {
+		tm.now = tm.now.Add(time.Minute)
+		if err := breaker.Do("external", fail); err != errFailed {
+			t.Fatalf("expected trial call to be made, got: %v", err)
+		}
+		if err := breaker.Do("external", succeed); err != ErrServiceUnavailable {
+			t.Fatalf("expected ErrServiceUnavailable, got: %v", err)
+		}
+	})
+
+	t.Run("closes in case the trial call succeeds", func(t *testing.T) {
+		tm.now = tm.now.Add(time.Minute)
+		if err := breaker.Do("external", succeed); err != nil {
+			t.Fatalf("expected trial call to succeed, got: %v", err)
+		}
+		if err := breaker.Do("external", fail); err != errFailed {
+			t.Fatalf("expected closed circuit to call fn, got: %v", err)
+		}
+		if err := breaker.Do("external", succeed); err != nil {
+			t.Fatalf("expected circuit to stay closed below threshold, got: %v", err)
+		}
+	})
+
+	t.Run("panicking trial calls reopen the circuit", func(t *testing.T) {
+		doPanicking := func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatal("expected panic to be passed on")
+				}
+			}()
+			breaker.Do("panicking", func() error {
+				panic("failed")
+			})
+		}
+		for i := 0; i < 2; i++ {
+			doPanicking()
+		}
+		if err := breaker.Do("panicking", succeed); err != ErrServiceUnavailable {
+			t.Fatalf("expected panics to open the circuit, got: %v", err)
+		}
+
+		// the trial call panics as well
+		tm.now = tm.now.Add(time.Minute)
+		doPanicking()
+		if err := breaker.Do("panicking", succeed); err != ErrServiceUnavailable {
+			t.Fatalf("expected ErrServiceUnavailable, got: %v", err)
+		}
+
+		tm.now = tm.now.Add(time.Minute)
+		if err := breaker.Do("panicking", succeed); err != nil {
+			t.Fatalf("expected next trial call to close the circuit, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package jonson
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// CacheProvider provides a process-wide in-memory cache.
+// Entries expire after their ttl; expiry is based on the
+// Time provider, hence a TimeProvider needs to be registered as well.
+// This allows us to move forward in time within our tests.
+// Expired entries will be removed once read or, for entries which
+// are never read again, by sweeping the cache while storing entries.
+//
+//	fac.RegisterProvider(jonson.NewTimeProvider())
+//	fac.RegisterProvider(jonson.NewCacheProvider())
+type CacheProvider struct {
+	mux     sync.Mutex
+	entries map[string]*cacheEntry
+	// sweepAt is the number of entries which
+	// triggers the next sweep of expired entries
+	sweepAt int
+}
+
+// cacheMinSweepAt prevents small caches from being swept on each Set
+const cacheMinSweepAt = 1024
+
+type cacheEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// NewCacheProvider returns a new cache provider
+func NewCacheProvider() *CacheProvider {
+	return &CacheProvider{
+		entries: map[string]*cacheEntry{},
+		sweepAt: cacheMinSweepAt,
+	}
+}
+
+// sweep removes all expired entries; the cache is swept once the number
+// of entries doubled since the last sweep to keep Set's amortized costs constant.
+// Must be called holding the lock.
+func (c *CacheProvider) sweep(now time.Time) {
+	if len(c.entries) < c.sweepAt {
+		return
+	}
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.sweepAt = max(len(c.entries)*2, cacheMinSweepAt)
+}
+
+func (c *CacheProvider) NewCache(ctx *Context) *Cache {
+	return &Cache{
+		provider: c,
+		time:     RequireTime(ctx),
+	}
+}
+
+// Cache gives access to the process-wide in-memory cache.
+// The cache is safe for concurrent use.
+type Cache struct {
+	Shareable
+	ShareableAcrossImpersonation
+
+	provider *CacheProvider
+	time     Time
+}
+
+var TypeCache = reflect.TypeOf((**Cache)(nil)).Elem()
+
+// RequireCache returns the in-memory cache
+func RequireCache(ctx *Context) *Cache {
+	if v := ctx.Require(TypeCache); v != nil {
+		return v.(*Cache)
+	}
+	return nil
+}
+
+// Get returns the value stored for key;
+// in case the key does not exist or has expired, false will be returned
+func (c *Cache) Get(key string) (any, bool) {
+	c.provider.mux.Lock()
+	defer c.provider.mux.Unlock()
+
+	entry, ok := c.provider.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.time.Now().Before(entry.expiresAt) {
+		delete(c.provider.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set stores value for key for the given ttl
+func (c *Cache) Set(key string, ttl time.Duration, value any) {
+	c.provider.mux.Lock()
+	defer c.provider.mux.Unlock()
+
+	now := c.time.Now()
+	c.provider.sweep(now)
+	c.provider.entries[key] = &cacheEntry{
+		value:     value,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// Delete removes the value stored for key
+func (c *Cache) Delete(key string) {
+	c.provider.mux.Lock()
+	defer c.provider.mux.Unlock()
+
+	delete(c.provider.entries, key)
+}
+
+// GetOrSet returns the value stored for key. In case the key does not
+// exist or has expired, fn will be called and its result will be stored
+// for the given ttl. Errors returned by fn will not be cached.
+func (c *Cache) GetOrSet(key string, ttl time.Duration, fn func() (any, error)) (any, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+
+	// fn is called without holding the lock:
+	// slow functions must not block other keys
+	v, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	c.Set(key, ttl, v)
+	return v, nil
+}
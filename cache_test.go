@@ -0,0 +1,106 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	tm := newMockTime(time.Now())
+
+	factory := NewFactory()
+	factory.RegisterProvider(NewTimeProvider(func() Time {
+		return tm
+	}))
+	factory.RegisterProvider(NewCacheProvider())
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+
+	newCache := func() *Cache {
+		return RequireCache(NewContext(context.Background(), factory, methodHandler))
+	}
+
+	t.Run("values are shared across requests and expire", func(t *testing.T) {
+		calls := 0
+		fn := func() (any, error) {
+			calls++
+			return "profile", nil
+		}
+
+		for i := 0; i < 2; i++ {
+			v, err := newCache().GetOrSet("profile", 30*time.Second, fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != "profile" {
+				t.Fatalf("expected value to equal 'profile', got: %v", v)
+			}
+		}
+		if calls != 1 {
+			t.Fatalf("expected fn to be called once, got: %d", calls)
+		}
+
+		tm.now = tm.now.Add(30 * time.Second)
+		newCache().GetOrSet("profile", 30*time.Second, fn)
+		if calls != 2 {
+			t.Fatalf("expected fn to be called again after expiry, got: %d", calls)
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		cache := newCache()
+		_, err := cache.GetOrSet("error", time.Minute, func() (any, error) {
+			return nil, errors.New("failed")
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if _, ok := cache.Get("error"); ok {
+			t.Fatal("expected error not to be cached")
+		}
+	})
+
+	t.Run("delete removes values", func(t *testing.T) {
+		cache := newCache()
+		cache.Set("key", time.Minute, 1)
+		cache.Delete("key")
+		if _, ok := cache.Get("key"); ok {
+			t.Fatal("expected value to be deleted")
+		}
+	})
+
+	t.Run("expired entries are swept while storing entries", func(t *testing.T) {
+		provider := NewCacheProvider()
+		cache := &Cache{provider: provider, time: tm}
+		for i := 0; i < cacheMinSweepAt; i++ {
+			cache.Set(fmt.Sprintf("once-%d", i), time.Second, i)
+		}
+
+		// none of the keys will be read again
+		tm.now = tm.now.Add(time.Second)
+		cache.Set("key", time.Minute, 1)
+		if len(provider.entries) != 1 {
+			t.Fatalf("expected expired entries to be swept, got: %d entries", len(provider.entries))
+		}
+		if v, ok := cache.Get("key"); !ok || v != 1 {
+			t.Fatalf("expected value to be kept, got: %v", v)
+		}
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cache := newCache()
+				cache.Set("concurrent", time.Minute, 1)
+				cache.Get("concurrent")
+			}()
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,69 @@
+package jonson
+
+import "sync"
+
+// MethodCall describes a single call of Context.CallMethods
+type MethodCall struct {
+	Method        string
+	RpcHttpMethod RpcHttpMethod
+	Payload       any
+}
+
+// MethodResult contains the outcome of a single call of Context.CallMethods
+type MethodResult struct {
+	Result any
+	Error  error
+}
+
+// CallMethodsOptions define how Context.CallMethods runs its calls
+type CallMethodsOptions struct {
+	// MaxConcurrency limits the number of calls running at the same time.
+	// Defaults to 0 (all calls run at once).
+	MaxConcurrency int
+}
+
+// CallMethods calls the given methods concurrently, e.g. to aggregate the results
+// of multiple endpoints. Each call will be run within its own forked context,
+// just like CallMethod: authentication and authorization will be evaluated per call.
+// The results are returned in the order of the calls; the returned error is the
+// first error in order of the calls, all results will be returned nevertheless.
+// The context must not be used by the caller until CallMethods returns.
+// In case no options are provided, all calls will run at once.
+func (c *Context) CallMethods(calls []MethodCall, opts *CallMethodsOptions) ([]MethodResult, error) {
+	if opts == nil {
+		opts = &CallMethodsOptions{}
+	}
+	concurrency := opts.MaxConcurrency
+	if concurrency <= 0 || concurrency > len(calls) {
+		concurrency = len(calls)
+	}
+
+	var (
+		results = make([]MethodResult, len(calls))
+		sem     = make(chan struct{}, concurrency)
+		wg      = sync.WaitGroup{}
+	)
+	for i, call := range calls {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			res, err := c.CallMethod(call.Method, call.RpcHttpMethod, call.Payload, nil)
+			results[i] = MethodResult{
+				Result: res,
+				Error:  err,
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, v := range results {
+		if v.Error != nil {
+			return results, v.Error
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,132 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type ConcurrencySystem struct {
+	mux     sync.Mutex
+	running int
+	max     int
+}
+
+type DoubleV1Params struct {
+	Params
+	Value int `json:"value"`
+}
+
+func (d *DoubleV1Params) JonsonValidate(v *Validator) {}
+
+func (c *ConcurrencySystem) DoubleV1(ctx *Context, params *DoubleV1Params) (int, error) {
+	c.mux.Lock()
+	c.running++
+	c.max = max(c.max, c.running)
+	c.mux.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mux.Lock()
+	c.running--
+	c.mux.Unlock()
+
+	if params.Value < 0 {
+		return 0, errors.New("negative value")
+	}
+	return params.Value * 2, nil
+}
+
+func TestContextCallMethods(t *testing.T) {
+	factory := NewFactory()
+	testProvider := NewTestProvider()
+	factory.RegisterProvider(testProvider)
+	factory.RegisterProvider(NewTimeProvider())
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	newCalls := func(values ...int) []MethodCall {
+		calls := []MethodCall{}
+		for _, v := range values {
+			calls = append(calls, MethodCall{
+				Method:        "concurrency-system/double.v1",
+				RpcHttpMethod: RpcHttpMethodPost,
+				Payload:       &DoubleV1Params{Value: v},
+			})
+		}
+		return calls
+	}
+
+	t.Run("returns results in order of the calls", func(t *testing.T) {
+		system := &ConcurrencySystem{}
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+		methodHandler.RegisterSystem(system)
+		ctx := NewContext(context.Background(), factory, methodHandler)
+
+		results, err := ctx.CallMethods(newCalls(1, 2, 3, 4), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for idx, v := range results {
+			if v.Result != (idx+1)*2 {
+				t.Fatalf("expected result %d, got: %v", (idx+1)*2, v.Result)
+			}
+		}
+		if system.max < 2 {
+			t.Fatalf("expected calls to run concurrently, got: %d", system.max)
+		}
+	})
+
+	t.Run("bounds concurrency", func(t *testing.T) {
+		system := &ConcurrencySystem{}
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+		methodHandler.RegisterSystem(system)
+		ctx := NewContext(context.Background(), factory, methodHandler)
+
+		if _, err := ctx.CallMethods(newCalls(1, 2, 3, 4, 5), &CallMethodsOptions{MaxConcurrency: 2}); err != nil {
+			t.Fatal(err)
+		}
+		if system.max != 2 {
+			t.Fatalf("expected at most 2 concurrent calls, got: %d", system.max)
+		}
+	})
+
+	t.Run("collects errors per call", func(t *testing.T) {
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+		methodHandler.RegisterSystem(&ConcurrencySystem{})
+		ctx := NewContext(context.Background(), factory, methodHandler)
+
+		results, err := ctx.CallMethods(newCalls(1, -1, 3), nil)
+		if err == nil || err != results[1].Error {
+			t.Fatalf("expected error of second call, got: %v", err)
+		}
+		if results[0].Error != nil || results[2].Error != nil || results[2].Result != 6 {
+			t.Fatalf("expected other calls to succeed, got: %v", results)
+		}
+	})
+
+	t.Run("evaluates authentication per call", func(t *testing.T) {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		calls := []MethodCall{
+			{Method: "test-system/me.v1", RpcHttpMethod: RpcHttpMethodGet},
+			{Method: "test-system/current-time.v1", RpcHttpMethod: RpcHttpMethodGet},
+		}
+
+		testProvider.setLoggedIn(false)
+		results, err := ctx.CallMethods(calls, nil)
+		if err != ErrUnauthorized {
+			t.Fatalf("expected unauthorized error, got: %v", err)
+		}
+		if results[1].Error != nil {
+			t.Fatalf("expected public call to succeed, got: %v", results[1].Error)
+		}
+
+		testProvider.setLoggedIn(true)
+		if _, err := ctx.CallMethods(calls, nil); err != nil {
+			t.Fatalf("expected calls to succeed, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package jonson
+
+import (
+	"strings"
+)
+
+// ToCamelCase converts the provided string to camelCase
+func ToCamelCase(input string) string {
+	pascal := ToPascalCase(input)
+	if pascal == "" {
+		return ""
+	}
+	return strings.ToLower(pascal[0:1]) + pascal[1:]
+}
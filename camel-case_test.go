@@ -0,0 +1,39 @@
+package jonson
+
+import "testing"
+
+func TestToCamelCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"camelCase", "camelCase"},
+		{"PascalCase", "pascalCase"},
+		{"snake_case", "snakeCase"},
+		{"Pascal_Snake", "pascalSnake"},
+		{"SCREAMING-SNAKE", "screamingSnake"},
+		{"kebab-case", "kebabCase"},
+		{"Pascal-Kebab", "pascalKebab"},
+		{"SCREAMING-KEBAB", "screamingKebab"},
+		{"A", "a"},
+		{"AA", "aa"},
+		{"AAA", "aaa"},
+		{"AAAA", "aaaa"},
+		{"AaAa", "aaAa"},
+		{"HTTPRequest", "httpRequest"},
+		{"BatteryLifeValue", "batteryLifeValue"},
+		{"Id0Value", "id0Value"},
+		{"ID0Value", "id0Value"},
+		{"MyAPIv2", "myApIv2"},
+		{"_id", "id"},
+		{"user__name", "userName"},
+		{"a-", "a"},
+		{"-", ""},
+	}
+	for _, tt := range tests {
+		if got := ToCamelCase(tt.input); got != tt.expected {
+			t.Errorf("ToCamelCase(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
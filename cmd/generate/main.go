@@ -23,7 +23,6 @@ import (
 var (
 	fpath          = "."
 	jonsonPath     = "github.com/doejon/jonson"
-	apiNameMatcher = regexp.MustCompile(`^(.+)V([0-9]+)$`)
 	apiTypeMatcher = regexp.MustCompile(`(^|\n)@generate($|\n)`)
 )
 
@@ -195,6 +194,12 @@ func writeProcedureFile(fset *token.FileSet, pkgName string, listMethods []*ast.
 		// we will find them in the function signature.
 		rpcHttpMethod := getRpcHttpMethod(object)
 
+		// methods requiring authentication will be annotated
+		var authNote string
+		if getRequiresAuth(object) {
+			authNote = "\n// requires authentication (jonson.RequiresAuth)"
+		}
+
 		if len(object.Type.Params.List) > 0 {
 			lastArg := object.Type.Params.List[len(object.Type.Params.List)-1]
 			starExpr, ok := lastArg.Type.(*ast.StarExpr)
@@ -250,7 +255,7 @@ func writeProcedureFile(fset *token.FileSet, pkgName string, listMethods []*ast.
 		fmt.Fprintf(
 			wtr,
 			`
-// %s -- %s
+// %s -- %s%s
 func %s(ctx *jonson.Context%s) %s {
 	%s := ctx.CallMethod("%s", %s, %s, nil)
 	if err != nil {
@@ -260,7 +265,7 @@ func %s(ctx *jonson.Context%s) %s {
 	return %s
 }
 `,
-			pos.Filename, object.Name,
+			pos.Filename, object.Name, authNote,
 			object.Name, params, result,
 			vAssign, methodName, rpcHttpMethod, parArg,
 			errRet,
@@ -374,7 +379,8 @@ func main() {
 		for _, d := range f.Decls {
 			// methods
 			if fn, ok := d.(*ast.FuncDecl); ok {
-				if apiNameMatcher.MatchString(fn.Name.Name) {
+				// skip methods the method handler will not register, e.g. GetProfileV0
+				if _, version := jonson.SplitMethodName(fn.Name.Name); version > 0 {
 					listMethods = append(listMethods, fn)
 				}
 			}
@@ -424,3 +430,17 @@ func getRpcHttpMethod(decl *ast.FuncDecl) string {
 
 	return m
 }
+
+// getRequiresAuth returns true in case the method requires jonson.RequiresAuth
+func getRequiresAuth(decl *ast.FuncDecl) bool {
+	for _, v := range decl.Type.Params.List {
+		intf, ok := v.Type.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		if intf.Sel.Name == "RequiresAuth" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+var (
+	size = 32
+)
+
+func init() {
+	flag.IntVar(&size, "size", size, "key size in bytes: 16, 24 or 32")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-size <bytes>]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "prints a random hex encoded key to be used by jonson.NewAESSecret\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+// genkey generates a cryptographically random key
+// to be used by jonson.NewAESSecret
+func main() {
+	if size != 16 && size != 24 && size != 32 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("error: %s", err)
+	}
+	fmt.Println(hex.EncodeToString(key))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/doejon/jonson"
+)
+
+var (
+	key string
+)
+
+func init() {
+	flag.StringVar(&key, "key", key, "hex encoded AES key used by jonson.NewAESSecret")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -key <hex key> [encoded debug string]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "in case no encoded debug string is passed, strings will be read line by line from stdin\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+// newSecret returns the secret;
+// jonson.NewAESSecret panics on invalid keys
+func newSecret(key string) (secret jonson.Secret, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return jonson.NewAESSecret(key), nil
+}
+
+// secret decodes debug strings which have been encoded
+// using jonson.AESSecret, e.g. an error's data.debug
+func main() {
+	if key == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	secret, err := newSecret(key)
+	if err != nil {
+		log.Fatalf("error: %s", err)
+	}
+
+	if flag.NArg() > 0 {
+		for _, v := range flag.Args() {
+			decoded, err := secret.Decode(v)
+			if err != nil {
+				log.Fatalf("error: %s", err)
+			}
+			fmt.Println(decoded)
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<22)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		decoded, err := secret.Decode(line)
+		if err != nil {
+			log.Fatalf("error: %s", err)
+		}
+		fmt.Println(decoded)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("error: %s", err)
+	}
+}
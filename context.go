@@ -7,17 +7,28 @@ import (
 	"reflect"
 	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 var TypeContext = reflect.TypeOf((**Context)(nil)).Elem()
 
+var errInstanceNotFound = errors.New("instance not found")
+
 type Context struct {
 	parent        context.Context
 	factory       *Factory
 	methodHandler *MethodHandler
-	values        []*valueItem
-	finalized     bool
+	// values keep the order of storage for finalization;
+	// index allows for looking up values by their type
+	values    []*valueItem
+	index     map[reflect.Type]*valueItem
+	finalized bool
+	// resolving bounds the resolution of a provider, see
+	// MethodHandlerOptions.ProviderTimeout; Done, Err and Deadline
+	// consult it instead of parent while it is set
+	resolving context.Context
 }
 
 type Finalizeable interface {
@@ -28,6 +39,26 @@ type valueItem struct {
 	rt    reflect.Type
 	val   any
 	valid bool
+	// borrowed values have been passed from another context;
+	// they will be finalized by the context owning them
+	borrowed bool
+	// carried values will be passed to contexts created by
+	// CallMethod regardless of being Shareable
+	carried bool
+	// owner refers to the item of the context which stored the value
+	// in case the value has been passed on from another context
+	owner *valueItem
+	// holders counts the detached contexts sharing the ownership of
+	// the value; the last owner to be finalized finalizes the value
+	holders atomic.Int32
+}
+
+// root returns the item of the context which stored the value
+func (v *valueItem) root() *valueItem {
+	if v.owner != nil {
+		return v.owner
+	}
+	return v
 }
 
 func NewContext(parent context.Context, factory *Factory, methodHandler *MethodHandler) *Context {
@@ -35,6 +66,8 @@ func NewContext(parent context.Context, factory *Factory, methodHandler *MethodH
 		parent:        parent,
 		factory:       factory,
 		methodHandler: methodHandler,
+		// most calls require a handful of values
+		values: make([]*valueItem, 0, 8),
 	}
 	ctx.StoreValue(TypeContext, ctx)
 	return ctx
@@ -44,6 +77,22 @@ func (c *Context) Fork() *Context {
 	return NewContext(c, c.factory, c.methodHandler)
 }
 
+// forkShareable forks the context and keeps
+// those values that have been marked explicitly shareable
+// or have been stored using StoreSharedValue
+func (c *Context) forkShareable(parent context.Context) *Context {
+	forked := NewContext(parent, c.factory, c.methodHandler)
+	for _, v := range c.values {
+		if !v.valid {
+			continue
+		}
+		if _, ok := v.val.(Shareable); ok || v.carried {
+			forked.borrowValue(v)
+		}
+	}
+	return forked
+}
+
 // Clone a context in order to use a context in a new goroutine.
 // Clone copies all values from the existing context to a new context
 // ignoring those values not yet fully initialized.
@@ -53,23 +102,93 @@ func (c *Context) Clone() *Context {
 		if !v.valid {
 			continue
 		}
-		forked.values = append(forked.values, v)
+		forked.appendValue(v)
 	}
 	return c
 }
 
-func (c *Context) StoreValue(rt reflect.Type, val any) {
-	for i := range c.values {
-		if c.values[i].rt == rt {
-			panic(errors.New("value of type " + rt.String() + " is already stored"))
+// Detach returns a new context which is not tied to the lifecycle of the
+// current request, e.g. to do work within a goroutine after a response has been sent.
+// In contrast to Clone and Fork, the detached context will neither be canceled
+// once the request is done nor be finalized by the request: the caller is
+// responsible to call Finalize once done.
+// Same as CallMethod, only those values marked Shareable or stored using
+// StoreSharedValue will be kept;
+// std values stored using WithStdValue remain available.
+// The detached context shares the ownership of the kept values: finalizeable
+// values will be finalized once both the request and the detached context
+// have been finalized.
+func (c *Context) Detach() *Context {
+	forked := NewContext(context.WithoutCancel(c), c.factory, c.methodHandler)
+	for _, v := range c.values {
+		if !v.valid {
+			continue
+		}
+		if _, ok := v.val.(Shareable); ok || v.carried {
+			forked.shareValue(v)
 		}
 	}
+	return forked
+}
+
+func (c *Context) StoreValue(rt reflect.Type, val any) {
+	c.storeValue(rt, val)
+}
+
+// StoreSharedValue stores a value which will be passed to contexts created
+// by CallMethod, even though it does not implement Shareable, e.g. a request-scoped
+// tenant id. The value still respects the impersonation boundary: it will only be
+// passed to an impersonated context in case it implements ShareableAcrossImpersonation.
+func (c *Context) StoreSharedValue(rt reflect.Type, val any) {
+	c.storeValue(rt, val).carried = true
+}
 
-	c.values = append(c.values, &valueItem{
+// borrowValue stores a value owned by another context;
+// borrowed values will not be finalized by the current context
+func (c *Context) borrowValue(v *valueItem) {
+	item := c.storeValue(v.rt, v.val)
+	item.borrowed = true
+	item.carried = v.carried
+	item.owner = v.root()
+}
+
+// shareValue stores a value owned by another context and
+// takes over a share of its ownership, see Detach
+func (c *Context) shareValue(v *valueItem) {
+	root := v.root()
+	root.holders.Add(1)
+	item := c.storeValue(v.rt, v.val)
+	item.carried = v.carried
+	item.owner = root
+}
+
+func (c *Context) storeValue(rt reflect.Type, val any) *valueItem {
+	if _, exists := c.index[rt]; exists {
+		panic(errors.New("value of type " + rt.String() + " is already stored"))
+	}
+
+	item := &valueItem{
 		rt:    rt,
 		val:   val,
 		valid: true,
-	})
+	}
+	c.appendValue(item)
+	return item
+}
+
+// appendValue appends a value item and indexes it by its type;
+// items without a type (e.g. deferred cleanups) will not be indexed
+func (c *Context) appendValue(item *valueItem) {
+	c.values = append(c.values, item)
+	if item.rt == nil {
+		return
+	}
+	if c.index == nil {
+		c.index = map[reflect.Type]*valueItem{}
+	}
+	if _, exists := c.index[item.rt]; !exists {
+		c.index[item.rt] = item
+	}
 }
 
 // Invalidate invalidates a value @ context.
@@ -86,6 +205,7 @@ func (c *Context) Invalidate(rt ...reflect.Type) {
 	vals := []*valueItem{}
 	for _, v := range c.values {
 		if _, ok := toInvalidate[v.rt]; ok {
+			delete(c.index, v.rt)
 			continue
 		}
 		vals = append(vals, v)
@@ -119,19 +239,26 @@ func (c *Context) Require(inst reflect.Type) any {
 		panic(errors.New("inst must either be a ptr or an interface"))
 	}
 
-	for i := range c.values {
-		if c.values[i].rt == inst {
-			if !c.values[i].valid {
-				panic(c.debugRecursionLoop(inst))
-			}
-			return c.values[i].val
+	if v, ok := c.index[inst]; ok {
+		if !v.valid {
+			panic(c.debugRecursionLoop(inst))
 		}
+		return v.val
 	}
 
 	v := &valueItem{
 		rt: inst,
 	}
-	c.values = append(c.values, v)
+	c.appendValue(v)
+
+	// in case the provider panics, the placeholder must not
+	// stay behind: otherwise any subsequent Require for the same
+	// type would be reported as a recursion loop
+	defer func() {
+		if !v.valid {
+			c.removeValue(v)
+		}
+	}()
 
 	// try to instantiate
 	v.val = c.factory.Provide(c, inst)
@@ -139,6 +266,19 @@ func (c *Context) Require(inst reflect.Type) any {
 	return v.val
 }
 
+// removeValue removes a single value item from context
+func (c *Context) removeValue(item *valueItem) {
+	if c.index[item.rt] == item {
+		delete(c.index, item.rt)
+	}
+	for i := range c.values {
+		if c.values[i] == item {
+			c.values = append(c.values[:i], c.values[i+1:]...)
+			return
+		}
+	}
+}
+
 // GetValue returns a value that's been previously required;
 // In case the value does _not_ exist, an error
 // will be returned;
@@ -150,14 +290,55 @@ func (c *Context) GetValue(inst reflect.Type) (any, error) {
 	if c.finalized {
 		return nil, fmt.Errorf("context is already finalized")
 	}
-	for _, v := range c.values {
-		if v.rt == inst && v.valid {
-			return v.val, nil
+	if v, ok := c.index[inst]; ok && v.valid {
+		return v.val, nil
+	}
+	return nil, errInstanceNotFound
+}
+
+// deferredFunc is a cleanup registered using Defer
+type deferredFunc func(err error)
+
+func (d deferredFunc) Finalize(errs []error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = getRecoverError(r)
 		}
+	}()
+	switch len(errs) {
+	case 0:
+		d(nil)
+	case 1:
+		d(errs[0])
+	default:
+		d(errors.Join(errs...))
+	}
+	return nil
+}
+
+// Defer registers a cleanup which will be run once the context gets finalized,
+// e.g. to close a temporary file within a handler without implementing a provider.
+// The cleanup receives the error the context has been finalized with (if any).
+// Cleanups run in LIFO order alongside Finalizeable values: a cleanup registered
+// after a value has been required runs before the value gets finalized.
+// Panics within a cleanup will be recovered and returned by Finalize.
+func (c *Context) Defer(fn func(err error)) {
+	if c.finalized {
+		panic(errors.New("context is already finalized"))
 	}
-	return nil, errors.New("instance not found")
+	c.appendValue(&valueItem{
+		val:   deferredFunc(fn),
+		valid: true,
+	})
 }
 
+// Finalize finalizes all Finalizeable values owned by the context, from the
+// most recently stored value to the first one, passing the errors collected so far.
+// Finalize is idempotent: once finalized, subsequent calls return the given error
+// and finalizers will not run again, e.g. in case a handler finalized its context
+// before the framework does. Values passed from another context, such as the
+// Shareable values of a context created by CallMethod or Detach, will only be
+// finalized by the context owning them.
 func (c *Context) Finalize(err error) error {
 	if c.finalized {
 		return err
@@ -168,16 +349,29 @@ func (c *Context) Finalize(err error) error {
 	if err != nil {
 		errors = append(errors, err)
 	}
+	given := len(errors)
 
 	// finalize from end to front
 	for i := len(c.values) - 1; i >= 0; i-- {
+		if c.values[i].borrowed {
+			continue
+		}
+		// values shared with detached contexts will be
+		// finalized by the last owner
+		if c.values[i].root().holders.Add(-1) >= 0 {
+			continue
+		}
 		if f, ok := c.values[i].val.(Finalizeable); ok {
 			if e := f.Finalize(errors); e != nil {
 				errors = append(errors, e)
 			}
 		}
 	}
+	if len(errors) > given && c.methodHandler != nil {
+		c.reportFinalizeErrors(errors[given:])
+	}
 	c.values = nil
+	c.index = nil
 
 	if len(errors) == 0 {
 		return nil
@@ -206,6 +400,21 @@ func (c *Context) Finalize(err error) error {
 	})
 }
 
+// reportFinalizeErrors passes the errors of failed
+// finalizers to the method handler's hooks
+func (c *Context) reportFinalizeErrors(errs []error) {
+	if len(c.methodHandler.onFinalizeErrors) == 0 {
+		return
+	}
+	var meta *RpcMeta
+	if v, ok := c.index[TypeRpcMeta]; ok && v.valid {
+		meta, _ = v.val.(*RpcMeta)
+	}
+	for _, fn := range c.methodHandler.onFinalizeErrors {
+		fn(meta, errs)
+	}
+}
+
 func (c *Context) CallMethod(method string, rpcHttpMethod RpcHttpMethod, payload any, bindata []byte) (any, error) {
 	v, err := c.methodHandler.CallMethod(c, method, rpcHttpMethod, payload, bindata)
 	if err != nil {
@@ -217,19 +426,99 @@ func (c *Context) CallMethod(method string, rpcHttpMethod RpcHttpMethod, payload
 	return nil, nil
 }
 
+// memoStore keeps memoized values of a context tree.
+// The store is shareable, hence available to contexts forked
+// using CallMethod, but not across impersonation: an impersonated
+// scope will start with an empty store.
+type memoStore struct {
+	Shareable
+
+	mux    sync.Mutex
+	values map[string]any
+}
+
+var typeMemoStore = reflect.TypeOf((**memoStore)(nil)).Elem()
+
+// Memoize returns the value memoized for key within the current context tree.
+// In case no value has been memoized yet, fn will be called and its result
+// will be kept for the lifetime of the context; errors will not be memoized.
+// Memoized values are passed to contexts created by CallMethod
+// in case the first value has been memoized before calling CallMethod;
+// memoized values are never shared across impersonation.
+func (c *Context) Memoize(key string, fn func() (any, error)) (any, error) {
+	var store *memoStore
+	if v, err := c.GetValue(typeMemoStore); err == nil {
+		store = v.(*memoStore)
+	} else {
+		store = &memoStore{
+			values: map[string]any{},
+		}
+		c.StoreValue(typeMemoStore, store)
+	}
+
+	store.mux.Lock()
+	v, ok := store.values[key]
+	store.mux.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	store.mux.Lock()
+	store.values[key] = v
+	store.mux.Unlock()
+	return v, nil
+}
+
+// WithStdValue stores a value within the underlying go standard library context
+// using context.WithValue. In contrast to StoreValue, values are not bound to
+// their type but to the given key; use this method in case you need to pass
+// the context to third-party libraries which read their own context keys.
+// The context itself is returned to allow for chaining.
+// Be aware: same as Require(), WithStdValue() is _not_ thread-safe.
+func (c *Context) WithStdValue(key, val any) *Context {
+	c.parent = context.WithValue(c.parent, key, val)
+	return c
+}
+
+// StdValue returns the value stored for the given key
+// within a go standard library context, casted to T.
+// In case the value does not exist or is not of type T,
+// the zero value of T and false will be returned.
+func StdValue[T any](ctx context.Context, key any) (T, bool) {
+	v, ok := ctx.Value(key).(T)
+	return v, ok
+}
+
 // methods below are for fulfilling the go library context.Context interface
 
 var _ (context.Context) = (*Context)(nil)
 
+// Deadline returns the deadline of the incoming request (if any).
+// Providers doing network I/O should respect the deadline
+// by passing the context to their clients.
 func (c *Context) Deadline() (time.Time, bool) {
+	if c.resolving != nil {
+		return c.resolving.Deadline()
+	}
 	return c.parent.Deadline()
 }
 
 func (c *Context) Done() <-chan struct{} {
+	if c.resolving != nil {
+		return c.resolving.Done()
+	}
 	return c.parent.Done()
 }
 
 func (c *Context) Err() error {
+	if c.resolving != nil {
+		return c.resolving.Err()
+	}
 	return c.parent.Err()
 }
 
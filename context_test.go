@@ -0,0 +1,480 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Transaction is a shareable value counting its finalizations
+type Transaction struct {
+	Shareable
+	finalized int
+}
+
+func (t *Transaction) Finalize(errs []error) error {
+	t.finalized++
+	return nil
+}
+
+var TypeTransaction = reflect.TypeOf((**Transaction)(nil)).Elem()
+
+type TransactionProvider struct {
+}
+
+func (t *TransactionProvider) NewTransaction(ctx *Context) *Transaction {
+	return &Transaction{}
+}
+
+// Tenant is a plain request-scoped value
+type Tenant struct {
+	ID string
+}
+
+var TypeTenant = reflect.TypeOf((**Tenant)(nil)).Elem()
+
+type TenantSystem struct {
+}
+
+func (s *TenantSystem) TenantV1(ctx *Context) (string, error) {
+	v, err := ctx.GetValue(TypeTenant)
+	if err != nil {
+		return "", err
+	}
+	return v.(*Tenant).ID, nil
+}
+
+func (s *TenantSystem) NestedTenantV1(ctx *Context) (any, error) {
+	return ctx.CallMethod("tenant-system/tenant.v1", RpcHttpMethodPost, nil, nil)
+}
+
+type FinalizeSystem struct {
+}
+
+func (f *FinalizeSystem) TransactionV1(ctx *Context) (int, error) {
+	tx := ctx.Require(TypeTransaction).(*Transaction)
+	// finalizing within the handler must not
+	// run finalizers again once the framework finalizes
+	if err := ctx.Finalize(nil); err != nil {
+		return 0, err
+	}
+	return tx.finalized, nil
+}
+
+type MemoizeSystem struct {
+}
+
+func (m *MemoizeSystem) MemoizedV1(ctx *Context) (any, error) {
+	return ctx.Memoize("value", func() (any, error) {
+		return "inner", nil
+	})
+}
+
+func TestContext(t *testing.T) {
+	fac := NewFactory()
+	methodHandler := NewMethodHandler(fac, NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(&MemoizeSystem{})
+
+	t.Run("std values are available through the context.Context interface", func(t *testing.T) {
+		type key struct{}
+		type upstreamKey struct{}
+
+		parent := context.WithValue(context.Background(), upstreamKey{}, "upstream")
+		ctx := NewContext(parent, fac, methodHandler).WithStdValue(key{}, 42)
+
+		v, ok := StdValue[int](ctx, key{})
+		if !ok || v != 42 {
+			t.Fatalf("expected std value to equal 42, got: %d", v)
+		}
+
+		s, ok := StdValue[string](ctx, upstreamKey{})
+		if !ok || s != "upstream" {
+			t.Fatalf("expected upstream value to equal 'upstream', got: %s", s)
+		}
+
+		// forked contexts will see the values as well
+		v, ok = StdValue[int](ctx.Fork(), key{})
+		if !ok || v != 42 {
+			t.Fatalf("expected forked std value to equal 42, got: %d", v)
+		}
+	})
+
+	t.Run("detached contexts outlive the request", func(t *testing.T) {
+		type key struct{}
+
+		parent, cancel := context.WithTimeout(context.Background(), time.Minute)
+		ctx := NewContext(parent, fac, methodHandler).WithStdValue(key{}, 42)
+		ctx.StoreValue(TypeTime, NewRealTime())
+		ctx.StoreValue(TypeRpcMeta, &RpcMeta{Method: "test"})
+
+		detached := ctx.Detach()
+		cancel()
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatalf("expected finalize to succeed, got: %s", err)
+		}
+
+		if detached.Err() != nil {
+			t.Fatalf("expected detached context not to be canceled, got: %s", detached.Err())
+		}
+		if _, ok := detached.Deadline(); ok {
+			t.Fatal("expected detached context not to have a deadline")
+		}
+		if v, ok := StdValue[int](detached, key{}); !ok || v != 42 {
+			t.Fatalf("expected std value to equal 42, got: %d", v)
+		}
+		if _, err := detached.GetValue(TypeTime); err != nil {
+			t.Fatalf("expected shareable value to be kept, got: %s", err)
+		}
+		if _, err := detached.GetValue(TypeRpcMeta); err == nil {
+			t.Fatal("expected non-shareable value to be dropped")
+		}
+		if err := detached.Finalize(nil); err != nil {
+			t.Fatalf("expected detached finalize to succeed, got: %s", err)
+		}
+	})
+
+	t.Run("detached contexts own their shareable values", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, methodHandler)
+		resource := &DetachedResource{}
+		ctx.StoreValue(TypeDetachedResource, resource)
+
+		detached := ctx.Detach()
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatalf("expected finalize to succeed, got: %s", err)
+		}
+		if resource.finalized != 0 {
+			t.Fatal("expected resource not to be finalized by the request while being detached")
+		}
+
+		// the detached context may still use the value
+		v, err := detached.GetValue(TypeDetachedResource)
+		if err != nil {
+			t.Fatalf("expected shareable value to be kept, got: %s", err)
+		}
+		if v.(*DetachedResource).finalized != 0 {
+			t.Fatal("expected detached value not to be finalized")
+		}
+
+		if err := detached.Finalize(nil); err != nil {
+			t.Fatalf("expected detached finalize to succeed, got: %s", err)
+		}
+		if resource.finalized != 1 {
+			t.Fatalf("expected resource to be finalized once by the detached context, got: %d", resource.finalized)
+		}
+	})
+
+	t.Run("finalizers run once on double finalize", func(t *testing.T) {
+		fac := NewFactory()
+		fac.RegisterProvider(&TransactionProvider{})
+		ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+		tx := ctx.Require(TypeTransaction).(*Transaction)
+
+		for i := 0; i < 2; i++ {
+			if err := ctx.Finalize(nil); err != nil {
+				t.Fatalf("expected finalize to succeed, got: %s", err)
+			}
+		}
+		if tx.finalized != 1 {
+			t.Fatalf("expected finalizer to run once, got: %d", tx.finalized)
+		}
+
+		// finalize keeps passing the given error
+		errTest := errors.New("test")
+		if err := ctx.Finalize(errTest); err != errTest {
+			t.Fatalf("expected finalize to return given error, got: %v", err)
+		}
+	})
+
+	t.Run("finalizers run once across nested calls", func(t *testing.T) {
+		fac := NewFactory()
+		fac.RegisterProvider(&TransactionProvider{})
+		methodHandler := NewMethodHandler(fac, NewDebugSecret(), nil)
+		methodHandler.RegisterSystem(&FinalizeSystem{})
+
+		// a transaction created within the called method
+		// will be finalized by the called method's context
+		ctx := NewContext(context.Background(), fac, methodHandler)
+		v, err := ctx.CallMethod("finalize-system/transaction.v1", RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatalf("expected call to succeed, got: %s", err)
+		}
+		if v.(int) != 1 {
+			t.Fatalf("expected finalizer to run once within method, got: %d", v)
+		}
+
+		// a transaction shared with the called method
+		// will only be finalized by its owner
+		ctx = NewContext(context.Background(), fac, methodHandler)
+		tx := ctx.Require(TypeTransaction).(*Transaction)
+		v, err = ctx.CallMethod("finalize-system/transaction.v1", RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatalf("expected call to succeed, got: %s", err)
+		}
+		if v.(int) != 0 {
+			t.Fatalf("expected shared transaction not to be finalized by method, got: %d", v)
+		}
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatalf("expected finalize to succeed, got: %s", err)
+		}
+		if tx.finalized != 1 {
+			t.Fatalf("expected finalizer to run once, got: %d", tx.finalized)
+		}
+	})
+
+	t.Run("reports finalize errors to hooks", func(t *testing.T) {
+		fac := NewFactory()
+		methodHandler := NewMethodHandler(fac, NewDebugSecret(), nil)
+		var (
+			reported     []error
+			reportedMeta *RpcMeta
+		)
+		methodHandler.OnFinalizeErrors(func(meta *RpcMeta, errs []error) {
+			reportedMeta = meta
+			reported = errs
+		})
+
+		ctx := NewContext(context.Background(), fac, methodHandler)
+		ctx.StoreValue(TypeRpcMeta, &RpcMeta{Method: "test"})
+		errCommit := errors.New("commit failed")
+		ctx.Defer(func(err error) {
+			panic(errCommit)
+		})
+
+		errTest := errors.New("test")
+		err := ctx.Finalize(errTest)
+		if e, ok := err.(*Error); !ok || e.Code != ErrInternal.Code {
+			t.Fatalf("expected client to receive internal error, got: %v", err)
+		}
+		if reportedMeta == nil || reportedMeta.Method != "test" {
+			t.Fatalf("expected hook to receive rpc meta, got: %v", reportedMeta)
+		}
+		// the error passed to Finalize is not a finalize error
+		if len(reported) != 1 || !errors.Is(reported[0], errCommit) {
+			t.Fatalf("expected hook to receive the original finalize error, got: %v", reported)
+		}
+	})
+
+	t.Run("deferred cleanups run in LIFO order alongside finalizers", func(t *testing.T) {
+		fac := NewFactory()
+		fac.RegisterProvider(&TransactionProvider{})
+		ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+
+		order := []string{}
+		ctx.Defer(func(err error) {
+			order = append(order, "first")
+		})
+		tx := ctx.Require(TypeTransaction).(*Transaction)
+		ctx.Defer(func(err error) {
+			// the transaction has not been finalized yet
+			order = append(order, "second")
+			if tx.finalized != 0 {
+				t.Fatalf("expected transaction to be finalized after cleanup, got: %d", tx.finalized)
+			}
+		})
+
+		errTest := errors.New("test")
+		var received error
+		ctx.Defer(func(err error) {
+			received = err
+			order = append(order, "third")
+		})
+
+		if err := ctx.Finalize(errTest); err != errTest {
+			t.Fatalf("expected finalize to return given error, got: %v", err)
+		}
+		if received != errTest {
+			t.Fatalf("expected cleanup to receive error, got: %v", received)
+		}
+		if len(order) != 3 || order[0] != "third" || order[1] != "second" || order[2] != "first" {
+			t.Fatalf("expected cleanups to run in LIFO order, got: %v", order)
+		}
+		if tx.finalized != 1 {
+			t.Fatalf("expected finalizer to run once, got: %d", tx.finalized)
+		}
+
+		// cleanups will not run twice
+		if err := ctx.Finalize(nil); err != nil {
+			t.Fatalf("expected finalize to succeed, got: %s", err)
+		}
+		if len(order) != 3 {
+			t.Fatalf("expected cleanups to run once, got: %v", order)
+		}
+	})
+
+	t.Run("panics within deferred cleanups are recovered", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, methodHandler)
+
+		called := false
+		ctx.Defer(func(err error) {
+			called = true
+		})
+		ctx.Defer(func(err error) {
+			panic("cleanup failed")
+		})
+
+		if err := ctx.Finalize(nil); err == nil {
+			t.Fatal("expected finalize to fail")
+		}
+		if !called {
+			t.Fatal("expected remaining cleanups to run")
+		}
+	})
+
+	t.Run("shared values are carried across method calls", func(t *testing.T) {
+		fac := NewFactory()
+		fac.RegisterProvider(NewImpersonatorProvider())
+		fac.RegisterProvider(NewAuthProvider(&testAuthClient{isAuthenticated: true}))
+		methodHandler := NewMethodHandler(fac, NewDebugSecret(), nil)
+		methodHandler.RegisterSystem(&TenantSystem{})
+
+		ctx := NewContext(context.Background(), fac, methodHandler)
+		ctx.StoreSharedValue(TypeTenant, &Tenant{ID: "acme"})
+
+		for _, method := range []string{"tenant-system/tenant.v1", "tenant-system/nested-tenant.v1"} {
+			v, err := ctx.CallMethod(method, RpcHttpMethodPost, nil, nil)
+			if err != nil {
+				t.Fatalf("expected %s to succeed, got: %s", method, err)
+			}
+			if v.(string) != "acme" {
+				t.Fatalf("expected tenant to be carried, got: %v", v)
+			}
+		}
+
+		// plain values are not carried
+		ctx = NewContext(context.Background(), fac, methodHandler)
+		ctx.StoreValue(TypeTenant, &Tenant{ID: "acme"})
+		if _, err := ctx.CallMethod("tenant-system/tenant.v1", RpcHttpMethodPost, nil, nil); err == nil {
+			t.Fatal("expected plain value not to be carried")
+		}
+
+		// shared values respect the impersonation boundary
+		ctx = NewContext(context.Background(), fac, methodHandler)
+		ctx.StoreSharedValue(TypeTenant, &Tenant{ID: "acme"})
+		err := RequireImpersonator(ctx).Impersonate(testAccountUuid, func(ctx *Context) error {
+			if _, err := ctx.GetValue(TypeTenant); err == nil {
+				t.Fatal("expected shared value not to be passed across impersonation")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected impersonation to succeed, got: %s", err)
+		}
+	})
+
+	t.Run("invalidated values will be provided again", func(t *testing.T) {
+		fac := NewFactory()
+		fac.RegisterProvider(&TransactionProvider{})
+		ctx := NewContext(context.Background(), fac, methodHandler)
+
+		tx := ctx.Require(TypeTransaction).(*Transaction)
+		if ctx.Require(TypeTransaction).(*Transaction) != tx {
+			t.Fatal("expected value to be provided once")
+		}
+		ctx.Invalidate(TypeTransaction)
+		if _, err := ctx.GetValue(TypeTransaction); err == nil {
+			t.Fatal("expected invalidated value to be removed")
+		}
+		if ctx.Require(TypeTransaction).(*Transaction) == tx {
+			t.Fatal("expected invalidated value to be provided again")
+		}
+	})
+
+	t.Run("std value returns false on type mismatch", func(t *testing.T) {
+		type key struct{}
+
+		ctx := NewContext(context.Background(), fac, methodHandler).WithStdValue(key{}, 42)
+		if _, ok := StdValue[string](ctx, key{}); ok {
+			t.Fatal("expected std value lookup to fail on type mismatch")
+		}
+	})
+
+	t.Run("memoized values are computed once", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, methodHandler)
+
+		calls := 0
+		for i := 0; i < 2; i++ {
+			v, err := ctx.Memoize("value", func() (any, error) {
+				calls++
+				return "outer", nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != "outer" {
+				t.Fatalf("expected memoized value to equal 'outer', got: %v", v)
+			}
+		}
+		if calls != 1 {
+			t.Fatalf("expected fn to be called once, got: %d", calls)
+		}
+	})
+
+	t.Run("memoized values are shared with internal calls", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fac, methodHandler)
+		ctx.Memoize("value", func() (any, error) {
+			return "outer", nil
+		})
+
+		v, err := ctx.CallMethod("memoize-system/memoized.v1", RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != "outer" {
+			t.Fatalf("expected memoized value of the outer context, got: %v", v)
+		}
+	})
+}
+
+func BenchmarkContextRequire(b *testing.B) {
+	// register 50 providers of distinct types
+	factory := NewFactory()
+	types := make([]reflect.Type, 50)
+	for i := range types {
+		rt := reflect.PointerTo(reflect.StructOf([]reflect.StructField{{
+			Name: "Field" + strconv.Itoa(i),
+			Type: reflect.TypeOf(0),
+		}}))
+		fn := reflect.MakeFunc(reflect.FuncOf([]reflect.Type{TypeContext}, []reflect.Type{rt}, false), func(args []reflect.Value) []reflect.Value {
+			return []reflect.Value{reflect.New(rt.Elem())}
+		})
+		factory.RegisterProviderFunc(fn.Interface())
+		types[i] = rt
+	}
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+
+	b.Run("require 50 providers", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ctx := NewContext(context.Background(), factory, methodHandler)
+			for _, rt := range types {
+				ctx.Require(rt)
+			}
+			ctx.Finalize(nil)
+		}
+	})
+
+	b.Run("require already provided", func(b *testing.B) {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		for _, rt := range types {
+			ctx.Require(rt)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			ctx.Require(types[i%len(types)])
+		}
+	})
+}
+
+type DetachedResource struct {
+	Shareable
+	finalized int
+}
+
+var TypeDetachedResource = reflect.TypeOf((**DetachedResource)(nil)).Elem()
+
+func (d *DetachedResource) Finalize(errs []error) error {
+	d.finalized++
+	return nil
+}
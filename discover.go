@@ -0,0 +1,299 @@
+package jonson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiscoverMethod is the name of the OpenRPC service discovery method
+const DiscoverMethod = "rpc.discover"
+
+// OpenRpcDocument describes the registered endpoints following
+// the OpenRPC specification (https://spec.open-rpc.org)
+type OpenRpcDocument struct {
+	OpenRpc    string             `json:"openrpc"`
+	Info       *OpenRpcInfo       `json:"info"`
+	Methods    []*OpenRpcMethod   `json:"methods"`
+	Components *OpenRpcComponents `json:"components,omitempty"`
+}
+
+type OpenRpcInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenRpcMethod struct {
+	Name           string                      `json:"name"`
+	Params         []*OpenRpcContentDescriptor `json:"params"`
+	Result         *OpenRpcContentDescriptor   `json:"result"`
+	ParamStructure string                      `json:"paramStructure"`
+	// RequiresAuth is a specification extension marking
+	// methods which require RequiresAuth
+	RequiresAuth bool `json:"x-requires-auth,omitempty"`
+}
+
+type OpenRpcContentDescriptor struct {
+	Name     string         `json:"name"`
+	Required bool           `json:"required,omitempty"`
+	Schema   map[string]any `json:"schema"`
+}
+
+type OpenRpcComponents struct {
+	Schemas map[string]map[string]any `json:"schemas,omitempty"`
+}
+
+// RegisterDiscover registers the rpc.discover method returning an OpenRPC
+// document of all endpoints, which allows standard JSON-RPC explorers to
+// browse the api. The document exposes the api's surface: only register
+// the method in case you want the api to be public.
+// Params will be described by-name using the fields of each method's params;
+// results using the method's result type.
+func (m *MethodHandler) RegisterDiscover(title string, version string) {
+	if _, exists := m.endpoints[DiscoverMethod]; exists {
+		panic(errors.New("method handler: endpoint already registered"))
+	}
+	info := &OpenRpcInfo{
+		Title:   title,
+		Version: version,
+	}
+	m.registerEndpoint(DiscoverMethod, &MethodDefinition{
+		HandlerFunc: func(ctx *Context) (*OpenRpcDocument, error) {
+			return m.discover(ctx, info), nil
+		},
+	})
+}
+
+// discover builds the OpenRPC document of all registered endpoints;
+// methods gated behind a disabled feature flag will be omitted
+func (m *MethodHandler) discover(ctx *Context, info *OpenRpcInfo) *OpenRpcDocument {
+	names := make([]string, 0, len(m.endpoints))
+	for k, endpoint := range m.endpoints {
+		if k != DiscoverMethod && m.isFeatureEnabled(ctx, endpoint) {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	s := newOpenRpcSchemas()
+	doc := &OpenRpcDocument{
+		OpenRpc: "1.2.6",
+		Info:    info,
+		Methods: []*OpenRpcMethod{},
+	}
+	for _, name := range names {
+		endpoint := m.endpoints[name]
+		method := &OpenRpcMethod{
+			Name:           name,
+			Params:         []*OpenRpcContentDescriptor{},
+			ParamStructure: "by-name",
+			Result: &OpenRpcContentDescriptor{
+				Name:   "result",
+				Schema: map[string]any{},
+			},
+			RequiresAuth: endpoint.requiresAuth,
+		}
+		if endpoint.paramsType != nil {
+			for _, f := range openRpcFields(endpoint.paramsType) {
+				method.Params = append(method.Params, &OpenRpcContentDescriptor{
+					Name:     f.name,
+					Required: f.required,
+					Schema:   s.schema(f.rt),
+				})
+			}
+		}
+		if rt := endpoint.handlerFunc.Type(); rt.NumOut() == 2 {
+			method.Result.Schema = s.schema(rt.Out(0))
+		}
+		doc.Methods = append(doc.Methods, method)
+	}
+	if len(s.schemas) > 0 {
+		doc.Components = &OpenRpcComponents{
+			Schemas: s.schemas,
+		}
+	}
+	return doc
+}
+
+// openRpcField is a json field of a struct
+type openRpcField struct {
+	name     string
+	rt       reflect.Type
+	required bool
+}
+
+// openRpcFields returns the json fields of a struct in order of their
+// declaration; fields are required unless tagged omitempty or default
+func openRpcFields(rt reflect.Type) []*openRpcField {
+	out := []*openRpcField{}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			et := f.Type
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				out = append(out, openRpcFields(et)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		_, hasDefault := f.Tag.Lookup("default")
+		out = append(out, &openRpcField{
+			name:     name,
+			rt:       f.Type,
+			required: !strings.Contains(opts, "omitempty") && !hasDefault,
+		})
+	}
+	return out
+}
+
+var (
+	typeTime       = reflect.TypeOf(time.Time{})
+	typeRawMessage = reflect.TypeOf(json.RawMessage{})
+	typeMarshaler  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// openRpcSchemas builds json schemas; named structs will be
+// referenced using components to support recursive types
+type openRpcSchemas struct {
+	schemas map[string]map[string]any
+	names   map[reflect.Type]string
+}
+
+func newOpenRpcSchemas() *openRpcSchemas {
+	return &openRpcSchemas{
+		schemas: map[string]map[string]any{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+func (s *openRpcSchemas) schema(rt reflect.Type) map[string]any {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	switch rt {
+	case typeTime:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case typeDuration:
+		return map[string]any{"type": "integer"}
+	case typeRawMessage:
+		return map[string]any{}
+	case typeJsonStream.Elem():
+		return map[string]any{"type": "array"}
+	}
+	if rt.Implements(typeMarshaler) || reflect.PointerTo(rt).Implements(typeMarshaler) {
+		// custom encoding, we cannot know the schema
+		return map[string]any{}
+	}
+
+	switch rt.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			// []byte will be encoded as base64 string
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": s.schema(rt.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(rt.Elem())}
+	case reflect.Struct:
+		if rt.Name() == "" {
+			return s.structSchema(rt)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + s.ref(rt)}
+	default:
+		return map[string]any{}
+	}
+}
+
+// ref returns the component name of a named struct
+// and adds its schema to the components
+func (s *openRpcSchemas) ref(rt reflect.Type) string {
+	if name, ok := s.names[rt]; ok {
+		return name
+	}
+	base := openRpcComponentName(rt)
+	name := base
+	for i := 2; ; i++ {
+		if _, taken := s.schemas[name]; !taken {
+			break
+		}
+		// different types sharing the same name
+		name = base + strconv.Itoa(i)
+	}
+	s.names[rt] = name
+	// reserve the name before building the schema: the type might reference itself
+	s.schemas[name] = map[string]any{}
+	s.schemas[name] = s.structSchema(rt)
+	return name
+}
+
+// matchQualifiedName matches the (package qualified) type names
+// within the type arguments of a generic type's name
+var matchQualifiedName = regexp.MustCompile(`[A-Za-z0-9_./-]+`)
+
+// openRpcComponentName returns the component name of a named struct;
+// generic types (e.g. BatchResult[*github.com/acme/app.Profile]) will be named
+// after their type arguments (BatchResult_Profile) in order to match
+// the component names allowed by OpenRPC (^[a-zA-Z0-9.\-_]+$)
+func openRpcComponentName(rt reflect.Type) string {
+	name := rt.Name()
+	base, args, ok := strings.Cut(name, "[")
+	if !ok {
+		return name
+	}
+	parts := []string{base}
+	for _, arg := range matchQualifiedName.FindAllString(args, -1) {
+		// strip the package path
+		if i := strings.LastIndex(arg, "."); i >= 0 {
+			arg = arg[i+1:]
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, "_")
+}
+
+func (s *openRpcSchemas) structSchema(rt reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	for _, f := range openRpcFields(rt) {
+		properties[f.name] = s.schema(f.rt)
+		if f.required {
+			required = append(required, f.name)
+		}
+	}
+	out := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
@@ -0,0 +1,166 @@
+package jonson
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type TreeNode struct {
+	Name      string      `json:"name"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Children  []*TreeNode `json:"children,omitempty"`
+}
+
+type TreeSystem struct{}
+
+type TreeV1Params struct {
+	Params
+	Depth int    `json:"depth" default:"1"`
+	Root  string `json:"root"`
+}
+
+func (t *TreeV1Params) JonsonValidate(v *Validator) {}
+
+func (t *TreeSystem) TreeV1(ctx *Context, params *TreeV1Params) (*TreeNode, error) {
+	return &TreeNode{Name: params.Root}, nil
+}
+
+type BulkTreeSystem struct{}
+
+func (b *BulkTreeSystem) BulkTreeV1(ctx *Context) (*BatchResult[*TreeNode], error) {
+	return NewBatchResult[*TreeNode](ctx), nil
+}
+
+func TestOpenRpcComponentName(t *testing.T) {
+	tests := []struct {
+		rt       reflect.Type
+		expected string
+	}{
+		{reflect.TypeOf(TreeNode{}), "TreeNode"},
+		{reflect.TypeOf(BatchResult[*TreeNode]{}), "BatchResult_TreeNode"},
+		{reflect.TypeOf(BatchItem[map[string][]time.Time]{}), "BatchItem_map_string_Time"},
+	}
+	for _, tt := range tests {
+		if got := openRpcComponentName(tt.rt); got != tt.expected {
+			t.Errorf("openRpcComponentName(%s) = %v, expected %v", tt.rt, got, tt.expected)
+		}
+	}
+}
+
+func TestMethodHandlerRegisterDiscover(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(&TreeSystem{})
+	RegisterSystemOf[Greeter](methodHandler, &EnglishGreeter{})
+
+	t.Run("is not registered by default", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, newHttpRpcRequest(DiscoverMethod, nil))
+		errResp, err := parseHttpRpcResponse(wtr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil || errResp.Code != ErrMethodNotFound.Code {
+			t.Fatalf("expected method not found, got: %v", errResp)
+		}
+	})
+
+	methodHandler.RegisterDiscover("Test API", "1.0.0")
+
+	wtr := httptest.NewRecorder()
+	NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, newHttpRpcRequest(DiscoverMethod, nil))
+	doc := &OpenRpcDocument{}
+	errResp, err := parseHttpRpcResponse(wtr, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errResp != nil {
+		t.Fatalf("expected discover to succeed, got: %v", errResp)
+	}
+
+	t.Run("lists all endpoints", func(t *testing.T) {
+		if doc.Info.Title != "Test API" || doc.Info.Version != "1.0.0" {
+			t.Fatalf("expected info to be set, got: %v", doc.Info)
+		}
+		names := []string{}
+		for _, v := range doc.Methods {
+			names = append(names, v.Name)
+		}
+		expected := []string{"greeter/greeting.v1", "greeter/ping.v1", "tree-system/tree.v1"}
+		if len(names) != len(expected) {
+			t.Fatalf("expected methods %v, got: %v", expected, names)
+		}
+		for idx := range expected {
+			if names[idx] != expected[idx] {
+				t.Fatalf("expected methods %v, got: %v", expected, names)
+			}
+		}
+	})
+
+	t.Run("describes params and results", func(t *testing.T) {
+		tree := doc.Methods[2]
+		if len(tree.Params) != 2 {
+			t.Fatalf("expected 2 params, got: %d", len(tree.Params))
+		}
+		if tree.Params[0].Name != "depth" || tree.Params[0].Required || tree.Params[0].Schema["type"] != "integer" {
+			t.Fatalf("expected optional integer depth, got: %+v", tree.Params[0])
+		}
+		if tree.Params[1].Name != "root" || !tree.Params[1].Required || tree.Params[1].Schema["type"] != "string" {
+			t.Fatalf("expected required string root, got: %+v", tree.Params[1])
+		}
+		if ref := tree.Result.Schema["$ref"]; ref != "#/components/schemas/TreeNode" {
+			t.Fatalf("expected result to reference TreeNode, got: %v", ref)
+		}
+
+		ping := doc.Methods[1]
+		if len(ping.Params) != 0 || len(ping.Result.Schema) != 0 {
+			t.Fatalf("expected void method without params, got: %+v", ping)
+		}
+	})
+
+	t.Run("describes recursive types using components", func(t *testing.T) {
+		b, _ := json.Marshal(doc.Components.Schemas["TreeNode"])
+		expected := `{"properties":{"children":{"items":{"$ref":"#/components/schemas/TreeNode"},"type":"array"},"createdAt":{"format":"date-time","type":"string"},"name":{"type":"string"}},"required":["name","createdAt"],"type":"object"}`
+		if string(b) != expected {
+			t.Fatalf("expected schema %s, got: %s", expected, b)
+		}
+	})
+
+	t.Run("names components of generic types", func(t *testing.T) {
+		methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		methodHandler.RegisterSystem(&BulkTreeSystem{})
+		methodHandler.RegisterDiscover("Test API", "1.0.0")
+
+		wtr := httptest.NewRecorder()
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, newHttpRpcRequest(DiscoverMethod, nil))
+		doc := &OpenRpcDocument{}
+		errResp, err := parseHttpRpcResponse(wtr, doc)
+		if err != nil || errResp != nil {
+			t.Fatalf("expected discover to succeed, got: %v, %v", err, errResp)
+		}
+		if ref := doc.Methods[0].Result.Schema["$ref"]; ref != "#/components/schemas/BatchResult_TreeNode" {
+			t.Fatalf("expected result to reference BatchResult_TreeNode, got: %v", ref)
+		}
+		matchComponent := regexp.MustCompile(`^[a-zA-Z0-9.\-_]+$`)
+		for name := range doc.Components.Schemas {
+			if !matchComponent.MatchString(name) {
+				t.Fatalf("expected valid component name, got: %s", name)
+			}
+		}
+	})
+
+	t.Run("can be called internally", func(t *testing.T) {
+		ctx := NewContext(context.Background(), methodHandler.factory, methodHandler)
+		res, err := ctx.CallMethod(DiscoverMethod, RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.(*OpenRpcDocument).Methods) != 3 {
+			t.Fatalf("expected 3 methods, got: %v", res)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package jonson
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// DryRunHeader allows clients to request a dry run of a method
+// served over http: params will be decoded and validated and all
+// providers will be resolved, but the method itself will not be called.
+const DryRunHeader = "X-Dry-Run"
+
+// DryRun must be required by methods which allow for dry runs,
+// e.g. to validate forms against the real params without executing business logic.
+// Dry runs of methods not requiring DryRun will be rejected with ErrInvalidRequest.
+// Example:
+// func (s *System) UpdateProfileV1(ctx *jonson.Context, _ jonson.DryRun, params *UpdateProfileV1Params) error{}
+type DryRun interface {
+	__dryRun()
+}
+type dryRun struct{}
+
+func (d *dryRun) __dryRun() {}
+
+var TypeDryRun = reflect.TypeOf((*DryRun)(nil)).Elem()
+
+// dryRunProvider provides the DryRun marker.
+// The dryRunProvider will be provided automatically.
+type dryRunProvider struct {
+}
+
+func newDryRunProvider() *dryRunProvider {
+	return &dryRunProvider{}
+}
+
+func (d *dryRunProvider) NewDryRun(ctx *Context) DryRun {
+	return &dryRun{}
+}
+
+// isDryRun returns true in case the client requested a dry run;
+// dry runs can only be requested for calls served over http
+func isDryRun(ctx *Context) bool {
+	meta, err := ctx.GetValue(TypeRpcMeta)
+	if err != nil {
+		return false
+	}
+	if src := meta.(*RpcMeta).Source; src != RpcSourceHttp && src != RpcSourceHttpRpc {
+		return false
+	}
+	req, err := ctx.GetValue(TypeHttpRequest)
+	if err != nil {
+		return false
+	}
+	v, _ := strconv.ParseBool(req.(*HttpRequest).Header.Get(DryRunHeader))
+	return v
+}
+
+// allowsDryRun returns true in case the handler requires DryRun
+func allowsDryRun(rt reflect.Type) bool {
+	for i := 0; i < rt.NumIn(); i++ {
+		if rt.In(i) == TypeDryRun {
+			return true
+		}
+	}
+	return false
+}
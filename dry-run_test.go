@@ -0,0 +1,104 @@
+package jonson
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type DryRunSystem struct {
+	calls int
+}
+
+type DryRunUpdateV1Params struct {
+	Params
+	Name string `json:"name"`
+}
+
+func (p *DryRunUpdateV1Params) JonsonValidate(v *Validator) {
+	if len(p.Name) < 2 {
+		v.Path("name").Message("name too short")
+	}
+}
+
+func (d *DryRunSystem) UpdateV1(ctx *Context, _ DryRun, params *DryRunUpdateV1Params) error {
+	d.calls++
+	return nil
+}
+
+func (d *DryRunSystem) DeleteV1(ctx *Context, params *DryRunUpdateV1Params) error {
+	d.calls++
+	return nil
+}
+
+func TestDryRun(t *testing.T) {
+	system := &DryRunSystem{}
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(system)
+
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc")
+	httpHandler := NewHttpMethodHandler(methodHandler)
+
+	callRpc := func(t *testing.T, method string, name string, dryRun bool) *Error {
+		t.Helper()
+		wtr := httptest.NewRecorder()
+		req := newHttpRpcRequest(method, &DryRunUpdateV1Params{Name: name})
+		if dryRun {
+			req.Header.Set(DryRunHeader, "true")
+		}
+		httpRpcHandler.Handle(wtr, req)
+		errResp, err := parseHttpRpcResponse(wtr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return errResp
+	}
+
+	t.Run("dry run validates params without calling the method", func(t *testing.T) {
+		system.calls = 0
+		if err := callRpc(t, "dry-run-system/update.v1", "Silvio", true); err != nil {
+			t.Fatalf("expected dry run to succeed, got: %v", err)
+		}
+		if err := callRpc(t, "dry-run-system/update.v1", "S", true); err == nil || err.Code != ErrInvalidParams.Code {
+			t.Fatalf("expected validation error, got: %v", err)
+		}
+		if system.calls != 0 {
+			t.Fatalf("expected method not to be called, got %d calls", system.calls)
+		}
+	})
+
+	t.Run("method is called without dry run", func(t *testing.T) {
+		system.calls = 0
+		if err := callRpc(t, "dry-run-system/update.v1", "Silvio", false); err != nil {
+			t.Fatalf("expected call to succeed, got: %v", err)
+		}
+		if system.calls != 1 {
+			t.Fatalf("expected method to be called once, got %d calls", system.calls)
+		}
+	})
+
+	t.Run("dry run of methods which did not opt in is rejected", func(t *testing.T) {
+		system.calls = 0
+		if err := callRpc(t, "dry-run-system/delete.v1", "Silvio", true); err == nil || err.Code != ErrInvalidRequest.Code {
+			t.Fatalf("expected invalid request error, got: %v", err)
+		}
+		if system.calls != 0 {
+			t.Fatalf("expected method not to be called, got %d calls", system.calls)
+		}
+	})
+
+	t.Run("http method handler supports dry runs", func(t *testing.T) {
+		system.calls = 0
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/dry-run-system/update.v1", bytes.NewReader([]byte(`{"name": "Silvio"}`)))
+		req.Header.Set(DryRunHeader, "true")
+		httpHandler.Handle(wtr, req)
+		if wtr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got: %d", wtr.Code)
+		}
+		if system.calls != 0 {
+			t.Fatalf("expected method not to be called, got %d calls", system.calls)
+		}
+	})
+}
@@ -62,6 +62,90 @@ func (e Error) CloneWithData(data *ErrorData) *Error {
 	return &e
 }
 
+// WithDetails returns a copy with the supplied details
+// appended to existing details
+func (e Error) WithDetails(errs ...*Error) *Error {
+	data := e.cloneData()
+	data.Details = append(data.Details, errs...)
+	e.Data = data
+	return &e
+}
+
+// WithPath returns a copy with the supplied path set
+func (e Error) WithPath(path ...string) *Error {
+	data := e.cloneData()
+	data.Path = append([]string{}, path...)
+	e.Data = data
+	return &e
+}
+
+// cloneData returns a copy of the error's data;
+// in case no data has been set, empty data will be returned
+func (e Error) cloneData() *ErrorData {
+	if e.Data == nil {
+		return &ErrorData{}
+	}
+	data := *e.Data
+	data.Path = append([]string(nil), e.Data.Path...)
+	data.Details = append([]*Error(nil), e.Data.Details...)
+	return &data
+}
+
+// SanitizeOptions define which information will be
+// removed from an error when calling Error.Sanitize.
+// The zero value removes debug information and all details,
+// keeping the top level code, message and path only.
+type SanitizeOptions struct {
+	// KeepDebug keeps debug information
+	KeepDebug bool
+	// MaxDepth removes all details below the given depth;
+	// the top level error has depth 0. Use a negative
+	// value to keep all details.
+	MaxDepth int
+	// Redact allows us to redact specific errors:
+	// errors the function returns true for will lose
+	// their data entirely (including nested details)
+	Redact func(*Error) bool
+}
+
+// Sanitize returns a deep copy of the error with
+// information removed according to the given options.
+// Use Sanitize before handing errors to untrusted clients.
+// The original error will not be modified.
+func (e *Error) Sanitize(opts *SanitizeOptions) *Error {
+	if opts == nil {
+		opts = &SanitizeOptions{}
+	}
+	return e.sanitize(opts, 0)
+}
+
+func (e *Error) sanitize(opts *SanitizeOptions, depth int) *Error {
+	out := &Error{
+		Code:    e.Code,
+		Message: e.Message,
+	}
+	if e.Data == nil || (opts.Redact != nil && opts.Redact(e)) {
+		return out
+	}
+
+	data := &ErrorData{
+		Path: append([]string(nil), e.Data.Path...),
+	}
+	if opts.KeepDebug {
+		data.Debug = e.Data.Debug
+	}
+	if opts.MaxDepth < 0 || depth < opts.MaxDepth {
+		for _, v := range e.Data.Details {
+			data.Details = append(data.Details, v.sanitize(opts, depth+1))
+		}
+	}
+
+	if len(data.Path) > 0 || len(data.Details) > 0 || len(data.Debug) > 0 {
+		out.Data = data
+	}
+	return out
+}
+
 func (e Error) String() string {
 	data := ""
 	if e.Data != nil {
@@ -0,0 +1,112 @@
+package jonson
+
+import "slices"
+
+// ErrorInspector allows us to search an error tree,
+// consisting of an error and its nested details, for errors
+// matching all given matchers.
+//
+//	found := jonson.NewErrorInspector(err).WithCode(10000).WithPath("name").FindFirst()
+type ErrorInspector struct {
+	err      *Error
+	matchers []func(*Error) bool
+}
+
+// NewErrorInspector returns a new error inspector
+// for the given error
+func NewErrorInspector(err *Error) *ErrorInspector {
+	return &ErrorInspector{
+		err: err,
+	}
+}
+
+// Where adds an arbitrary matcher; only errors
+// the predicate returns true for will be found
+func (i *ErrorInspector) Where(predicate func(*Error) bool) *ErrorInspector {
+	i.matchers = append(i.matchers, predicate)
+	return i
+}
+
+// WithCode matches errors with the given code
+func (i *ErrorInspector) WithCode(code int) *ErrorInspector {
+	return i.Where(func(e *Error) bool {
+		return e.Code == code
+	})
+}
+
+// WithMessage matches errors with the given message
+func (i *ErrorInspector) WithMessage(message string) *ErrorInspector {
+	return i.Where(func(e *Error) bool {
+		return e.Message == message
+	})
+}
+
+// WithPath matches errors with the given path
+func (i *ErrorInspector) WithPath(path ...string) *ErrorInspector {
+	return i.Where(func(e *Error) bool {
+		if e.Data == nil {
+			return len(path) == 0
+		}
+		return slices.Equal(e.Data.Path, path)
+	})
+}
+
+func (i *ErrorInspector) matches(e *Error) bool {
+	for _, v := range i.matchers {
+		if !v(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// walk walks the error tree depth-first; the walk
+// stops as soon as fn returns false
+func (i *ErrorInspector) walk(e *Error, fn func(*Error) bool) bool {
+	if e == nil {
+		return true
+	}
+	if !fn(e) {
+		return false
+	}
+	if e.Data == nil {
+		return true
+	}
+	for _, v := range e.Data.Details {
+		if !i.walk(v, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// FindAll returns all matching errors
+func (i *ErrorInspector) FindAll() []*Error {
+	out := []*Error{}
+	i.walk(i.err, func(e *Error) bool {
+		if i.matches(e) {
+			out = append(out, e)
+		}
+		return true
+	})
+	return out
+}
+
+// FindFirst returns the first matching error;
+// in case no error matches, nil will be returned
+func (i *ErrorInspector) FindFirst() *Error {
+	var out *Error
+	i.walk(i.err, func(e *Error) bool {
+		if i.matches(e) {
+			out = e
+			return false
+		}
+		return true
+	})
+	return out
+}
+
+// Count returns the number of matching errors
+func (i *ErrorInspector) Count() int {
+	return len(i.FindAll())
+}
@@ -0,0 +1,54 @@
+package jonson
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestErrorInspector(t *testing.T) {
+	err := ErrInvalidParams.WithDetails(
+		(&Error{Code: 10000, Message: "name too short"}).WithPath("name"),
+		(&Error{Code: 10001, Message: "street too short"}).WithPath("address", "street"),
+		(&Error{Code: 10002, Message: "address invalid"}).WithDetails(
+			(&Error{Code: 10000, Message: "zip too short"}).WithPath("address", "zip"),
+		),
+	)
+
+	t.Run("finds by code", func(t *testing.T) {
+		if cnt := NewErrorInspector(err).WithCode(10000).Count(); cnt != 2 {
+			t.Fatalf("expected 2 errors, got: %d", cnt)
+		}
+	})
+
+	t.Run("finds by path", func(t *testing.T) {
+		found := NewErrorInspector(err).WithPath("address", "zip").FindFirst()
+		if found == nil || found.Message != "zip too short" {
+			t.Fatalf("expected zip error, got: %v", found)
+		}
+	})
+
+	t.Run("finds by predicate", func(t *testing.T) {
+		matchStreet := regexp.MustCompile("^(street|zip)")
+		found := NewErrorInspector(err).Where(func(e *Error) bool {
+			return matchStreet.MatchString(e.Message)
+		}).FindAll()
+		if len(found) != 2 {
+			t.Fatalf("expected 2 errors, got: %d", len(found))
+		}
+	})
+
+	t.Run("predicates compose with existing matchers", func(t *testing.T) {
+		found := NewErrorInspector(err).WithCode(10000).Where(func(e *Error) bool {
+			return e.Data != nil && len(e.Data.Path) == 2
+		}).FindAll()
+		if len(found) != 1 || found[0].Message != "zip too short" {
+			t.Fatalf("expected zip error only, got: %v", found)
+		}
+	})
+
+	t.Run("returns nil on no match", func(t *testing.T) {
+		if found := NewErrorInspector(err).WithMessage("unknown").FindFirst(); found != nil {
+			t.Fatalf("expected no match, got: %v", found)
+		}
+	})
+}
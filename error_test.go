@@ -0,0 +1,105 @@
+package jonson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	t.Run("with details appends details to a copy", func(t *testing.T) {
+		first := &Error{Code: 1, Message: "first"}
+		second := &Error{Code: 2, Message: "second"}
+
+		err := ErrInvalidParams.WithDetails(first)
+		errNext := err.WithDetails(second)
+
+		if ErrInvalidParams.Data != nil {
+			t.Fatal("expected original error to stay untouched")
+		}
+		if len(err.Data.Details) != 1 || err.Data.Details[0] != first {
+			t.Fatalf("expected a single detail, got: %v", err.Data.Details)
+		}
+		if len(errNext.Data.Details) != 2 || errNext.Data.Details[1] != second {
+			t.Fatalf("expected two details, got: %v", errNext.Data.Details)
+		}
+		if errNext.Code != ErrInvalidParams.Code || errNext.Message != ErrInvalidParams.Message {
+			t.Fatalf("expected code and message to be kept, got: %d %s", errNext.Code, errNext.Message)
+		}
+	})
+
+	t.Run("with path sets the path and keeps existing data", func(t *testing.T) {
+		err := ErrInvalidParams.CloneWithData(&ErrorData{Debug: "debug"}).WithPath("profile", "name")
+
+		if err.Data.Debug != "debug" {
+			t.Fatalf("expected debug to be kept, got: %s", err.Data.Debug)
+		}
+
+		b, _ := json.Marshal(err)
+		if string(b) != `{"code":-32602,"message":"Invalid params","data":{"path":["profile","name"],"debug":"debug"}}` {
+			t.Fatalf("unexpected wire format: %s", string(b))
+		}
+	})
+
+	t.Run("sanitize removes debug and details by default", func(t *testing.T) {
+		err := ErrInternal.CloneWithData(&ErrorData{Debug: "secret"}).WithDetails(
+			&Error{Code: 1, Message: "first", Data: &ErrorData{Debug: "secret"}},
+		)
+
+		sanitized := err.Sanitize(nil)
+		if sanitized.Data != nil {
+			t.Fatalf("expected data to be removed, got: %v", sanitized.Data)
+		}
+		if sanitized.Code != ErrInternal.Code || sanitized.Message != ErrInternal.Message {
+			t.Fatalf("expected code and message to be kept, got: %d %s", sanitized.Code, sanitized.Message)
+		}
+		if err.Data.Debug != "secret" || err.Data.Details[0].Data.Debug != "secret" {
+			t.Fatal("expected original error to stay untouched")
+		}
+	})
+
+	t.Run("sanitize keeps details up to max depth", func(t *testing.T) {
+		err := ErrInvalidParams.WithDetails(
+			(&Error{Code: 1, Message: "first", Data: &ErrorData{Debug: "secret"}}).WithPath("name").WithDetails(
+				&Error{Code: 2, Message: "second"},
+			),
+			&Error{Code: 3, Message: "third"},
+		)
+
+		sanitized := err.Sanitize(&SanitizeOptions{MaxDepth: 1})
+		if len(sanitized.Data.Details) != 2 {
+			t.Fatalf("expected two details, got: %d", len(sanitized.Data.Details))
+		}
+		first := sanitized.Data.Details[0]
+		if first.Data.Debug != "" || len(first.Data.Details) != 0 {
+			t.Fatalf("expected debug and nested details to be removed, got: %v", first.Data)
+		}
+		if first.Data.Path[0] != "name" {
+			t.Fatalf("expected path to be kept, got: %v", first.Data.Path)
+		}
+
+		all := err.Sanitize(&SanitizeOptions{MaxDepth: -1, KeepDebug: true})
+		if NewErrorInspector(all).Count() != 4 {
+			t.Fatalf("expected all errors to be kept, got: %d", NewErrorInspector(all).Count())
+		}
+		if all.Data.Details[0].Data.Debug != "secret" {
+			t.Fatal("expected debug to be kept")
+		}
+	})
+
+	t.Run("sanitize redacts matching errors", func(t *testing.T) {
+		err := ErrInvalidParams.WithDetails(
+			(&Error{Code: 1, Message: "first"}).WithPath("name"),
+			(&Error{Code: 2, Message: "second"}).WithPath("internal"),
+		)
+
+		sanitized := err.Sanitize(&SanitizeOptions{MaxDepth: -1, Redact: func(e *Error) bool {
+			return e.Code == 2
+		}})
+		if sanitized.Data.Details[0].Data == nil {
+			t.Fatal("expected first detail to keep its data")
+		}
+		if sanitized.Data.Details[1].Data != nil {
+			t.Fatalf("expected second detail to be redacted, got: %v", sanitized.Data.Details[1].Data)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package jonson
+
+import (
+	"reflect"
+	"sync"
+)
+
+// EventHandler handles a published event's payload
+type EventHandler func(ctx *Context, payload any)
+
+// EventBusProvider provides a process-wide event bus
+// allowing systems to react to events of other systems
+// without knowing each other:
+//
+//	bus := jonson.NewEventBusProvider()
+//	bus.Subscribe("account.updated", func(ctx *jonson.Context, payload any) {
+//		// notify the account
+//	})
+//	fac.RegisterProvider(bus)
+//
+// Each subscriber will be called within its own context forked from the
+// publishing context, hence with its own provider scope; values marked
+// Shareable will be kept. By default, events are delivered synchronously;
+// use WithAsyncDelivery to deliver events within their own goroutines.
+type EventBusProvider struct {
+	mux         sync.RWMutex
+	subscribers map[string][]EventHandler
+	async       bool
+	wg          sync.WaitGroup
+}
+
+// NewEventBusProvider returns a new event bus provider
+func NewEventBusProvider() *EventBusProvider {
+	return &EventBusProvider{
+		subscribers: map[string][]EventHandler{},
+	}
+}
+
+// WithAsyncDelivery delivers events within a separate goroutine per subscriber;
+// Publish will return immediately. The subscriber's context will be detached
+// from the publishing context (see Context.Detach): it will not be canceled
+// once the publishing context has been canceled and Shareable values stay
+// usable until the subscriber returned.
+func (e *EventBusProvider) WithAsyncDelivery() *EventBusProvider {
+	e.async = true
+	return e
+}
+
+// Subscribe registers a handler for the given topic
+func (e *EventBusProvider) Subscribe(topic string, handler EventHandler) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.subscribers[topic] = append(e.subscribers[topic], handler)
+}
+
+// Wait blocks until all asynchronously delivered events have been handled
+func (e *EventBusProvider) Wait() {
+	e.wg.Wait()
+}
+
+func (e *EventBusProvider) NewEventBus(ctx *Context) *EventBus {
+	return &EventBus{
+		provider: e,
+		ctx:      ctx,
+	}
+}
+
+// EventBus publishes events to the subscribers of the process-wide event bus.
+// The event bus is bound to the context it has been required with.
+type EventBus struct {
+	provider *EventBusProvider
+	ctx      *Context
+}
+
+var TypeEventBus = reflect.TypeOf((**EventBus)(nil)).Elem()
+
+// RequireEventBus returns the event bus
+func RequireEventBus(ctx *Context) *EventBus {
+	if v := ctx.Require(TypeEventBus); v != nil {
+		return v.(*EventBus)
+	}
+	return nil
+}
+
+// Subscribe registers a handler for the given topic
+func (e *EventBus) Subscribe(topic string, handler EventHandler) {
+	e.provider.Subscribe(topic, handler)
+}
+
+// Publish delivers the payload to all subscribers of the given topic.
+// Panicking subscribers will be logged and do not affect other subscribers.
+func (e *EventBus) Publish(topic string, payload any) {
+	e.provider.mux.RLock()
+	handlers := append([]EventHandler{}, e.provider.subscribers[topic]...)
+	e.provider.mux.RUnlock()
+
+	for _, handler := range handlers {
+		// contexts are not thread-safe: fork
+		// the subscriber's context right away
+		if !e.provider.async {
+			e.deliver(e.ctx.forkShareable(e.ctx), topic, handler, payload)
+			continue
+		}
+		// the subscriber may outlive the request: detach
+		// in order to share the ownership of the values
+		ctx := e.ctx.Detach()
+		e.provider.wg.Add(1)
+		go func() {
+			defer e.provider.wg.Done()
+			e.deliver(ctx, topic, handler, payload)
+		}()
+	}
+}
+
+func (e *EventBus) deliver(ctx *Context, topic string, handler EventHandler, payload any) {
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			err = getRecoverError(r)
+			ctx.factory.Logger().Warn("event bus: subscriber panicked", "topic", topic, "error", err)
+		}
+		ctx.Finalize(err)
+	}()
+	handler(ctx, payload)
+}
@@ -0,0 +1,135 @@
+package jonson
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	t.Run("delivers events synchronously to all subscribers", func(t *testing.T) {
+		provider := NewEventBusProvider()
+		fac := NewFactory()
+		fac.RegisterProvider(provider)
+
+		received := []any{}
+		provider.Subscribe("account.updated", func(ctx *Context, payload any) {
+			received = append(received, payload)
+		})
+		provider.Subscribe("account.updated", func(ctx *Context, payload any) {
+			received = append(received, payload)
+		})
+		provider.Subscribe("account.deleted", func(ctx *Context, payload any) {
+			t.Fatal("expected subscriber of other topic not to be called")
+		})
+
+		ctx := NewContext(context.Background(), fac, nil)
+		RequireEventBus(ctx).Publish("account.updated", "alice")
+		if len(received) != 2 || received[0] != "alice" || received[1] != "alice" {
+			t.Fatalf("expected both subscribers to receive the event, got: %v", received)
+		}
+	})
+
+	t.Run("subscribers use their own provider scope", func(t *testing.T) {
+		provider := NewEventBusProvider()
+		fac := NewFactory()
+		testProvider := NewTestProvider()
+		testProvider.setLoggedIn(true)
+		fac.RegisterProvider(provider)
+		fac.RegisterProvider(testProvider)
+
+		var subscriberCtx *Context
+		provider.Subscribe("topic", func(ctx *Context, payload any) {
+			subscriberCtx = ctx
+			if _, err := ctx.GetValue(TypeTestPrivate); err == nil {
+				t.Fatal("expected non-shareable values not to be passed to subscribers")
+			}
+		})
+
+		ctx := NewContext(context.Background(), fac, nil)
+		RequireTestPrivate(ctx)
+		RequireEventBus(ctx).Publish("topic", nil)
+		if subscriberCtx == nil || subscriberCtx == ctx {
+			t.Fatal("expected subscriber to be called with a forked context")
+		}
+	})
+
+	t.Run("a panicking subscriber does not affect other subscribers", func(t *testing.T) {
+		provider := NewEventBusProvider()
+		fac := NewFactory()
+		fac.RegisterProvider(provider)
+
+		called := false
+		provider.Subscribe("topic", func(ctx *Context, payload any) {
+			panic("subscriber failed")
+		})
+		provider.Subscribe("topic", func(ctx *Context, payload any) {
+			called = true
+		})
+
+		ctx := NewContext(context.Background(), fac, nil)
+		RequireEventBus(ctx).Publish("topic", nil)
+		if !called {
+			t.Fatal("expected second subscriber to be called")
+		}
+	})
+
+	t.Run("delivers events asynchronously", func(t *testing.T) {
+		provider := NewEventBusProvider().WithAsyncDelivery()
+		fac := NewFactory()
+		fac.RegisterProvider(provider)
+
+		mux := sync.Mutex{}
+		received := 0
+		for i := 0; i < 3; i++ {
+			provider.Subscribe("topic", func(ctx *Context, payload any) {
+				mux.Lock()
+				defer mux.Unlock()
+				received++
+			})
+		}
+
+		parent, cancel := context.WithCancel(context.Background())
+		ctx := NewContext(parent, fac, nil)
+		RequireEventBus(ctx).Publish("topic", nil)
+		cancel()
+		provider.Wait()
+
+		if received != 3 {
+			t.Fatalf("expected 3 subscribers to receive the event, got: %d", received)
+		}
+	})
+
+	t.Run("async subscribers keep shareable values usable", func(t *testing.T) {
+		provider := NewEventBusProvider().WithAsyncDelivery()
+		fac := NewFactory()
+		fac.RegisterProvider(provider)
+
+		resource := &DetachedResource{}
+		release := make(chan struct{})
+		finalized := -1
+		provider.Subscribe("topic", func(ctx *Context, payload any) {
+			<-release
+			v, err := ctx.GetValue(TypeDetachedResource)
+			if err != nil {
+				t.Errorf("expected shareable value to be passed, got: %s", err)
+				return
+			}
+			finalized = v.(*DetachedResource).finalized
+		})
+
+		ctx := NewContext(context.Background(), fac, nil)
+		ctx.StoreValue(TypeDetachedResource, resource)
+		RequireEventBus(ctx).Publish("topic", nil)
+		ctx.Finalize(nil)
+		close(release)
+		provider.Wait()
+
+		if finalized != 0 {
+			t.Fatalf("expected value not to be finalized while the subscriber uses it, got: %d", finalized)
+		}
+		if resource.finalized != 1 {
+			t.Fatalf("expected value to be finalized once the subscriber returned, got: %d", resource.finalized)
+		}
+	})
+}
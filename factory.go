@@ -2,7 +2,10 @@ package jonson
 
 import (
 	"log/slog"
+	"maps"
 	"reflect"
+	"runtime"
+	"sort"
 	"strings"
 )
 
@@ -15,6 +18,17 @@ type Factory struct {
 type boundMethod struct {
 	this   reflect.Value
 	method reflect.Value
+	// name is the name of the constructor, e.g. (*jonson.TimeProvider).NewTime
+	name string
+}
+
+// ProviderInfo describes a registered provider
+type ProviderInfo struct {
+	// Type is the provided type
+	Type reflect.Type
+	// Constructor is the name of the method or func
+	// constructing the type, e.g. (*jonson.TimeProvider).NewTime
+	Constructor string
 }
 
 type FactoryOptions struct {
@@ -49,7 +63,11 @@ func NewFactory(options ...*FactoryOptions) *Factory {
 	// and will be available by default to all
 	// calls
 	out.RegisterProvider(newHttpMethodProvider())
+	// methods may allow for dry runs
+	out.RegisterProvider(newDryRunProvider())
+	out.RegisterProvider(newRequiresAuthProvider())
 	out.RegisterProvider(newLoggerProvider(opts.Logger, opts.LoggerOptions))
+	out.RegisterProvider(newRequestMetaProvider())
 	out.logger = opts.Logger
 
 	return out
@@ -67,7 +85,7 @@ func (f *Factory) Provide(ctx *Context, rt reflect.Type) any {
 
 	var pres []reflect.Value
 
-	if !bm.this.IsValid() || bm.this.IsNil() {
+	if !bm.this.IsValid() {
 		pres = bm.method.Call([]reflect.Value{refctx})
 	} else {
 		pres = bm.method.Call([]reflect.Value{bm.this, refctx})
@@ -110,14 +128,17 @@ func (f *Factory) RegisterProviderFunc(fn any) {
 
 	f.providers[rtfno] = boundMethod{
 		method: reflect.ValueOf(fn),
+		name:   runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name(),
 	}
 }
 
 // RegisterProvider registers a new Provider and panics on error
-// The provider needs to be a pointer to a struct which provides
+// The provider needs to be a value (usually a pointer to a struct) which provides
 // methods accepting *jonson.Context and returning a single type.
 // The method's name needs to be equal to the returned type's name
 // and start with New.
+// Providers passed as an interface (e.g. a mock implementing a provider interface)
+// will be registered using their dynamic type.
 // Example:
 //
 //	type Provider struct {}
@@ -137,11 +158,15 @@ func (f *Factory) RegisterProviderFunc(fn any) {
 //	fac := jonson.NewFactory()
 //	fac.RegisterProvider(&Provider{})
 func (f *Factory) RegisterProvider(provider any) {
-	// step 1 - check if we have a ptr to a struct
+	// step 1 - check if we have a value providing methods
 	rv := reflect.ValueOf(provider)
-	rt := reflect.TypeOf(provider)
-	if rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Struct {
-		panic("factory: must pass ptr to struct")
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		panic("factory: must not pass a nil provider")
+	}
+	rt := rv.Type()
+	if rt.Kind() != reflect.Ptr && !hasNewMethod(rt) && hasNewMethod(reflect.PointerTo(rt)) {
+		// methods with pointer receivers are not part of the value's method set
+		panic("factory: provider " + rt.String() + " has New methods with pointer receivers, pass a pointer instead")
 	}
 
 	// step 2 - scan methods
@@ -181,8 +206,44 @@ func (f *Factory) RegisterProvider(provider any) {
 		f.providers[t] = boundMethod{
 			this:   rv,
 			method: rtm.Func,
+			name:   "(" + rt.String() + ")." + rtm.Name,
+		}
+	}
+}
+
+// hasNewMethod returns true in case the type's method set contains a New method
+func hasNewMethod(rt reflect.Type) bool {
+	for i := 0; i < rt.NumMethod(); i++ {
+		if strings.HasPrefix(rt.Method(i).Name, "New") {
+			return true
 		}
 	}
+	return false
+}
+
+// Clone returns a copy of the factory, e.g. to derive a factory per test
+// from a shared base factory. Providers registered with the clone will not
+// affect the original factory and vice versa. The registered provider instances
+// themselves (e.g. a *TimeProvider holding a mocked time) will be shared.
+func (f *Factory) Clone() *Factory {
+	out := &Factory{
+		providers: make(map[reflect.Type]boundMethod, len(f.providers)),
+		logger:    f.logger,
+	}
+	maps.Copy(out.providers, f.providers)
+	return out
+}
+
+// Logger returns the logger passed within FactoryOptions.
+// In case no logger has been passed, a NoOpLogger will be returned.
+func (f *Factory) Logger() *slog.Logger {
+	return f.logger
+}
+
+// hasProvider returns true in case a provider for rt has been registered
+func (f *Factory) hasProvider(rt reflect.Type) bool {
+	_, ok := f.providers[rt]
+	return ok
 }
 
 func (f *Factory) Types() []reflect.Type {
@@ -192,3 +253,26 @@ func (f *Factory) Types() []reflect.Type {
 	}
 	return res
 }
+
+// DescribeProviders returns all registered providers
+// sorted by their provided type, e.g. for startup diagnostics
+func (f *Factory) DescribeProviders() []ProviderInfo {
+	res := make([]ProviderInfo, 0, len(f.providers))
+	for rt, bm := range f.providers {
+		res = append(res, ProviderInfo{
+			Type:        rt,
+			Constructor: bm.name,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Type.String() < res[j].Type.String()
+	})
+	return res
+}
+
+// DumpProviders logs all registered providers using the factory's logger
+func (f *Factory) DumpProviders() {
+	for _, v := range f.DescribeProviders() {
+		f.logger.Info("factory: provider", "type", v.Type.String(), "constructor", v.Constructor)
+	}
+}
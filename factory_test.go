@@ -1,8 +1,11 @@
 package jonson
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -27,6 +30,11 @@ func (m *mockTime) Sleep(time.Duration) {
 	// no-op
 }
 
+func (m *mockTime) SleepContext(ctx context.Context, _ time.Duration) error {
+	// no-op
+	return ctx.Err()
+}
+
 type TestProvider struct {
 	loggedIn bool
 }
@@ -85,6 +93,29 @@ func (t *TestProvider) NewTestPublic(ctx *Context) *TestPublic {
 	return &TestPublic{}
 }
 
+// TestPublicProvider allows tests to swap the provider of *TestPublic
+type TestPublicProvider interface {
+	NewTestPublic(ctx *Context) *TestPublic
+}
+
+// valueTestPublicProvider implements TestPublicProvider using a value receiver
+type valueTestPublicProvider struct {
+	public *TestPublic
+}
+
+func (p valueTestPublicProvider) NewTestPublic(ctx *Context) *TestPublic {
+	return p.public
+}
+
+// pointerTestPublicProvider implements TestPublicProvider using a pointer receiver
+type pointerTestPublicProvider struct {
+	public *TestPublic
+}
+
+func (p *pointerTestPublicProvider) NewTestPublic(ctx *Context) *TestPublic {
+	return p.public
+}
+
 func TestFactory(t *testing.T) {
 	fac := NewFactory()
 	enc := NewDebugSecret()
@@ -107,4 +138,112 @@ func TestFactory(t *testing.T) {
 		}
 	})
 
+	t.Run("registers providers passed as interface", func(t *testing.T) {
+		public := &TestPublic{}
+		for name, provider := range map[string]TestPublicProvider{
+			"pointer": &pointerTestPublicProvider{public: public},
+			"value":   valueTestPublicProvider{public: public},
+		} {
+			t.Run(name, func(t *testing.T) {
+				fac := NewFactory()
+				fac.RegisterProvider(provider)
+				ctx := NewContext(context.Background(), fac, nil)
+				if RequireTestPublic(ctx) != public {
+					t.Fatal("expected value of the registered provider")
+				}
+			})
+		}
+	})
+
+	t.Run("rejects invalid providers", func(t *testing.T) {
+		var nilProvider *pointerTestPublicProvider
+		for name, provider := range map[string]any{
+			"nil":               nil,
+			"nil pointer":       nilProvider,
+			"pointer receivers": pointerTestPublicProvider{},
+		} {
+			t.Run(name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Fatal("expected registration to panic")
+					}
+				}()
+				NewFactory().RegisterProvider(provider)
+			})
+		}
+	})
+
+	t.Run("clones providers", func(t *testing.T) {
+		base := NewFactory()
+		base.RegisterProvider(NewTimeProvider())
+
+		clone := base.Clone()
+		if !clone.hasProvider(TypeTime) || clone.Logger() != base.Logger() {
+			t.Fatal("expected clone to contain the base's providers and logger")
+		}
+
+		public := &TestPublic{}
+		clone.RegisterProvider(&pointerTestPublicProvider{public: public})
+		if base.hasProvider(TypeTestPublic) {
+			t.Fatal("expected provider registered with the clone not to affect the base")
+		}
+		if RequireTestPublic(NewContext(context.Background(), clone, nil)) != public {
+			t.Fatal("expected clone to provide the registered value")
+		}
+
+		// each clone may register the same type
+		other := base.Clone()
+		other.RegisterProvider(&pointerTestPublicProvider{public: &TestPublic{}})
+		if RequireTestPublic(NewContext(context.Background(), clone, nil)) != public {
+			t.Fatal("expected clones not to affect each other")
+		}
+
+		// the base may be extended after cloning
+		base.RegisterProviderFunc(func(ctx *Context) *TestPrivate {
+			return &TestPrivate{}
+		})
+		if clone.hasProvider(TypeTestPrivate) {
+			t.Fatal("expected provider registered with the base not to affect the clone")
+		}
+	})
+
+	t.Run("factory always provides a logger", func(t *testing.T) {
+		if NewFactory().Logger() == nil {
+			t.Fatal("expected default logger to be set")
+		}
+
+		logger := NewNoOpLogger()
+		if NewFactory(&FactoryOptions{Logger: logger}).Logger() != logger {
+			t.Fatal("expected passed logger to be returned")
+		}
+	})
+
+	t.Run("describes registered providers", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		fac := NewFactory(&FactoryOptions{Logger: slog.New(slog.NewJSONHandler(buf, nil))})
+		fac.RegisterProvider(NewTimeProvider())
+		provideTestPublic := func(ctx *Context) *TestPublic {
+			return &TestPublic{}
+		}
+		fac.RegisterProviderFunc(provideTestPublic)
+
+		constructors := map[reflect.Type]string{}
+		for _, v := range fac.DescribeProviders() {
+			constructors[v.Type] = v.Constructor
+		}
+		if c := constructors[TypeTime]; c != "(*jonson.TimeProvider).NewTime" {
+			t.Fatalf("expected time constructor, got: %s", c)
+		}
+		if c := constructors[TypeTestPublic]; !strings.Contains(c, "TestFactory") {
+			t.Fatalf("expected func constructor, got: %s", c)
+		}
+		if len(constructors) != len(fac.Types()) {
+			t.Fatalf("expected all providers to be described, got: %d", len(constructors))
+		}
+
+		fac.DumpProviders()
+		if !strings.Contains(buf.String(), `"constructor":"(*jonson.TimeProvider).NewTime"`) {
+			t.Fatalf("expected providers to be logged, got: %s", buf.String())
+		}
+	})
 }
@@ -0,0 +1,103 @@
+package jonson
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Features decides whether a feature flag is enabled.
+// Methods gated behind a feature flag (see MethodDefinition.Feature)
+// will only be callable while their flag is enabled; flags will
+// be evaluated per call, hence they can change at runtime.
+type Features interface {
+	IsEnabled(ctx *Context, flag string) bool
+}
+
+var TypeFeatures = reflect.TypeOf((*Features)(nil)).Elem()
+
+// RequireFeatures returns the current features
+func RequireFeatures(ctx *Context) Features {
+	if v := ctx.Require(TypeFeatures); v != nil {
+		return v.(Features)
+	}
+	return nil
+}
+
+// FeatureProvider provides in-memory feature flags which
+// can be enabled or disabled at runtime. Provide your own
+// Features in case flags depend on e.g. the calling account.
+type FeatureProvider struct {
+	mux     sync.RWMutex
+	enabled map[string]struct{}
+}
+
+// NewFeatureProvider returns a new feature provider
+// with the given flags being enabled
+func NewFeatureProvider(enabled ...string) *FeatureProvider {
+	f := &FeatureProvider{
+		enabled: map[string]struct{}{},
+	}
+	f.Enable(enabled...)
+	return f
+}
+
+func (f *FeatureProvider) NewFeatures(ctx *Context) Features {
+	return &features{
+		f: f,
+	}
+}
+
+// Enable enables the given flags
+func (f *FeatureProvider) Enable(flag ...string) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	for _, v := range flag {
+		f.enabled[v] = struct{}{}
+	}
+}
+
+// Disable disables the given flags
+func (f *FeatureProvider) Disable(flag ...string) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	for _, v := range flag {
+		delete(f.enabled, v)
+	}
+}
+
+type features struct {
+	Shareable
+	ShareableAcrossImpersonation
+	f *FeatureProvider
+}
+
+func (f *features) IsEnabled(ctx *Context, flag string) bool {
+	f.f.mux.RLock()
+	defer f.f.mux.RUnlock()
+	_, ok := f.f.enabled[flag]
+	return ok
+}
+
+// SetFeature gates the given registered method, e.g. "account/beta.v1",
+// behind a feature flag; see MethodDefinition.Feature.
+// Call it during startup before handling any calls.
+func (m *MethodHandler) SetFeature(method string, flag string) {
+	endpoint, ok := m.endpoints[method]
+	if !ok {
+		panic(fmt.Errorf("setFeature: method %s does not exist", method))
+	}
+	endpoint.def.Feature = flag
+}
+
+// isFeatureEnabled returns true in case the endpoint is not
+// gated behind a feature flag or its flag is enabled
+func (m *MethodHandler) isFeatureEnabled(ctx *Context, endpoint apiEndpoint) bool {
+	if endpoint.def.Feature == "" {
+		return true
+	}
+	if !ctx.factory.hasProvider(TypeFeatures) {
+		return false
+	}
+	return RequireFeatures(ctx).IsEnabled(ctx, endpoint.def.Feature)
+}
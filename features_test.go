@@ -0,0 +1,94 @@
+package jonson
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	features := NewFeatureProvider()
+	factory := NewFactory()
+	factory.RegisterProvider(features)
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	RegisterSystemOf[Greeter](methodHandler, &EnglishGreeter{})
+	methodHandler.RegisterMethod(&MethodDefinition{
+		System:  "beta",
+		Method:  "greeting",
+		Version: 1,
+		HandlerFunc: func(ctx *Context, params *GreetingV1Params) (string, error) {
+			return "hi " + params.Name, nil
+		},
+		Feature: "beta-greeting",
+	})
+	methodHandler.SetFeature("greeter/greeting.v1", "greeting")
+	methodHandler.RegisterDiscover("Test API", "1.0.0")
+
+	call := func(method string) error {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		_, err := methodHandler.CallMethod(ctx, method, RpcHttpMethodPost, &GreetingV1Params{Name: "alice"}, nil)
+		return err
+	}
+	discovered := func() map[string]bool {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		res, err := methodHandler.CallMethod(ctx, DiscoverMethod, RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatalf("expected discover to succeed, got: %s", err)
+		}
+		out := map[string]bool{}
+		for _, v := range res.(*OpenRpcDocument).Methods {
+			out[v.Name] = true
+		}
+		return out
+	}
+
+	t.Run("disabled methods are not found", func(t *testing.T) {
+		for _, method := range []string{"beta/greeting.v1", "greeter/greeting.v1"} {
+			if err := call(method); err != ErrMethodNotFound {
+				t.Fatalf("expected %s to be not found, got: %v", method, err)
+			}
+		}
+		if methods := discovered(); methods["beta/greeting.v1"] || methods["greeter/greeting.v1"] {
+			t.Fatalf("expected disabled methods to be omitted from discover, got: %v", methods)
+		}
+	})
+
+	t.Run("flags are evaluated per call", func(t *testing.T) {
+		features.Enable("beta-greeting")
+		if err := call("beta/greeting.v1"); err != nil {
+			t.Fatalf("expected enabled method to succeed, got: %s", err)
+		}
+		if err := call("greeter/greeting.v1"); err != ErrMethodNotFound {
+			t.Fatalf("expected method to be not found, got: %v", err)
+		}
+		if methods := discovered(); !methods["beta/greeting.v1"] || methods["greeter/greeting.v1"] {
+			t.Fatalf("expected enabled methods to be discovered only, got: %v", methods)
+		}
+
+		features.Disable("beta-greeting")
+		if err := call("beta/greeting.v1"); err != ErrMethodNotFound {
+			t.Fatalf("expected disabled method to be not found, got: %v", err)
+		}
+	})
+
+	t.Run("gated methods are disabled without features being provided", func(t *testing.T) {
+		factory := NewFactory()
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+		RegisterSystemOf[Greeter](methodHandler, &EnglishGreeter{})
+		methodHandler.SetFeature("greeter/greeting.v1", "greeting")
+
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		_, err := methodHandler.CallMethod(ctx, "greeter/greeting.v1", RpcHttpMethodPost, &GreetingV1Params{Name: "alice"}, nil)
+		if err != ErrMethodNotFound {
+			t.Fatalf("expected method to be not found, got: %v", err)
+		}
+	})
+
+	t.Run("setting a feature of an unknown method panics", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		methodHandler.SetFeature("unknown/method.v1", "flag")
+	})
+}
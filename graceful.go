@@ -2,14 +2,17 @@ package jonson
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -74,6 +77,14 @@ type GracefulProvider struct {
 	httpServer *http.Server
 	timeout    *time.Duration
 	logger     *slog.Logger
+	shutdown   []func(ctx context.Context) error
+
+	// tracking enables counting active requests;
+	// trackingInterval defines how often the count will be logged on shutdown
+	tracking         bool
+	trackingInterval time.Duration
+	activeRequests   atomic.Int64
+	inFlight         sync.Map
 
 	// checkStatusChan allows us to check for
 	// the server being in shutdown mode by other goroutines
@@ -85,7 +96,7 @@ type GracefulProvider struct {
 func NewGracefulProvider() *GracefulProvider {
 	return &GracefulProvider{
 		httpServer:      nil,
-		logger:          slog.New(slog.NewJSONHandler(io.Discard, nil)),
+		logger:          NewNoOpLogger(),
 		checkStatusChan: make(chan struct{}),
 		quitChan:        make(chan os.Signal, 1),
 	}
@@ -116,7 +127,8 @@ func (g *GracefulProvider) WithDefaultHttpServer(handler http.Handler, addr stri
 
 // WithTimeout allows you to specify a specific timeout to wait for a
 // proper graceful shutdown of your server. In case timeout is reached,
-// the server will leave the shutdown routine and exit.
+// remaining connections will be closed forcefully and the server will
+// leave the shutdown routine and exit.
 func (g *GracefulProvider) WithTimeout(duration time.Duration) *GracefulProvider {
 	g.timeout = &duration
 	return g
@@ -128,10 +140,111 @@ func (g *GracefulProvider) WithLogger(logger *slog.Logger) *GracefulProvider {
 	return g
 }
 
+// WithShutdownFunc registers a function which will be called once the http server
+// has been shut down, e.g. WorkerProvider.Shutdown to drain background jobs.
+// The passed context will be canceled once the shutdown timeout has been reached.
+// All functions will be called in order of registration, even if shutting down the
+// server or any other function failed; ListenAndServe returns all errors joined.
+func (g *GracefulProvider) WithShutdownFunc(fn func(ctx context.Context) error) *GracefulProvider {
+	g.shutdown = append(g.shutdown, fn)
+	return g
+}
+
+// WithRequestTracking counts the requests being handled by the http server,
+// see ActiveRequests. While shutting down, the number of active requests will
+// be logged every interval which helps to find out why a shutdown does not complete.
+// In case the shutdown timeout is reached, the method, path and duration of each
+// request still being handled will be logged.
+// In case interval is <= 0, it defaults to one second.
+// The http server's handler will be wrapped once ListenAndServe is being called.
+func (g *GracefulProvider) WithRequestTracking(interval time.Duration) *GracefulProvider {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	g.tracking = true
+	g.trackingInterval = interval
+	return g
+}
+
+// ActiveRequests returns the number of requests currently being handled.
+// Requests will only be counted in case WithRequestTracking has been set.
+func (g *GracefulProvider) ActiveRequests() int {
+	return int(g.activeRequests.Load())
+}
+
+// inFlightRequest describes a request currently being handled
+type inFlightRequest struct {
+	method  string
+	path    string
+	startAt time.Time
+}
+
+// trackRequests wraps the handler to count active requests
+func (g *GracefulProvider) trackRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r := &inFlightRequest{
+			method:  req.Method,
+			path:    req.URL.Path,
+			startAt: time.Now(),
+		}
+		g.activeRequests.Add(1)
+		g.inFlight.Store(r, struct{}{})
+		defer func() {
+			g.inFlight.Delete(r)
+			g.activeRequests.Add(-1)
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// logOffenders logs the requests which are still being handled,
+// longest running requests first
+func (g *GracefulProvider) logOffenders() {
+	offenders := []*inFlightRequest{}
+	g.inFlight.Range(func(key, _ any) bool {
+		offenders = append(offenders, key.(*inFlightRequest))
+		return true
+	})
+	sort.Slice(offenders, func(i, j int) bool {
+		return offenders[i].startAt.Before(offenders[j].startAt)
+	})
+	g.logger.Error(fmt.Sprintf("graceful.ListenAndServe: shutdown timed out with %d active requests", len(offenders)))
+	for _, v := range offenders {
+		g.logger.Error("graceful.ListenAndServe: request still active",
+			"method", v.method,
+			"path", v.path,
+			"duration", time.Since(v.startAt).String(),
+		)
+	}
+}
+
+// logActiveRequests logs the number of active requests
+// every tracking interval until done gets closed
+func (g *GracefulProvider) logActiveRequests(done <-chan struct{}) {
+	ticker := time.NewTicker(g.trackingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			g.logger.Info(fmt.Sprintf("graceful.ListenAndServe: waiting for %d active requests", g.ActiveRequests()))
+		}
+	}
+}
+
 // ListenAndServe listens and serve on given address.
 // In case you did provide a server, address will be ignored (if present).
 // In case no server was provided,
 func (g *GracefulProvider) ListenAndServe() error {
+	if g.tracking {
+		handler := g.httpServer.Handler
+		if handler == nil {
+			handler = http.DefaultServeMux
+		}
+		g.httpServer.Handler = g.trackRequests(handler)
+	}
+
 	// start the server in a goroutine
 	go func() {
 		g.logger.Info(fmt.Sprintf("graceful.ListenAndServe: accepting incoming requests on: %s", g.httpServer.Addr))
@@ -164,9 +277,37 @@ func (g *GracefulProvider) ListenAndServe() error {
 	// shutdown
 	nw := time.Now()
 	close(g.checkStatusChan)
+	if g.tracking {
+		g.logger.Info(fmt.Sprintf("graceful.ListenAndServe: %d active requests", g.ActiveRequests()))
+		done := make(chan struct{})
+		defer close(done)
+		go g.logActiveRequests(done)
+	}
+	var errs []error
 	if err := g.httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
 		g.logger.Info("graceful.ListenAndServe: failed to shutdown server", "error", err)
-		return err
+		if errors.Is(err, context.DeadlineExceeded) {
+			// timeout reached: tell which requests are still running
+			// and force close all remaining connections
+			if g.tracking {
+				g.logOffenders()
+			}
+			if err := g.httpServer.Close(); err != nil {
+				g.logger.Info("graceful.ListenAndServe: failed to close server", "error", err)
+			}
+		}
+	}
+	// run all shutdown funcs, even if the server or
+	// any other func failed to shut down
+	for _, fn := range g.shutdown {
+		if err := fn(ctx); err != nil {
+			errs = append(errs, err)
+			g.logger.Info("graceful.ListenAndServe: failed to run shutdown func", "error", err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 	g.logger.Info(fmt.Sprintf("graceful.ListenAndServe: gracefully stopped server after %.4f seconds", time.Since(nw).Seconds()))
 	return nil
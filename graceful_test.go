@@ -1,12 +1,17 @@
 package jonson
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"regexp"
+	"strings"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
@@ -96,7 +101,12 @@ func TestGraceful(t *testing.T) {
 
 		srv := NewServer(regexpHandler)
 		port := getPort()
-		prov := NewGracefulProvider().WithDefaultHttpServer(srv, port).WithLogger(logger).WithTimeout(time.Second * 2)
+		shutdownCalled := false
+		prov := NewGracefulProvider().WithDefaultHttpServer(srv, port).WithLogger(logger).WithTimeout(time.Second * 2).
+			WithShutdownFunc(func(ctx context.Context) error {
+				shutdownCalled = true
+				return nil
+			})
 
 		cnt := 0
 		var err error
@@ -133,6 +143,44 @@ func TestGraceful(t *testing.T) {
 		if cnt == 0 {
 			t.Fatal("expected graceful shutdown to be reached")
 		}
+		if !shutdownCalled {
+			t.Fatal("expected shutdown func to be called even though the server failed to shut down")
+		}
+	})
+
+	t.Run("graceful shutdown runs all shutdown funcs", func(t *testing.T) {
+		errFirst := errors.New("first failed")
+		errSecond := errors.New("second failed")
+		called := []int{}
+		prov := NewGracefulProvider().WithDefaultHttpServer(NewServer(), getPort()).WithLogger(logger).
+			WithShutdownFunc(func(ctx context.Context) error {
+				called = append(called, 1)
+				return errFirst
+			}).
+			WithShutdownFunc(func(ctx context.Context) error {
+				called = append(called, 2)
+				return errSecond
+			}).
+			WithShutdownFunc(func(ctx context.Context) error {
+				called = append(called, 3)
+				return nil
+			})
+
+		var err error
+		down := make(chan struct{})
+		go func() {
+			err = prov.ListenAndServe()
+			close(down)
+		}()
+		killServer(prov)
+		<-down
+
+		if len(called) != 3 {
+			t.Fatalf("expected all shutdown funcs to be called, got: %v", called)
+		}
+		if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+			t.Fatalf("expected errors of all shutdown funcs to be returned, got: %v", err)
+		}
 	})
 
 	t.Run("graceful shutdown is being handled by checking for shutdown", func(t *testing.T) {
@@ -244,4 +292,74 @@ func TestGraceful(t *testing.T) {
 			t.Fatal("expected graceful shutdown to be reached")
 		}
 	})
+
+	t.Run("request tracking counts active requests and logs them while shutting down", func(t *testing.T) {
+		fac := NewFactory()
+		methods := NewMethodHandler(fac, nil, nil)
+		regexpHandler := NewHttpRegexpHandler(fac, methods)
+
+		startedProcessing := make(chan struct{})
+		regexpHandler.RegisterRegexp(regexp.MustCompile("/process"), func(ctx *Context, w http.ResponseWriter, req *http.Request, parts []string) {
+			close(startedProcessing)
+			for {
+				time.Sleep(time.Second * 1)
+			}
+		})
+
+		mtx := sync.Mutex{}
+		buf := &bytes.Buffer{}
+		trackingLogger := slog.New(slog.NewJSONHandler(writerFunc(func(p []byte) (int, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			return buf.Write(p)
+		}), nil))
+
+		srv := NewServer(regexpHandler)
+		port := getPort()
+		prov := NewGracefulProvider().WithDefaultHttpServer(srv, port).WithLogger(trackingLogger).
+			WithTimeout(time.Millisecond * 1500).WithRequestTracking(time.Millisecond * 200)
+
+		var err error
+		up := make(chan struct{})
+		down := make(chan struct{})
+		go func() {
+			close(up)
+			err = prov.ListenAndServe()
+			close(down)
+		}()
+
+		go func() {
+			<-up
+			callProcessEndpoint(port)
+		}()
+
+		<-startedProcessing
+		if prov.ActiveRequests() != 1 {
+			t.Fatalf("expected 1 active request, got: %d", prov.ActiveRequests())
+		}
+
+		killServer(prov)
+
+		<-down
+		if err == nil {
+			t.Fatal("expected graceful shutdown to fail")
+		}
+		mtx.Lock()
+		defer mtx.Unlock()
+		if !strings.Contains(buf.String(), "waiting for 1 active requests") {
+			t.Fatalf("expected active requests to be logged, got: %s", buf.String())
+		}
+		if !strings.Contains(buf.String(), "shutdown timed out with 1 active requests") {
+			t.Fatalf("expected timeout to be logged, got: %s", buf.String())
+		}
+		if !strings.Contains(buf.String(), `"path":"/process"`) {
+			t.Fatalf("expected offending request to be logged, got: %s", buf.String())
+		}
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (fn writerFunc) Write(p []byte) (int, error) {
+	return fn(p)
 }
@@ -1,11 +1,19 @@
 package jonson
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"reflect"
 	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 func init() {
@@ -33,10 +41,75 @@ func RequireHttpRequest(ctx *Context) *HttpRequest {
 	return nil
 }
 
+// RequireTLSState returns the tls connection state of the current request,
+// e.g. to access the client certificates of a mTLS connection.
+// In case the connection was created using websockets, the state of
+// the connection upgraded to a websocket connection will be returned.
+// For non-TLS connections and internal calls, nil will be returned.
+func RequireTLSState(ctx *Context) *tls.ConnectionState {
+	v, err := ctx.GetValue(TypeHttpRequest)
+	if err != nil {
+		return nil
+	}
+	return v.(*HttpRequest).TLS
+}
+
 type HttpResponseWriter struct {
 	Shareable
 	ShareableAcrossImpersonation
 	http.ResponseWriter
+
+	tookOver atomic.Bool
+	written  atomic.Bool
+}
+
+// TakeOver declares that the ongoing call writes the response itself,
+// e.g. after hijacking the connection using http.NewResponseController
+// or upgrading it to a websocket connection. The http handlers will then
+// neither write the call's response nor its headers; within batches,
+// the responses of all calls will be dropped.
+// Calls writing to the HttpResponseWriter directly (e.g. using http.Redirect)
+// take over the response implicitly.
+func (w *HttpResponseWriter) TakeOver() {
+	w.tookOver.Store(true)
+}
+
+// TookOver returns true in case the response has been taken over,
+// either using TakeOver or by writing to the HttpResponseWriter
+func (w *HttpResponseWriter) TookOver() bool {
+	return w.tookOver.Load() || w.written.Load()
+}
+
+// Written returns true in case the status or body
+// has been written using the HttpResponseWriter
+func (w *HttpResponseWriter) Written() bool {
+	return w.written.Load()
+}
+
+func (w *HttpResponseWriter) WriteHeader(status int) {
+	w.written.Store(true)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *HttpResponseWriter) Write(b []byte) (int, error) {
+	w.written.Store(true)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer, allowing
+// http.NewResponseController to hijack the connection
+func (w *HttpResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// newHttpResponseWriter wraps w unless it has been wrapped already
+func newHttpResponseWriter(w http.ResponseWriter) *HttpResponseWriter {
+	if hw, ok := w.(*HttpResponseWriter); ok {
+		return hw
+	}
+	return &HttpResponseWriter{
+		ResponseWriter: w,
+	}
 }
 
 var TypeHttpResponseWriter = reflect.TypeOf((**HttpResponseWriter)(nil)).Elem()
@@ -50,6 +123,60 @@ func RequireHttpResponseWriter(ctx *Context) *HttpResponseWriter {
 	return nil
 }
 
+// ResponseHeaders contains the headers which will be
+// sent along with the response of the ongoing request.
+type ResponseHeaders struct {
+	http.Header
+}
+
+var TypeResponseHeaders = reflect.TypeOf((**ResponseHeaders)(nil)).Elem()
+
+// RequireResponseHeaders returns the headers which will be written
+// right before the response's status and body are sent.
+// In contrast to RequireHttpResponseWriter, the headers can be mutated
+// independently of the ongoing write process.
+// For websocket connections and internal calls, the returned headers
+// will be discarded.
+func RequireResponseHeaders(ctx *Context) http.Header {
+	if v := ctx.Require(TypeResponseHeaders); v != nil {
+		return v.(*ResponseHeaders).Header
+	}
+	return nil
+}
+
+// writeResponseHeaders copies the collected response headers
+// to the http response writer
+func writeResponseHeaders(w http.ResponseWriter, headers http.Header) {
+	for k, v := range headers {
+		w.Header()[k] = v
+	}
+}
+
+// requestTimeout derives a request's deadline from
+// a client provided header
+type requestTimeout struct {
+	header string
+	max    time.Duration
+}
+
+// apply returns the request with a context bound to the timeout
+// passed within the timeout header. Missing or invalid timeouts
+// will be ignored, timeouts exceeding max will be clamped to max.
+func (r *requestTimeout) apply(req *http.Request) (*http.Request, context.CancelFunc) {
+	if r == nil {
+		return req, func() {}
+	}
+	timeout, err := time.ParseDuration(req.Header.Get(r.header))
+	if err != nil || timeout <= 0 {
+		return req, func() {}
+	}
+	if timeout > r.max {
+		timeout = r.max
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	return req.WithContext(ctx), cancel
+}
+
 // The HttpRegexpHandler will accept regular expressions and
 // will register those as default http endpoints. Those methods cannot
 // be called within the rpc world
@@ -89,6 +216,11 @@ func (h *HttpRegexpHandler) RegisterRegexp(pattern *regexp.Regexp, handler func(
 		ctx.StoreValue(TypeHttpResponseWriter, &HttpResponseWriter{
 			ResponseWriter: w,
 		})
+		// the handler writes the response itself: headers can be
+		// set directly on the underlying response writer
+		ctx.StoreValue(TypeResponseHeaders, &ResponseHeaders{
+			Header: w.Header(),
+		})
 		ctx.StoreValue(TypeSecret, h.methodHandler.errorEncoder)
 		defer ctx.Finalize(nil)
 
@@ -96,9 +228,29 @@ func (h *HttpRegexpHandler) RegisterRegexp(pattern *regexp.Regexp, handler func(
 	}
 }
 
+// ArrayResponsePolicy defines whether the HttpRpcHandler
+// wraps its responses in an array
+type ArrayResponsePolicy string
+
+const (
+	// ArrayResponseMirrorRequest answers batches with an array
+	// and single calls with a single response object
+	ArrayResponseMirrorRequest ArrayResponsePolicy = "mirrorRequest"
+	// ArrayResponseAlways wraps single responses in an array as well
+	ArrayResponseAlways ArrayResponsePolicy = "always"
+	// ArrayResponseNever unwraps batches resulting in a single response;
+	// batches resulting in multiple responses will still be answered with an array
+	ArrayResponseNever ArrayResponsePolicy = "never"
+)
+
 type HttpRpcHandler struct {
 	path          string
 	methodHandler *MethodHandler
+	timeout       *requestTimeout
+	get           *getRequests
+	arrayResponse ArrayResponsePolicy
+	onRequest     []func(r *http.Request)
+	onResponse    []func(status int, body []byte)
 }
 
 func NewHttpRpcHandler(methodHandler *MethodHandler, path string) *HttpRpcHandler {
@@ -108,6 +260,63 @@ func NewHttpRpcHandler(methodHandler *MethodHandler, path string) *HttpRpcHandle
 	}
 }
 
+// WithRequestTimeout allows clients to bound their calls using the given
+// header (e.g. "X-Request-Timeout") containing a duration such as "500ms" or "2s".
+// The request's context will be canceled once the timeout exceeded;
+// the effective deadline is available using Context.Deadline().
+// Timeouts exceeding max will be clamped to max.
+func (h *HttpRpcHandler) WithRequestTimeout(header string, max time.Duration) *HttpRpcHandler {
+	h.timeout = &requestTimeout{
+		header: header,
+		max:    max,
+	}
+	return h
+}
+
+// WithGetRequests allows clients to call methods requiring HttpGet
+// using GET, passing the rpc envelope within the query:
+// /rpc?jsonrpc=2.0&id=1&method=system/get-profile.v1&params={"uuid":"..."}
+// id and params contain json values; the query must be url encoded.
+// GET requests for any other method will be rejected to keep
+// the protection against cross site requests of mutating methods.
+// Successful responses will carry the given Cache-Control header (e.g. "public, max-age=60"),
+// errors will never be cached.
+func (h *HttpRpcHandler) WithGetRequests(cacheControl string) *HttpRpcHandler {
+	h.get = &getRequests{
+		cacheControl: cacheControl,
+	}
+	return h
+}
+
+// WithArrayResponse defines whether responses will be wrapped in an array.
+// Defaults to ArrayResponseMirrorRequest: batches will be answered
+// with an array, single calls with a single response object.
+func (h *HttpRpcHandler) WithArrayResponse(policy ArrayResponsePolicy) *HttpRpcHandler {
+	h.arrayResponse = policy
+	return h
+}
+
+// OnRequest registers an interceptor which will be called for
+// every request received on the handler's path before the payload
+// gets decoded; the request's body can be read by the interceptor
+// without affecting the rpc processing.
+// In contrast to method middleware, interceptors see malformed payloads
+// which never reach a method as well.
+// Interceptors will be called in the order of their registration.
+func (h *HttpRpcHandler) OnRequest(fn func(r *http.Request)) *HttpRpcHandler {
+	h.onRequest = append(h.onRequest, fn)
+	return h
+}
+
+// OnResponse registers an interceptor which will be called with
+// the raw status and body right before the response gets written.
+// The body must not be modified.
+// Interceptors will be called in the order of their registration.
+func (h *HttpRpcHandler) OnResponse(fn func(status int, body []byte)) *HttpRpcHandler {
+	h.onResponse = append(h.onResponse, fn)
+	return h
+}
+
 // Handle will handle an incoming http request
 func (h *HttpRpcHandler) Handle(w http.ResponseWriter, req *http.Request) bool {
 	// check for exact matches
@@ -115,46 +324,159 @@ func (h *HttpRpcHandler) Handle(w http.ResponseWriter, req *http.Request) bool {
 		return false
 	}
 
+	var err error
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+	if len(h.onRequest) > 0 {
+		// interceptors may consume the body, hence we need to buffer it
+		var body []byte
+		body, err = io.ReadAll(req.Body)
+		for _, fn := range h.onRequest {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			fn(req)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if req.Method == "GET" && h.get != nil {
+		h.handleGet(w, req)
+		return true
+	}
+
 	// the http rpc handler only accepts post to prevent from xss scripting
 	if req.Method != "POST" {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		w.Write(respMethodNotAllowed)
+		h.write(w, http.StatusMethodNotAllowed, respMethodNotAllowed)
 		return true
 	}
 
+	req, cancel := h.timeout.apply(req)
+	defer cancel()
+
 	var (
-		resp  []any
-		batch bool
+		resp    []any
+		batch   bool
+		headers = http.Header{}
+		hw      = newHttpResponseWriter(w)
 	)
 
-	body, err := io.ReadAll(req.Body)
 	if err != nil {
 		h.methodHandler.logger.Warn("rpc http handler: read error", "error", err)
 		resp = []any{NewRpcErrorResponse(nil, ErrParse)}
 	} else {
-		resp, batch = h.methodHandler.processRpcMessages(RpcSourceHttpRpc, RpcHttpMethodPost, req, w, nil, body)
+		// single calls will be decoded while reading the body
+		resp, batch = h.methodHandler.processRpcStream(RpcSourceHttpRpc, RpcHttpMethodPost, req, hw, headers, nil, req.Body)
 	}
+	if hw.TookOver() {
+		h.methodHandler.releaseResponses(resp)
+		return true
+	}
+	writeResponseHeaders(w, headers)
 
 	if len(resp) == 0 {
-		// nothing to return but obviously everything was ok
-		w.WriteHeader(http.StatusOK)
+		// nothing to return but obviously everything was ok;
+		// this happens in case we only received notifications
+		// which must not be answered (jsonrpc 2.0 spec)
+		h.write(w, http.StatusNoContent, nil)
 		return true
 	}
 
-	// no batch response
+	out := h.marshalResponse(resp, batch)
+	h.methodHandler.releaseResponses(resp)
+	h.write(w, http.StatusOK, out)
+	return true
+}
+
+// marshalResponse marshals the responses according
+// to the handler's ArrayResponsePolicy
+func (h *HttpRpcHandler) marshalResponse(resp []any, batch bool) []byte {
+	switch h.arrayResponse {
+	case ArrayResponseAlways:
+		batch = true
+	case ArrayResponseNever:
+		batch = len(resp) > 1
+	}
 	if !batch {
 		// single response
 		b, _ := json.Marshal(resp[0])
-		w.WriteHeader(http.StatusOK)
-		w.Write(b)
-		return true
+		return b
 	}
-
 	// batch response
 	b, _ := json.Marshal(resp)
-	w.WriteHeader(http.StatusOK)
-	w.Write(b)
-	return true
+	return b
+}
+
+// getRequests configures rpc calls using GET
+type getRequests struct {
+	cacheControl string
+}
+
+// handleGet handles a single call passed within the request's query;
+// batches are not supported.
+func (h *HttpRpcHandler) handleGet(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	method := query.Get("method")
+	if endpoint, ok := h.methodHandler.endpoints[method]; !ok || !endpoint.httpGet {
+		h.write(w, http.StatusMethodNotAllowed, respMethodNotAllowed)
+		return
+	}
+
+	req, cancel := h.timeout.apply(req)
+	defer cancel()
+
+	rpcRequest := &RpcRequest{
+		Version: query.Get("jsonrpc"),
+		Method:  method,
+	}
+	if id := query.Get("id"); id != "" {
+		rpcRequest.ID = json.RawMessage(id)
+	}
+	if params := query.Get("params"); params != "" {
+		rpcRequest.Params = json.RawMessage(params)
+	}
+
+	var (
+		resp    []any
+		headers = http.Header{}
+		hw      = newHttpResponseWriter(w)
+	)
+	// marshaling validates the passed json values
+	data, err := json.Marshal(rpcRequest)
+	if err != nil {
+		h.methodHandler.logger.Warn("rpc http handler: invalid query", "error", err)
+		resp = []any{NewRpcErrorResponse(nil, ErrParse)}
+	} else {
+		resp, _ = h.methodHandler.processRpcMessages(RpcSourceHttpRpc, RpcHttpMethodGet, req, hw, headers, nil, data)
+	}
+	if hw.TookOver() {
+		h.methodHandler.releaseResponses(resp)
+		return
+	}
+	writeResponseHeaders(w, headers)
+
+	if len(resp) == 0 {
+		// notification
+		h.write(w, http.StatusNoContent, nil)
+		return
+	}
+	if _, ok := resp[0].(*RpcResultResponse); ok && h.get.cacheControl != "" {
+		w.Header().Set("Cache-Control", h.get.cacheControl)
+	}
+	body := h.marshalResponse(resp, false)
+	h.methodHandler.releaseResponses(resp)
+	h.write(w, http.StatusOK, body)
+}
+
+// write passes the response to the registered
+// interceptors before writing it
+func (h *HttpRpcHandler) write(w http.ResponseWriter, status int, body []byte) {
+	for _, fn := range h.onResponse {
+		fn(status, body)
+	}
+	w.WriteHeader(status)
+	if len(body) > 0 {
+		w.Write(body)
+	}
 }
 
 // HttpMethodHandler will register all methods within the methodHandler
@@ -165,6 +487,8 @@ func (h *HttpRpcHandler) Handle(w http.ResponseWriter, req *http.Request) bool {
 // otherwise GET will be used as the accepting http method.
 type HttpMethodHandler struct {
 	methodHandler *MethodHandler
+	fieldsParam   string
+	timeout       *requestTimeout
 }
 
 func NewHttpMethodHandler(methodHandler *MethodHandler) *HttpMethodHandler {
@@ -173,6 +497,26 @@ func NewHttpMethodHandler(methodHandler *MethodHandler) *HttpMethodHandler {
 	}
 }
 
+// WithFieldsFilter allows clients to request a subset of
+// the result's fields using the given query parameter (e.g. "fields"):
+// /system/method.v1?fields=name,address.street
+// Nested fields are separated by dots; fields are matched against
+// their json names. The handler's result itself will not be modified.
+func (h *HttpMethodHandler) WithFieldsFilter(param string) *HttpMethodHandler {
+	h.fieldsParam = param
+	return h
+}
+
+// WithRequestTimeout allows clients to bound their calls using the given
+// header; see HttpRpcHandler.WithRequestTimeout.
+func (h *HttpMethodHandler) WithRequestTimeout(header string, max time.Duration) *HttpMethodHandler {
+	h.timeout = &requestTimeout{
+		header: header,
+		max:    max,
+	}
+	return h
+}
+
 // Handle handles the incoming http request and parses the payload.
 // Since we do not need the json rpc wrapper for these calls (method is the http path),
 // we only expect a _single_ data json object inside the body.
@@ -188,9 +532,14 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 		return false
 	}
 
+	req, cancel := h.timeout.apply(req)
+	defer cancel()
+
 	pl := json.RawMessage{}
 	var resp any
 	var err error
+	headers := http.Header{}
+	hw := newHttpResponseWriter(w)
 
 	// we need to unmarshal the body _only_ in case
 	// parameters are expected; Otherwise the body
@@ -205,7 +554,7 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 		h.methodHandler.logger.Warn("http method handler: read error", "error", err)
 		resp = NewRpcErrorResponse(nil, ErrParse)
 	} else {
-		resp = h.methodHandler.processRpcMessage(RpcSourceHttp, method, req, w, nil, &RpcRequest{
+		resp = h.methodHandler.processRpcMessage(RpcSourceHttp, method, req, hw, headers, nil, &RpcRequest{
 			Version: "2.0",
 			Method:  p,
 			// we do not have any IDs here -> set to -1
@@ -213,36 +562,28 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 			Params: pl,
 		}, nil)
 	}
+	if hw.TookOver() {
+		return true
+	}
 
 	successResp, ok := resp.(*RpcResultResponse)
 	httpStatus := http.StatusOK
 	var dataToMarshal = resp
 	if ok {
 		dataToMarshal = successResp.Result
+		if fields := h.requestedFields(req); len(fields) > 0 {
+			dataToMarshal = filterFields(dataToMarshal, fields)
+		}
 	}
 	errorResp, ok := resp.(*RpcErrorResponse)
 	if ok {
 		dataToMarshal = errorResp.Error
-		switch errorResp.Error.Code {
-		case ErrServerMethodNotAllowed.Code:
-			httpStatus = http.StatusMethodNotAllowed
-		case ErrInvalidParams.Code:
-			fallthrough
-		case ErrParse.Code:
-			httpStatus = http.StatusBadRequest
-		case ErrUnauthorized.Code:
-			fallthrough // do not use 401 -> triggers basic auth
-		case ErrUnauthenticated.Code:
-			httpStatus = http.StatusForbidden
-		case ErrMethodNotFound.Code:
-			httpStatus = http.StatusNotFound
-		default:
-			httpStatus = http.StatusInternalServerError
-		}
+		httpStatus = errorHttpStatus(errorResp.Error)
 	}
 
 	// single response for these calls allowed only
 	b, _ := json.Marshal(dataToMarshal)
+	writeResponseHeaders(w, headers)
 	// make sure we're responding with application/json for everything
 	if len(b) > 0 {
 		w.Header().Set("Content-Type", "application/json")
@@ -252,3 +593,152 @@ func (h *HttpMethodHandler) Handle(w http.ResponseWriter, req *http.Request) boo
 	return true
 
 }
+
+var (
+	httpStatusesMux sync.RWMutex
+	httpStatuses    = map[int]int{}
+)
+
+// RegisterHttpStatus maps an error code to the http status sent by the
+// HttpMethodHandler, e.g. to map your application's custom error codes once
+// during init. Registered mappings take precedence over the built-in mappings
+// of jonson's errors; unmapped codes will be served using http status 500.
+func RegisterHttpStatus(code int, status int) {
+	if status < 100 || status > 999 {
+		panic(fmt.Errorf("registerHttpStatus: invalid http status %d", status))
+	}
+	httpStatusesMux.Lock()
+	defer httpStatusesMux.Unlock()
+	httpStatuses[code] = status
+}
+
+// errorHttpStatus maps an error to the http status
+// sent by the HttpMethodHandler
+func errorHttpStatus(err *Error) int {
+	httpStatusesMux.RLock()
+	status, ok := httpStatuses[err.Code]
+	httpStatusesMux.RUnlock()
+	if ok {
+		return status
+	}
+
+	switch err.Code {
+	case ErrServerMethodNotAllowed.Code:
+		return http.StatusMethodNotAllowed
+	case ErrInvalidRequest.Code:
+		fallthrough
+	case ErrInvalidParams.Code:
+		fallthrough
+	case ErrParse.Code:
+		return http.StatusBadRequest
+	case ErrUnauthorized.Code:
+		fallthrough // do not use 401 -> triggers basic auth
+	case ErrUnauthenticated.Code:
+		return http.StatusForbidden
+	case ErrMethodNotFound.Code:
+		fallthrough
+	case ErrNotFound.Code:
+		return http.StatusNotFound
+	case ErrConflict.Code:
+		return http.StatusConflict
+	case ErrServiceUnavailable.Code:
+		return http.StatusServiceUnavailable
+	case ErrNotImplemented.Code:
+		return http.StatusNotImplemented
+	case ErrProviderTimeout.Code:
+		return http.StatusGatewayTimeout
+	case ErrTooManyRequests.Code:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// requestedFields returns the fields requested by the client
+func (h *HttpMethodHandler) requestedFields(req *http.Request) []string {
+	if h.fieldsParam == "" {
+		return nil
+	}
+	fields := []string{}
+	for _, v := range req.URL.Query()[h.fieldsParam] {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// filterFields returns a copy of data only containing the given fields.
+// The data will be converted to its json representation first
+// to make sure the original data won't be modified; numbers are kept
+// as json.Number to preserve their precision (e.g. int64 ids).
+// A requested field selects everything below, even if some of its
+// children have been requested as well (e.g. address,address.zip).
+func filterFields(data any, fields []string) any {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return data
+	}
+
+	tree := &fieldTree{}
+	for _, f := range fields {
+		node := tree
+		for _, part := range strings.Split(f, ".") {
+			if node.all {
+				break
+			}
+			if node.children == nil {
+				node.children = map[string]*fieldTree{}
+			}
+			next, ok := node.children[part]
+			if !ok {
+				next = &fieldTree{}
+				node.children[part] = next
+			}
+			node = next
+		}
+		// the field itself has been requested
+		node.all = true
+		node.children = nil
+	}
+	return filterFieldTree(generic, tree)
+}
+
+// fieldTree contains the requested fields below a field
+type fieldTree struct {
+	// all selects everything below
+	all      bool
+	children map[string]*fieldTree
+}
+
+func filterFieldTree(data any, tree *fieldTree) any {
+	if tree.all || len(tree.children) == 0 {
+		return data
+	}
+	switch x := data.(type) {
+	case map[string]any:
+		out := map[string]any{}
+		for k, sub := range tree.children {
+			if v, ok := x[k]; ok {
+				out[k] = filterFieldTree(v, sub)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(x))
+		for i, v := range x {
+			out[i] = filterFieldTree(v, tree)
+		}
+		return out
+	default:
+		return data
+	}
+}
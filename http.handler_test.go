@@ -2,11 +2,15 @@ package jonson
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -94,6 +98,81 @@ func newHttpRpcRequest(rpcMethod string, data any) *http.Request {
 	return httpReq
 }
 
+func newHttpRpcBatchRequest(rpcRequests ...*RpcRequest) *http.Request {
+	b, err := json.Marshal(rpcRequests)
+	if err != nil {
+		panic(err)
+	}
+	httpReq, _ := http.NewRequest("POST", "/rpc", bytes.NewReader(b))
+	return httpReq
+}
+
+func parseHttpRpcBatchResponse(wtr *httptest.ResponseRecorder) ([]*rpcTestResponse, error) {
+	if wtr.Code != 200 {
+		return nil, fmt.Errorf("expected status code 200, got: %d", wtr.Code)
+	}
+	out := []*rpcTestResponse{}
+	if err := json.NewDecoder(wtr.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type rpcTestResponse struct {
+	RpcResponseHeader
+	Error  *Error           `json:"error"`
+	Result *json.RawMessage `json:"result"`
+}
+
+func TestIPAddress(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.0.1:1234"
+
+	if ip := IPAddress(req); ip != "192.168.0.1:1234" {
+		t.Fatalf("expected remote address to be used, got: %s", ip)
+	}
+
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	if ip := IPAddress(req); ip != "10.0.0.1" {
+		t.Fatalf("expected forwarded address to be used, got: %s", ip)
+	}
+
+	req.Header.Set("X-Forwarded-For", "10.0.0.2,10.0.0.3")
+	if ip := IPAddress(req); ip != "10.0.0.2" {
+		t.Fatalf("expected first forwarded address to be used, got: %s", ip)
+	}
+}
+
+func TestRequireTLSState(t *testing.T) {
+	factory := NewFactory()
+
+	t.Run("returns nil without http request", func(t *testing.T) {
+		ctx := NewContext(context.Background(), factory, nil)
+		if state := RequireTLSState(ctx); state != nil {
+			t.Fatalf("expected tls state to be nil, got: %v", state)
+		}
+	})
+
+	t.Run("returns nil for non-TLS connections", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/", nil)
+		ctx := NewContext(context.Background(), factory, nil)
+		ctx.StoreValue(TypeHttpRequest, &HttpRequest{Request: req})
+		if state := RequireTLSState(ctx); state != nil {
+			t.Fatalf("expected tls state to be nil, got: %v", state)
+		}
+	})
+
+	t.Run("returns the connection state of TLS connections", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.TLS = &tls.ConnectionState{ServerName: "jonson.test"}
+		ctx := NewContext(context.Background(), factory, nil)
+		ctx.StoreValue(TypeHttpRequest, &HttpRequest{Request: req})
+		if state := RequireTLSState(ctx); state == nil || state.ServerName != "jonson.test" {
+			t.Fatalf("expected tls state to be returned, got: %v", state)
+		}
+	})
+}
+
 func TestHttpHandler(t *testing.T) {
 	tm := time.Now()
 	testProvider := NewTestProvider()
@@ -169,6 +248,38 @@ func TestHttpHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("rpc meta contains client information", func(t *testing.T) {
+		for _, method := range []string{"rpc-meta.v1", "internal-rpc-meta.v1"} {
+			wtr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test-system/"+method, nil)
+			req.Header.Set("User-Agent", "jonson-test")
+			req.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+
+			httpHandler.Handle(wtr, req)
+			result := &RpcMeta{}
+			_, err := parseHttpResponse(wtr, result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.IPAddress != "10.0.0.1" {
+				t.Fatalf("%s: expected ip address to equal 10.0.0.1, got: %s", method, result.IPAddress)
+			}
+			if result.UserAgent != "jonson-test" {
+				t.Fatalf("%s: expected user agent to equal jonson-test, got: %s", method, result.UserAgent)
+			}
+		}
+	})
+
+	t.Run("handler sets response headers", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test-system/set-header.v1", nil)
+
+		httpHandler.Handle(wtr, req)
+		if h := wtr.Header().Get("X-Test"); h != "jonson" {
+			t.Fatalf("expected header X-Test to equal 'jonson', got: %s", h)
+		}
+	})
+
 	t.Run("calls method me.v1", func(t *testing.T) {
 		wtr := httptest.NewRecorder()
 		testProvider.setLoggedIn(true)
@@ -307,6 +418,16 @@ func TestHttpRpcHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("handler sets response headers", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req := newHttpRpcRequest("test-system/set-header.v1", nil)
+
+		httpRpcHandler.Handle(wtr, req)
+		if h := wtr.Header().Get("X-Test"); h != "jonson" {
+			t.Fatalf("expected header X-Test to equal 'jonson', got: %s", h)
+		}
+	})
+
 	t.Run("expect current-time to return current time", func(t *testing.T) {
 		wtr := httptest.NewRecorder()
 		req := newHttpRpcRequest("test-system/current-time.v1", nil)
@@ -358,4 +479,987 @@ func TestHttpRpcHandler(t *testing.T) {
 			t.Fatalf("result code to match unauthorized, got: %d", errResult.Code)
 		}
 	})
+
+	t.Run("a panicking batch item does not affect other batch items", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		testProvider.setLoggedIn(false)
+
+		req := newHttpRpcBatchRequest(
+			&RpcRequest{Version: "2.0", ID: []byte("1"), Method: "test-system/current-time.v1"},
+			&RpcRequest{Version: "2.0", ID: []byte("2"), Method: "test-system/panic.v1"},
+			&RpcRequest{Version: "2.0", ID: []byte("3"), Method: "test-system/me.v1"},
+			&RpcRequest{Version: "2.0", ID: []byte("4"), Method: "test-system/me.v1"},
+			&RpcRequest{Version: "2.0", ID: []byte("5"), Method: "test-system/current-time.v1"},
+		)
+
+		httpRpcHandler.Handle(wtr, req)
+		resp, err := parseHttpRpcBatchResponse(wtr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp) != 5 {
+			t.Fatalf("expected 5 responses, got: %d", len(resp))
+		}
+
+		for i, v := range resp {
+			if string(v.ID) != fmt.Sprintf("%d", i+1) {
+				t.Fatalf("expected response id %d, got: %s", i+1, string(v.ID))
+			}
+		}
+
+		if resp[0].Error != nil || resp[4].Error != nil {
+			t.Fatalf("expected current-time calls to succeed, got: %v | %v", resp[0].Error, resp[4].Error)
+		}
+		if resp[1].Error == nil || resp[1].Error.Code != ErrInternal.Code {
+			t.Fatalf("expected panicking call to return internal error, got: %v", resp[1].Error)
+		}
+		// a provider panicking twice must always return the provider's error
+		// and never leak a half-initialized value into subsequent calls
+		for _, v := range resp[2:4] {
+			if v.Error == nil || v.Error.Code != ErrUnauthorized.Code {
+				t.Fatalf("expected me.v1 to return unauthorized, got: %v", v.Error)
+			}
+		}
+	})
+
+	t.Run("a batch of notifications only returns no content", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+
+		req, _ := http.NewRequest("POST", "/rpc", bytes.NewReader([]byte(`[
+			{"jsonrpc": "2.0", "method": "test-system/current-time.v1"},
+			{"jsonrpc": "2.0", "method": "test-system/current-time.v1"}
+		]`)))
+
+		httpRpcHandler.Handle(wtr, req)
+		if wtr.Code != http.StatusNoContent {
+			t.Fatalf("expected status code 204, got: %d", wtr.Code)
+		}
+		if wtr.Body.Len() != 0 {
+			t.Fatalf("expected empty body, got: %s", wtr.Body.String())
+		}
+	})
+
+	t.Run("a mixed batch only returns responses for non-notifications", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+
+		req, _ := http.NewRequest("POST", "/rpc", bytes.NewReader([]byte(`[
+			{"jsonrpc": "2.0", "method": "test-system/current-time.v1"},
+			{"jsonrpc": "2.0", "id": 1, "method": "test-system/current-time.v1"},
+			{"jsonrpc": "2.0", "method": "test-system/current-time.v1"},
+			{"jsonrpc": "2.0", "id": 2, "method": "test-system/current-time.v1"}
+		]`)))
+
+		httpRpcHandler.Handle(wtr, req)
+		resp, err := parseHttpRpcBatchResponse(wtr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp) != 2 {
+			t.Fatalf("expected 2 responses, got: %d", len(resp))
+		}
+		if string(resp[0].ID) != "1" || string(resp[1].ID) != "2" {
+			t.Fatalf("expected response ids 1 and 2, got: %s | %s", string(resp[0].ID), string(resp[1].ID))
+		}
+	})
+}
+
+func TestHttpRpcHandlerMaxBatchSize(t *testing.T) {
+	factory := NewFactory()
+	factory.RegisterProvider(NewTestProvider())
+	factory.RegisterProvider(NewTimeProvider())
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		MaxBatchSize: 2,
+	})
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+	newBatch := func(n int) *http.Request {
+		reqs := []*RpcRequest{}
+		for i := 0; i < n; i++ {
+			reqs = append(reqs, &RpcRequest{Version: "2.0", ID: []byte(fmt.Sprintf("%d", i+1)), Method: "test-system/current-time.v1"})
+		}
+		return newHttpRpcBatchRequest(reqs...)
+	}
+
+	t.Run("batch within limit is processed", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		httpRpcHandler.Handle(wtr, newBatch(2))
+
+		resp, err := parseHttpRpcBatchResponse(wtr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp) != 2 {
+			t.Fatalf("expected 2 responses, got: %d", len(resp))
+		}
+	})
+
+	t.Run("batch exceeding limit is rejected", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		httpRpcHandler.Handle(wtr, newBatch(3))
+
+		rpcErr, err := parseHttpRpcResponse(wtr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rpcErr == nil || rpcErr.Code != ErrInvalidRequest.Code {
+			t.Fatalf("expected invalid request error, got: %v", rpcErr)
+		}
+	})
+}
+
+type FieldsSystem struct {
+	result *FieldsV1Result
+}
+
+type FieldsV1Address struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip"`
+}
+
+type FieldsV1Result struct {
+	Name      string             `json:"name"`
+	Email     string             `json:"email"`
+	Address   *FieldsV1Address   `json:"address"`
+	Addresses []*FieldsV1Address `json:"addresses"`
+}
+
+func (f *FieldsSystem) FieldsV1(ctx *Context) (*FieldsV1Result, error) {
+	return f.result, nil
+}
+
+func TestFilterFields(t *testing.T) {
+	t.Run("keeps the precision of large numbers", func(t *testing.T) {
+		data := map[string]any{"id": int64(1<<53 + 1), "name": "Silvio"}
+		b, _ := json.Marshal(filterFields(data, []string{"id"}))
+		if string(b) != `{"id":9007199254740993}` {
+			t.Fatalf("expected id to keep its precision, got: %s", b)
+		}
+	})
+}
+
+func TestHttpMethodHandlerFieldsFilter(t *testing.T) {
+	factory := NewFactory()
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	system := &FieldsSystem{
+		result: &FieldsV1Result{
+			Name:      "Silvio",
+			Email:     "silvio@example.com",
+			Address:   &FieldsV1Address{Street: "Main Street", Zip: "12345"},
+			Addresses: []*FieldsV1Address{{Street: "First", Zip: "1"}, {Street: "Second", Zip: "2"}},
+		},
+	}
+	methodHandler.RegisterSystem(system)
+
+	httpHandler := NewHttpMethodHandler(methodHandler).WithFieldsFilter("fields")
+
+	t.Run("returns requested fields only", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/fields-system/fields.v1?fields=name,address.zip,addresses.street", nil)
+
+		httpHandler.Handle(wtr, req)
+		content, _ := io.ReadAll(wtr.Body)
+		expected := `{"address":{"zip":"12345"},"addresses":[{"street":"First"},{"street":"Second"}],"name":"Silvio"}`
+		if string(content) != expected {
+			t.Fatalf("expected filtered result %s, got: %s", expected, string(content))
+		}
+
+		if system.result.Email == "" || system.result.Address.Street == "" {
+			t.Fatal("expected handler result to stay untouched")
+		}
+	})
+
+	t.Run("requested parents win over their children", func(t *testing.T) {
+		for _, fields := range []string{"address,address.zip", "address.zip,address"} {
+			wtr := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/fields-system/fields.v1?fields="+fields, nil)
+
+			httpHandler.Handle(wtr, req)
+			content, _ := io.ReadAll(wtr.Body)
+			expected := `{"address":{"street":"Main Street","zip":"12345"}}`
+			if string(content) != expected {
+				t.Fatalf("expected full parent %s for %s, got: %s", expected, fields, string(content))
+			}
+		}
+	})
+
+	t.Run("returns all fields without filter", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/fields-system/fields.v1", nil)
+
+		httpHandler.Handle(wtr, req)
+		result := &FieldsV1Result{}
+		if _, err := parseHttpResponse(wtr, result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Email != system.result.Email || len(result.Addresses) != 2 {
+			t.Fatalf("expected full result, got: %v", result)
+		}
+	})
+}
+
+func TestHttpRpcHandlerRejectDuplicateBatchIDs(t *testing.T) {
+	factory := NewFactory()
+	factory.RegisterProvider(NewTestProvider())
+	factory.RegisterProvider(NewTimeProvider())
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		RejectDuplicateBatchIDs: true,
+	})
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+	wtr := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/rpc", bytes.NewReader([]byte(`[
+		{"jsonrpc": "2.0", "id": "1", "method": "test-system/current-time.v1"},
+		{"jsonrpc": "2.0", "id": "1", "method": "test-system/current-time.v1"},
+		{"jsonrpc": "2.0", "id": 1, "method": "test-system/current-time.v1"}
+	]`)))
+
+	httpRpcHandler.Handle(wtr, req)
+	resp, err := parseHttpRpcBatchResponse(wtr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) != 3 {
+		t.Fatalf("expected 3 responses, got: %d", len(resp))
+	}
+	if resp[0].Error != nil {
+		t.Fatalf("expected first call to succeed, got: %v", resp[0].Error)
+	}
+	if resp[1].Error == nil || resp[1].Error.Code != ErrInvalidRequest.Code {
+		t.Fatalf("expected duplicate call to be rejected, got: %v", resp[1].Error)
+	}
+	// a number id does not equal a string id
+	if resp[2].Error != nil {
+		t.Fatalf("expected third call to succeed, got: %v", resp[2].Error)
+	}
+}
+
+func TestHttpHandlerRequestTimeout(t *testing.T) {
+	factory := NewFactory()
+	factory.RegisterProvider(NewTestProvider())
+	factory.RegisterProvider(NewTimeProvider())
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc").WithRequestTimeout("X-Request-Timeout", time.Second)
+	httpHandler := NewHttpMethodHandler(methodHandler).WithRequestTimeout("X-Request-Timeout", time.Second)
+
+	callRpc := func(t *testing.T, timeout string) *DeadlineV1Result {
+		t.Helper()
+		wtr := httptest.NewRecorder()
+		req := newHttpRpcRequest("test-system/deadline.v1", nil)
+		req.Header.Set("X-Request-Timeout", timeout)
+		httpRpcHandler.Handle(wtr, req)
+		result := &DeadlineV1Result{}
+		if _, err := parseHttpRpcResponse(wtr, result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	t.Run("derives the deadline from the header", func(t *testing.T) {
+		result := callRpc(t, "500ms")
+		if !result.HasDeadline || result.Remaining > 500*time.Millisecond || result.Remaining <= 0 {
+			t.Fatalf("expected deadline within 500ms, got: %v", result)
+		}
+	})
+
+	t.Run("clamps the timeout to the maximum", func(t *testing.T) {
+		result := callRpc(t, "1h")
+		if !result.HasDeadline || result.Remaining > time.Second {
+			t.Fatalf("expected deadline to be clamped to 1s, got: %v", result)
+		}
+	})
+
+	t.Run("ignores missing and invalid timeouts", func(t *testing.T) {
+		for _, v := range []string{"", "soon", "-1s"} {
+			if result := callRpc(t, v); result.HasDeadline {
+				t.Fatalf("expected no deadline for timeout '%s', got: %v", v, result)
+			}
+		}
+	})
+
+	t.Run("http method handler derives the deadline from the header", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/test-system/deadline.v1", nil)
+		req.Header.Set("X-Request-Timeout", "500ms")
+		httpHandler.Handle(wtr, req)
+		result := &DeadlineV1Result{}
+		if _, err := parseHttpResponse(wtr, result); err != nil {
+			t.Fatal(err)
+		}
+		if !result.HasDeadline || result.Remaining > 500*time.Millisecond {
+			t.Fatalf("expected deadline within 500ms, got: %v", result)
+		}
+	})
+}
+
+type NilSystem struct{}
+
+func (n *NilSystem) VoidV1(ctx *Context) error {
+	return nil
+}
+
+func (n *NilSystem) NilPointerV1(ctx *Context) (*MeV1Result, error) {
+	return nil, nil
+}
+
+func (n *NilSystem) NilSliceV1(ctx *Context) ([]string, error) {
+	return nil, nil
+}
+
+func TestHttpRpcHandlerNilResultPolicy(t *testing.T) {
+	call := func(t *testing.T, policy NilResultPolicy, method string) string {
+		t.Helper()
+		methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), &MethodHandlerOptions{
+			NilResultPolicy: policy,
+		})
+		methodHandler.RegisterSystem(&NilSystem{})
+		httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+		wtr := httptest.NewRecorder()
+		httpRpcHandler.Handle(wtr, newHttpRpcRequest(method, nil))
+		resp := &rpcTestResponse{}
+		if err := json.NewDecoder(wtr.Body).Decode(resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != nil {
+			t.Fatalf("expected call to succeed, got: %v", resp.Error)
+		}
+		if resp.Result == nil {
+			return "null"
+		}
+		return string(*resp.Result)
+	}
+
+	t.Run("sends null by default", func(t *testing.T) {
+		for _, method := range []string{"nil-system/void.v1", "nil-system/nil-pointer.v1"} {
+			if res := call(t, "", method); res != "null" {
+				t.Fatalf("%s: expected null result, got: %s", method, res)
+			}
+		}
+	})
+
+	t.Run("sends empty objects", func(t *testing.T) {
+		if res := call(t, NilResultEmptyObject, "nil-system/nil-pointer.v1"); res != "{}" {
+			t.Fatalf("expected empty object result, got: %s", res)
+		}
+	})
+
+	t.Run("does not affect void methods", func(t *testing.T) {
+		if res := call(t, NilResultEmptyObject, "nil-system/void.v1"); res != "null" {
+			t.Fatalf("expected null result, got: %s", res)
+		}
+	})
+
+	t.Run("nil slices stay null", func(t *testing.T) {
+		if res := call(t, NilResultEmptyObject, "nil-system/nil-slice.v1"); res != "null" {
+			t.Fatalf("expected null result, got: %s", res)
+		}
+	})
+}
+
+func TestVoidResultPolicy(t *testing.T) {
+	tests := []struct {
+		policy   VoidResultPolicy
+		expected string
+	}{
+		{"", "null"},
+		{VoidResultNull, "null"},
+		{VoidResultEmptyObject, "{}"},
+		{VoidResultTrue, "true"},
+	}
+
+	for _, v := range tests {
+		methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), &MethodHandlerOptions{
+			VoidResultPolicy: v.policy,
+		})
+		methodHandler.RegisterSystem(&NilSystem{})
+
+		t.Run("rpc handler with policy '"+string(v.policy)+"'", func(t *testing.T) {
+			wtr := httptest.NewRecorder()
+			NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, newHttpRpcRequest("nil-system/void.v1", nil))
+			resp := &rpcTestResponse{}
+			if err := json.NewDecoder(wtr.Body).Decode(resp); err != nil {
+				t.Fatal(err)
+			}
+			res := "null"
+			if resp.Result != nil {
+				res = string(*resp.Result)
+			}
+			if res != v.expected {
+				t.Fatalf("expected result %s, got: %s", v.expected, res)
+			}
+		})
+
+		t.Run("http method handler with policy '"+string(v.policy)+"'", func(t *testing.T) {
+			wtr := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/nil-system/void.v1", nil)
+			NewHttpMethodHandler(methodHandler).Handle(wtr, req)
+			if wtr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got: %d", wtr.Code)
+			}
+			if body := wtr.Body.String(); body != v.expected {
+				t.Fatalf("expected body %s, got: %s", v.expected, body)
+			}
+		})
+	}
+}
+
+func TestHttpRpcHandlerInterceptors(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(&NilSystem{})
+
+	var (
+		calls        []string
+		requestBody  []byte
+		status       int
+		responseBody []byte
+	)
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc").
+		OnRequest(func(r *http.Request) {
+			calls = append(calls, "request")
+			requestBody, _ = io.ReadAll(r.Body)
+		}).
+		OnRequest(func(r *http.Request) {
+			calls = append(calls, "request")
+		}).
+		OnResponse(func(s int, b []byte) {
+			calls = append(calls, "response")
+			status = s
+			responseBody = b
+		})
+
+	t.Run("interceptors receive raw request and response", func(t *testing.T) {
+		calls = nil
+		wtr := httptest.NewRecorder()
+		httpRpcHandler.Handle(wtr, newHttpRpcRequest("nil-system/void.v1", nil))
+		if strings.Join(calls, ",") != "request,request,response" {
+			t.Fatalf("unexpected interceptor calls: %v", calls)
+		}
+		if !strings.Contains(string(requestBody), "nil-system/void.v1") {
+			t.Fatalf("expected request body to be passed, got: %s", requestBody)
+		}
+		if status != http.StatusOK || string(responseBody) != wtr.Body.String() {
+			t.Fatalf("expected response to be passed, got: %d %s", status, responseBody)
+		}
+		// the interceptor must not consume the body
+		if _, err := parseHttpRpcResponse(wtr, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("interceptors receive malformed payloads", func(t *testing.T) {
+		calls = nil
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/rpc", bytes.NewReader([]byte(`{"jsonrpc": "2.0",`)))
+		httpRpcHandler.Handle(wtr, req)
+		if string(responseBody) != wtr.Body.String() {
+			t.Fatalf("expected error response to be passed, got: %s", responseBody)
+		}
+		if string(requestBody) != `{"jsonrpc": "2.0",` {
+			t.Fatalf("expected malformed request body to be passed, got: %s", requestBody)
+		}
+		errResp, err := parseHttpRpcResponse(wtr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil || errResp.Code != ErrParse.Code {
+			t.Fatalf("expected parse error, got: %v", errResp)
+		}
+	})
+
+	t.Run("interceptors receive rejected requests", func(t *testing.T) {
+		calls = nil
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/rpc", nil)
+		httpRpcHandler.Handle(wtr, req)
+		if strings.Join(calls, ",") != "request,request,response" || status != http.StatusMethodNotAllowed {
+			t.Fatalf("unexpected interceptor calls: %v, status: %d", calls, status)
+		}
+	})
+}
+
+func TestHttpRpcHandlerPayloadRobustness(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), &MethodHandlerOptions{
+		MaxJsonDepth: 8,
+	})
+	methodHandler.RegisterSystem(&NilSystem{})
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+	call := func(payload string) *httptest.ResponseRecorder {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/rpc", strings.NewReader(payload))
+		httpRpcHandler.Handle(wtr, req)
+		return wtr
+	}
+	request := `{"jsonrpc": "2.0", "id": 1, "method": "nil-system/void.v1"}`
+
+	t.Run("single call with leading whitespace", func(t *testing.T) {
+		errResp, err := parseHttpRpcResponse(call(" \r\n\t"+request), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil {
+			t.Fatalf("expected call to succeed, got: %v", errResp)
+		}
+	})
+
+	t.Run("batch with leading whitespace", func(t *testing.T) {
+		resp, err := parseHttpRpcBatchResponse(call("\n  [" + request + "]"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp) != 1 || resp[0].Error != nil {
+			t.Fatalf("expected a single successful response, got: %v", resp)
+		}
+	})
+
+	t.Run("whitespace only is a parse error", func(t *testing.T) {
+		errResp, err := parseHttpRpcResponse(call(" \n "), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil || errResp.Code != ErrParse.Code {
+			t.Fatalf("expected parse error, got: %v", errResp)
+		}
+	})
+
+	t.Run("deeply nested payload is rejected", func(t *testing.T) {
+		nested := strings.Repeat("[", 10000) + strings.Repeat("]", 10000)
+		errResp, err := parseHttpRpcResponse(call(`{"jsonrpc": "2.0", "id": 1, "method": "nil-system/void.v1", "params": `+nested+`}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil || errResp.Code != ErrParse.Code {
+			t.Fatalf("expected parse error, got: %v", errResp)
+		}
+	})
+
+	t.Run("brackets within strings do not count", func(t *testing.T) {
+		errResp, err := parseHttpRpcResponse(call(`{"jsonrpc": "2.0", "id": "[[[[[[[[[[\"{{{{{{{{{{", "method": "nil-system/void.v1"}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil {
+			t.Fatalf("expected call to succeed, got: %v", errResp)
+		}
+	})
+}
+
+func TestExceedsJsonDepth(t *testing.T) {
+	tests := []struct {
+		data     string
+		max      int
+		expected bool
+	}{
+		{`{}`, 1, false},
+		{`{"a": []}`, 1, true},
+		{`{"a": [], "b": {}}`, 2, false},
+		{`[[[]]]`, 2, true},
+		{`{"a": "[[[\\\"]]]"}`, 1, false},
+	}
+	for _, v := range tests {
+		if got := exceedsJsonDepth([]byte(v.data), v.max); got != v.expected {
+			t.Fatalf("expected %s with max depth %d to return %t, got: %t", v.data, v.max, v.expected, got)
+		}
+	}
+}
+
+func TestHttpRpcHandlerGetRequests(t *testing.T) {
+	factory := NewFactory()
+	testProvider := NewTestProvider()
+	testProvider.setLoggedIn(true)
+	factory.RegisterProvider(testProvider)
+	factory.RegisterProvider(NewTimeProvider())
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc").WithGetRequests("public, max-age=60")
+
+	newGetRequest := func(method string, id string) *http.Request {
+		query := url.Values{}
+		query.Set("jsonrpc", "2.0")
+		query.Set("id", id)
+		query.Set("method", method)
+		req, _ := http.NewRequest("GET", "/rpc?"+query.Encode(), nil)
+		return req
+	}
+
+	t.Run("calls methods requiring HttpGet", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		httpRpcHandler.Handle(wtr, newGetRequest("test-system/me.v1", "1"))
+		if cc := wtr.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+			t.Fatalf("expected cache control header, got: %s", cc)
+		}
+		result := &MeV1Result{}
+		errResp, err := parseHttpRpcResponse(wtr, result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil {
+			t.Fatalf("expected call to succeed, got: %v", errResp)
+		}
+		if result.HttpMethod != RpcHttpMethodGet {
+			t.Fatalf("expected http method GET, got %s", result.HttpMethod)
+		}
+	})
+
+	t.Run("errors will not be cached", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		httpRpcHandler.Handle(wtr, newGetRequest("test-system/me-error.v1", "1"))
+		if cc := wtr.Header().Get("Cache-Control"); cc != "" {
+			t.Fatalf("expected no cache control header, got: %s", cc)
+		}
+		errResp, err := parseHttpRpcResponse(wtr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("invalid json values are rejected", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		httpRpcHandler.Handle(wtr, newGetRequest("test-system/me.v1", "{invalid"))
+		errResp, err := parseHttpRpcResponse(wtr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil || errResp.Code != ErrParse.Code {
+			t.Fatalf("expected parse error, got: %v", errResp)
+		}
+	})
+
+	t.Run("methods not requiring HttpGet are rejected", func(t *testing.T) {
+		for _, method := range []string{"test-system/get-profile.v1", "test-system/unknown.v1"} {
+			wtr := httptest.NewRecorder()
+			httpRpcHandler.Handle(wtr, newGetRequest(method, "1"))
+			if wtr.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("expected status 405 for %s, got: %d", method, wtr.Code)
+			}
+		}
+	})
+
+	t.Run("get requests are rejected by default", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, newGetRequest("test-system/me.v1", "1"))
+		if wtr.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("expected status 405, got: %d", wtr.Code)
+		}
+	})
+}
+
+func TestHttpRpcHandlerArrayResponse(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(&NilSystem{})
+
+	single := `{"jsonrpc": "2.0", "id": 1, "method": "nil-system/void.v1"}`
+	payloads := map[string]string{
+		"single":        single,
+		"batch of one":  "[" + single + "]",
+		"batch of many": "[" + single + "," + single + "]",
+	}
+
+	tests := []struct {
+		policy   ArrayResponsePolicy
+		expected map[string]bool
+	}{
+		{"", map[string]bool{"single": false, "batch of one": true, "batch of many": true}},
+		{ArrayResponseMirrorRequest, map[string]bool{"single": false, "batch of one": true, "batch of many": true}},
+		{ArrayResponseAlways, map[string]bool{"single": true, "batch of one": true, "batch of many": true}},
+		{ArrayResponseNever, map[string]bool{"single": false, "batch of one": false, "batch of many": true}},
+	}
+
+	for _, v := range tests {
+		httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc").WithArrayResponse(v.policy)
+		for name, payload := range payloads {
+			t.Run("policy '"+string(v.policy)+"' for "+name, func(t *testing.T) {
+				wtr := httptest.NewRecorder()
+				req, _ := http.NewRequest("POST", "/rpc", strings.NewReader(payload))
+				httpRpcHandler.Handle(wtr, req)
+				if isArray := strings.HasPrefix(wtr.Body.String(), "["); isArray != v.expected[name] {
+					t.Fatalf("expected array response to be %t, got: %s", v.expected[name], wtr.Body.String())
+				}
+			})
+		}
+	}
+}
+
+func TestErrorHttpStatus(t *testing.T) {
+	for _, v := range []struct {
+		err    *Error
+		status int
+	}{
+		{ErrParse, http.StatusBadRequest},
+		{ErrServerMethodNotAllowed, http.StatusMethodNotAllowed},
+		{ErrUnauthorized, http.StatusForbidden},
+		{ErrMethodNotFound, http.StatusNotFound},
+		{ErrServiceUnavailable, http.StatusServiceUnavailable},
+		{ErrNotImplemented, http.StatusNotImplemented},
+		{ErrNotFound, http.StatusNotFound},
+		{ErrConflict, http.StatusConflict},
+		{ErrProviderTimeout, http.StatusGatewayTimeout},
+		{ErrInternal, http.StatusInternalServerError},
+	} {
+		t.Run(v.err.Message, func(t *testing.T) {
+			if status := errorHttpStatus(v.err); status != v.status {
+				t.Fatalf("expected status %d, got: %d", v.status, status)
+			}
+		})
+	}
+}
+
+func TestRegisterHttpStatus(t *testing.T) {
+	defer func() {
+		httpStatusesMux.Lock()
+		delete(httpStatuses, 10000)
+		delete(httpStatuses, ErrConflict.Code)
+		httpStatusesMux.Unlock()
+	}()
+
+	custom := &Error{Code: 10000, Message: "Account not found"}
+	if status := errorHttpStatus(custom); status != http.StatusInternalServerError {
+		t.Fatalf("expected unmapped code to be served using 500, got: %d", status)
+	}
+
+	RegisterHttpStatus(10000, http.StatusNotFound)
+	if status := errorHttpStatus(custom); status != http.StatusNotFound {
+		t.Fatalf("expected registered status 404, got: %d", status)
+	}
+
+	// registered mappings take precedence over built-in mappings
+	RegisterHttpStatus(ErrConflict.Code, http.StatusUnprocessableEntity)
+	if status := errorHttpStatus(ErrConflict); status != http.StatusUnprocessableEntity {
+		t.Fatalf("expected registered status 422, got: %d", status)
+	}
+
+	t.Run("panics on invalid status", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		RegisterHttpStatus(10001, 42)
+	})
+}
+
+type uploadV1Params struct {
+	Params
+	Name    string `json:"name"`
+	Bindata []byte `json:"-"`
+}
+
+func TestHttpRpcHandlerStreamedSingleCall(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterMethod(&MethodDefinition{
+		System:  "upload",
+		Method:  "store",
+		Version: 1,
+		HandlerFunc: func(ctx *Context, params *uploadV1Params) (string, error) {
+			return params.Name + ":" + string(params.Bindata), nil
+		},
+	})
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+	call := func(payload string) *httptest.ResponseRecorder {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/rpc", strings.NewReader(payload))
+		httpRpcHandler.Handle(wtr, req)
+		return wtr
+	}
+	request := `{"jsonrpc": "2.0", "id": 1, "method": "upload/store.v1", "params": {"name": "photo"}}`
+
+	t.Run("single call with leading whitespace", func(t *testing.T) {
+		var res string
+		errResp, err := parseHttpRpcResponse(call(" \r\n\t"+request), &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil || res != "photo:" {
+			t.Fatalf("expected call to succeed, got: %v, %s", errResp, res)
+		}
+	})
+
+	t.Run("passes remaining data as bindata", func(t *testing.T) {
+		var res string
+		errResp, err := parseHttpRpcResponse(call(request+"\nbinary"), &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil || res != "photo:binary" {
+			t.Fatalf("expected bindata to be passed, got: %v, %s", errResp, res)
+		}
+	})
+
+	t.Run("malformed single call is a parse error", func(t *testing.T) {
+		errResp, err := parseHttpRpcResponse(call(`{"jsonrpc": "2.0", "id": 1,`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil || errResp.Code != ErrParse.Code {
+			t.Fatalf("expected parse error, got: %v", errResp)
+		}
+	})
+
+	t.Run("interceptors still see the body", func(t *testing.T) {
+		seen := ""
+		httpRpcHandler.OnRequest(func(r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			seen = string(b)
+		})
+		var res string
+		errResp, err := parseHttpRpcResponse(call(request), &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil || res != "photo:" || seen != request {
+			t.Fatalf("expected call to succeed and body to be intercepted, got: %v, %s, %s", errResp, res, seen)
+		}
+	})
+}
+
+func BenchmarkHttpRpcHandlerLargeRequest(b *testing.B) {
+	fac := NewFactory()
+	methodHandler := NewMethodHandler(fac, NewDebugSecret(), nil)
+	methodHandler.RegisterMethod(&MethodDefinition{
+		System:  "upload",
+		Method:  "store",
+		Version: 1,
+		HandlerFunc: func(ctx *Context, params *GreetingV1Params) (int, error) {
+			return len(params.Name), nil
+		},
+	})
+	handler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+	body, _ := json.Marshal(&RpcRequest{
+		Version: "2.0",
+		ID:      []byte("1"),
+		Method:  "upload/store.v1",
+		Params:  []byte(`{"name":"` + strings.Repeat("a", 10<<20) + `"}`),
+	})
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("POST", "/rpc", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.Handle(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("expected status 200, got: %d", w.Code)
+		}
+	}
+}
+
+type TakeOverSystem struct{}
+
+func (s *TakeOverSystem) WriteV1(ctx *Context) error {
+	w := RequireHttpResponseWriter(ctx)
+	w.TakeOver()
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("written by method"))
+	return nil
+}
+
+func (s *TakeOverSystem) HijackV1(ctx *Context) error {
+	w := RequireHttpResponseWriter(ctx)
+	conn, buf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return err
+	}
+	w.TakeOver()
+	defer conn.Close()
+	buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+	return buf.Flush()
+}
+
+func TestHttpResponseWriterTakeOver(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(&TakeOverSystem{})
+
+	t.Run("http method handler skips writing the response", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/take-over-system/write.v1", nil)
+		NewHttpMethodHandler(methodHandler).Handle(wtr, req)
+		if wtr.Code != http.StatusAccepted || wtr.Body.String() != "written by method" {
+			t.Fatalf("expected response written by method, got: %d, %s", wtr.Code, wtr.Body.String())
+		}
+	})
+
+	t.Run("http rpc handler skips writing the response", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"take-over-system/write.v1"}`))
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, req)
+		if wtr.Code != http.StatusAccepted || wtr.Body.String() != "written by method" {
+			t.Fatalf("expected response written by method, got: %d, %s", wtr.Code, wtr.Body.String())
+		}
+	})
+
+	t.Run("allows hijacking the connection", func(t *testing.T) {
+		srv := httptest.NewServer(NewServer(NewHttpMethodHandler(methodHandler)))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/take-over-system/hijack.v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "hijacked" {
+			t.Fatalf("expected response written to hijacked connection, got: %s", body)
+		}
+	})
+}
+
+func (s *TakeOverSystem) RedirectV1(ctx *Context) error {
+	http.Redirect(RequireHttpResponseWriter(ctx), RequireHttpRequest(ctx).Request, "/login", http.StatusFound)
+	return nil
+}
+
+func TestHttpResponseWriterWritten(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(&TakeOverSystem{})
+
+	assertRedirect := func(t *testing.T, wtr *httptest.ResponseRecorder) {
+		t.Helper()
+		if wtr.Code != http.StatusFound || wtr.Header().Get("Location") != "/login" {
+			t.Fatalf("expected redirect, got: %d, %s", wtr.Code, wtr.Header().Get("Location"))
+		}
+		if strings.Contains(wtr.Body.String(), "jsonrpc") || strings.Contains(wtr.Body.String(), "null") {
+			t.Fatalf("expected handler not to write its own response, got: %s", wtr.Body.String())
+		}
+	}
+
+	t.Run("http method handler skips writing after a redirect", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/take-over-system/redirect.v1", nil)
+		NewHttpMethodHandler(methodHandler).Handle(wtr, req)
+		assertRedirect(t, wtr)
+	})
+
+	t.Run("http rpc handler skips writing after a redirect", func(t *testing.T) {
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"take-over-system/redirect.v1"}`))
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, req)
+		assertRedirect(t, wtr)
+	})
+
+	t.Run("tracks writes", func(t *testing.T) {
+		w := &HttpResponseWriter{ResponseWriter: httptest.NewRecorder()}
+		if w.Written() || w.TookOver() {
+			t.Fatal("expected fresh writer not to be written")
+		}
+		w.Write([]byte("binary"))
+		if !w.Written() || !w.TookOver() {
+			t.Fatal("expected writer to be written")
+		}
+	})
 }
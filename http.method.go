@@ -1,5 +1,7 @@
 package jonson
 
+import "reflect"
+
 // httpMethodProvider allows us to ensure
 // a specific http method has been used when using
 // the HttpMethodHandler.
@@ -53,7 +55,8 @@ func (h *httpPost) __post() {}
 // HttpGet can be used in case you want to enforce GET within your remote procedures
 // served over http.
 // In case you're using websockets or http rpc, GET cannot be enforced.
-// For rpc over http (single endpoint), POST will be enforced by default.
+// For rpc over http (single endpoint), POST will be enforced by default;
+// see HttpRpcHandler.WithGetRequests to serve methods requiring HttpGet using GET.
 // Example:
 // func (s *System) GetProfileV1(ctx *jonson.Context, _ jonson.HttpGet) error{}
 type HttpGet interface {
@@ -62,3 +65,15 @@ type HttpGet interface {
 type httpGet struct{}
 
 func (h *httpGet) __get() {}
+
+var TypeHttpGet = reflect.TypeOf((*HttpGet)(nil)).Elem()
+
+// acceptsHttpGet returns true in case the handler requires HttpGet
+func acceptsHttpGet(rt reflect.Type) bool {
+	for i := 0; i < rt.NumIn(); i++ {
+		if rt.In(i) == TypeHttpGet {
+			return true
+		}
+	}
+	return false
+}
@@ -119,7 +119,7 @@ func (i *Impersonator) Impersonate(accountUuid string, fn func(ctx *Context) err
 		// we only keep those values that have
 		// been marked explicitly shareable across impersonation
 		if _, ok := v.val.(ShareableAcrossImpersonation); ok {
-			newContext.StoreValue(v.rt, v.val)
+			newContext.borrowValue(v)
 		}
 		if v.rt == TypeImpersonated {
 			existingImpersonation = v.val.(*Impersonated)
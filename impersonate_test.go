@@ -104,4 +104,26 @@ func TestImpersonation(t *testing.T) {
 			t.Fatalf("expect impersonation to work: %s", err)
 		}
 	})
+
+	t.Run("memoized values are not shared across impersonation", func(t *testing.T) {
+		tac.isAuthenticated = true
+
+		ctx := NewContext(context.Background(), fac, nil)
+		ctx.Memoize("account", func() (any, error) {
+			return "outer", nil
+		})
+
+		err := RequireImpersonator(ctx).Impersonate(aliceUuid, func(ctx *Context) error {
+			v, _ := ctx.Memoize("account", func() (any, error) {
+				return aliceUuid, nil
+			})
+			if v != aliceUuid {
+				t.Fatalf("expected memoized value to be recomputed within impersonation, got: %v", v)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expect impersonation to work: %s", err)
+		}
+	})
 }
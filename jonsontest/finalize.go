@@ -0,0 +1,131 @@
+package jonsontest
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/doejon/jonson"
+)
+
+// FinalizeRecorder records the order in which provided values
+// implementing jonson.Finalizeable are finalized, e.g. to make sure
+// a transaction will be finalized before the database pool it has been created from.
+// Register the providers under test using the recorder instead of the factory:
+//
+//	rec := jonsontest.NewFinalizeRecorder()
+//	fac := jonson.NewFactory()
+//	rec.RegisterProvider(fac, NewDBProvider())
+//	rec.RegisterProvider(fac, NewTransactionProvider())
+//
+//	jonsontest.NewContextBoundary(t, fac, nil).MustRun(func(ctx *jonson.Context) error {
+//		RequireDB(ctx)
+//		RequireTransaction(ctx)
+//		return nil
+//	})
+//	rec.AssertOrder(t, TypeTransaction, TypeDB)
+//
+// Note that values required by a provider (e.g. the DB required by NewTransaction)
+// will be finalized before the value being provided in case the provided value
+// has been required first. Values stored using Context.StoreValue will not be recorded.
+type FinalizeRecorder struct {
+	mux   sync.Mutex
+	order []reflect.Type
+}
+
+// NewFinalizeRecorder returns a new finalize recorder
+func NewFinalizeRecorder() *FinalizeRecorder {
+	return &FinalizeRecorder{}
+}
+
+// RegisterProvider registers the provider with the factory
+// (see jonson.Factory.RegisterProvider) and records the finalization
+// of all values provided by the provider's New methods
+func (r *FinalizeRecorder) RegisterProvider(fac *jonson.Factory, provider any) {
+	rv := reflect.ValueOf(provider)
+	if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+		panic("finalize recorder: must not pass a nil provider")
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumMethod(); i++ {
+		if !strings.HasPrefix(rt.Method(i).Name, "New") {
+			continue
+		}
+		fac.RegisterProviderFunc(r.wrap(rv.Method(i)))
+	}
+}
+
+// RegisterProviderFunc registers the function with the factory
+// (see jonson.Factory.RegisterProviderFunc) and records the finalization
+// of all values provided by the function
+func (r *FinalizeRecorder) RegisterProviderFunc(fac *jonson.Factory, fn any) {
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		panic("finalize recorder: expect registered function to be a function")
+	}
+	fac.RegisterProviderFunc(r.wrap(rv))
+}
+
+// wrap returns a provider func recording the finalization of the provided value.
+// The context stores a value's placeholder before calling its provider; a cleanup
+// deferred first thing within the provider will therefore be run right before
+// the value gets finalized.
+func (r *FinalizeRecorder) wrap(fn reflect.Value) any {
+	return reflect.MakeFunc(fn.Type(), func(args []reflect.Value) []reflect.Value {
+		finalizeable := false
+		if ctx, ok := args[0].Interface().(*jonson.Context); ok && fn.Type().NumOut() == 1 {
+			rt := fn.Type().Out(0)
+			ctx.Defer(func(err error) {
+				if finalizeable {
+					r.record(rt)
+				}
+			})
+		}
+		out := fn.Call(args)
+		if len(out) == 1 {
+			_, finalizeable = out[0].Interface().(jonson.Finalizeable)
+		}
+		return out
+	}).Interface()
+}
+
+func (r *FinalizeRecorder) record(rt reflect.Type) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.order = append(r.order, rt)
+}
+
+// Order returns the types of the finalized values in order of their finalization
+func (r *FinalizeRecorder) Order() []reflect.Type {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]reflect.Type{}, r.order...)
+}
+
+// Reset forgets all finalizations recorded so far
+func (r *FinalizeRecorder) Reset() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.order = nil
+}
+
+// AssertOrder makes the test fail in case the recorded
+// finalizations do not match the expected order
+func (r *FinalizeRecorder) AssertOrder(t *testing.T, expected ...reflect.Type) {
+	t.Helper()
+	order := r.Order()
+	if len(order) == len(expected) {
+		match := true
+		for i := range order {
+			if order[i] != expected[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return
+		}
+	}
+	t.Fatalf("expected finalize order %v, got: %v", expected, order)
+}
@@ -0,0 +1,101 @@
+package jonsontest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/doejon/jonson"
+)
+
+type testPool struct{}
+
+func (p *testPool) Finalize(errs []error) error {
+	return nil
+}
+
+type testTransaction struct {
+	pool *testPool
+}
+
+func (t *testTransaction) Finalize(errs []error) error {
+	return nil
+}
+
+// testClock is not finalizeable and will not be recorded
+type testClock struct{}
+
+var (
+	typeTestPool        = reflect.TypeOf((**testPool)(nil)).Elem()
+	typeTestTransaction = reflect.TypeOf((**testTransaction)(nil)).Elem()
+	typeTestClock       = reflect.TypeOf((**testClock)(nil)).Elem()
+)
+
+type testStorageProvider struct{}
+
+func (p *testStorageProvider) NewTestPool(ctx *jonson.Context) *testPool {
+	return &testPool{}
+}
+
+func (p *testStorageProvider) NewTestTransaction(ctx *jonson.Context) *testTransaction {
+	return &testTransaction{
+		pool: ctx.Require(typeTestPool).(*testPool),
+	}
+}
+
+func TestFinalizeRecorder(t *testing.T) {
+	setup := func() (*FinalizeRecorder, *jonson.Factory) {
+		rec := NewFinalizeRecorder()
+		fac := jonson.NewFactory()
+		rec.RegisterProvider(fac, &testStorageProvider{})
+		rec.RegisterProviderFunc(fac, func(ctx *jonson.Context) *testClock {
+			return &testClock{}
+		})
+		return rec, fac
+	}
+
+	t.Run("records values in reverse require order", func(t *testing.T) {
+		rec, fac := setup()
+		NewContextBoundary(t, fac, nil).MustRun(func(ctx *jonson.Context) error {
+			ctx.Require(typeTestPool)
+			ctx.Require(typeTestClock)
+			ctx.Require(typeTestTransaction)
+			return nil
+		})
+		rec.AssertOrder(t, typeTestTransaction, typeTestPool)
+	})
+
+	t.Run("records dependencies required by providers", func(t *testing.T) {
+		rec, fac := setup()
+		NewContextBoundary(t, fac, nil).MustRun(func(ctx *jonson.Context) error {
+			ctx.Require(typeTestTransaction)
+			return nil
+		})
+		// the transaction has been required first
+		rec.AssertOrder(t, typeTestPool, typeTestTransaction)
+	})
+
+	t.Run("records finalizations of failed runs", func(t *testing.T) {
+		rec, fac := setup()
+		err := NewContextBoundary(t, fac, nil).Run(func(ctx *jonson.Context) error {
+			ctx.Require(typeTestPool)
+			return errors.New("failed")
+		})
+		if err == nil {
+			t.Fatal("expected run to fail")
+		}
+		rec.AssertOrder(t, typeTestPool)
+	})
+
+	t.Run("resets recorded finalizations", func(t *testing.T) {
+		rec, fac := setup()
+		NewContextBoundary(t, fac, nil).MustRun(func(ctx *jonson.Context) error {
+			ctx.Require(typeTestPool)
+			return nil
+		})
+		rec.Reset()
+		if len(rec.Order()) != 0 {
+			t.Fatalf("expected no finalizations, got: %v", rec.Order())
+		}
+	})
+}
@@ -100,6 +100,9 @@ func WithHttpSource(r *http.Request, w http.ResponseWriter) NewTestContextBounda
 		ctx.StoreValue(jonson.TypeHttpResponseWriter, &jonson.HttpResponseWriter{
 			ResponseWriter: w,
 		})
+		ctx.StoreValue(jonson.TypeResponseHeaders, &jonson.ResponseHeaders{
+			Header: w.Header(),
+		})
 	}
 }
 
@@ -0,0 +1,51 @@
+package jonsontest
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/doejon/jonson"
+)
+
+// SeededRand is a deterministic random source: instances
+// created with the same seed generate the same sequence of
+// uuids and bytes, allowing tests to assert generated ids.
+type SeededRand struct {
+	jonson.Shareable
+	jonson.ShareableAcrossImpersonation
+	mux  sync.Mutex
+	seed uint64
+	rnd  *rand.Rand
+}
+
+var _ = jonson.Rand(&SeededRand{})
+
+// NewSeededRand returns a new deterministic random source
+func NewSeededRand(seed uint64) *SeededRand {
+	out := &SeededRand{seed: seed}
+	out.Reset()
+	return out
+}
+
+// Reset restarts the sequence
+func (s *SeededRand) Reset() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.rnd = rand.New(rand.NewPCG(s.seed, s.seed))
+}
+
+func (s *SeededRand) UUID() string {
+	var b [16]byte
+	copy(b[:], s.Bytes(len(b)))
+	return jonson.FormatUUID(b)
+}
+
+func (s *SeededRand) Bytes(n int) []byte {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(s.rnd.UintN(256))
+	}
+	return out
+}
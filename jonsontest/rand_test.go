@@ -0,0 +1,38 @@
+package jonsontest
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSeededRand(t *testing.T) {
+	t.Run("generates a deterministic sequence", func(t *testing.T) {
+		a, b := NewSeededRand(42), NewSeededRand(42)
+		for i := 0; i < 3; i++ {
+			if x, y := a.UUID(), b.UUID(); x != y {
+				t.Fatalf("expected equal uuids, got: %s, %s", x, y)
+			}
+		}
+		if !bytes.Equal(a.Bytes(16), b.Bytes(16)) {
+			t.Fatal("expected equal bytes")
+		}
+	})
+
+	t.Run("seeds differ", func(t *testing.T) {
+		if NewSeededRand(1).UUID() == NewSeededRand(2).UUID() {
+			t.Fatal("expected different seeds to generate different uuids")
+		}
+	})
+
+	t.Run("restarts the sequence", func(t *testing.T) {
+		rnd := NewSeededRand(42)
+		first := rnd.UUID()
+		if rnd.UUID() == first {
+			t.Fatal("expected subsequent uuids to differ")
+		}
+		rnd.Reset()
+		if id := rnd.UUID(); id != first {
+			t.Fatalf("expected reset to restart the sequence, got: %s", id)
+		}
+	})
+}
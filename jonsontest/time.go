@@ -1,6 +1,7 @@
 package jonsontest
 
 import (
+	"context"
 	"time"
 
 	"github.com/doejon/jonson"
@@ -50,6 +51,10 @@ func (f *FrozenTime) Sleep(dur time.Duration) {
 	f.sleep(dur)
 }
 
+func (f *FrozenTime) SleepContext(ctx context.Context, dur time.Duration) error {
+	return sleepContext(ctx, f.sleep, dur)
+}
+
 var _ = MockTime(&FrozenTime{})
 
 // NewFrozenTime returns a new frozen time.
@@ -110,6 +115,10 @@ func (m *ReferenceTime) Sleep(dur time.Duration) {
 	m.sleep(dur)
 }
 
+func (m *ReferenceTime) SleepContext(ctx context.Context, dur time.Duration) error {
+	return sleepContext(ctx, m.sleep, dur)
+}
+
 func (m *ReferenceTime) WithSleep(slp func(time.Duration)) *ReferenceTime {
 	m.sleep = slp
 	return m
@@ -132,3 +141,22 @@ func NewReferenceTime(reference time.Time, now ...time.Time) *ReferenceTime {
 	}
 	return out
 }
+
+// sleepContext calls sleep but returns early
+// in case the context has been canceled
+func sleepContext(ctx context.Context, sleep func(time.Duration), dur time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		sleep(dur)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,31 @@
+package jonsontest
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFrozenTimeSleepContext(t *testing.T) {
+	t.Run("uses the provided sleep", func(t *testing.T) {
+		slept := time.Duration(0)
+		tm := NewFrozenTime().WithSleep(func(d time.Duration) {
+			slept = d
+		})
+		if err := tm.SleepContext(context.Background(), time.Hour); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+		if slept != time.Hour {
+			t.Fatalf("expected provided sleep to be called, got: %s", slept)
+		}
+	})
+
+	t.Run("returns early in case the context has been canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tm := NewFrozenTime()
+		if err := tm.SleepContext(ctx, time.Hour); err != context.Canceled {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+	})
+}
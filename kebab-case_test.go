@@ -27,6 +27,7 @@ func Test_ToKebabCase(t *testing.T) {
 		{"BatteryLifeValue", "battery-life-value"},
 		{"Id0Value", "id0-value"},
 		{"ID0Value", "id0-value"},
+		{"MyAPIv2", "my-ap-iv2"},
 	}
 
 	for _, test := range tests {
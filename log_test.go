@@ -2,6 +2,7 @@ package jonson
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
@@ -123,3 +124,191 @@ func TestLog(t *testing.T) {
 	})
 
 }
+
+func TestLogWithSource(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+
+	factory := NewFactory(&FactoryOptions{
+		Logger:        slog.New(slog.NewJSONHandler(buf, nil)),
+		LoggerOptions: (&LoggerOptions{}).WithSource().WithCallerRpcMeta(),
+	})
+	factory.RegisterProvider(NewTestProvider())
+	factory.RegisterProvider(NewTimeProvider())
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+	wtr := httptest.NewRecorder()
+	httpRpcHandler.Handle(wtr, newHttpRpcRequest("test-system/current-time.v1", nil))
+
+	logs := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(logs) != 2 {
+		t.Fatalf("expected two logs, got %d", len(logs))
+	}
+	for _, v := range logs {
+		out := struct {
+			Source  string   `json:"source"`
+			RpcMeta *RpcMeta `json:"rpcMeta"`
+		}{}
+		if err := json.Unmarshal([]byte(v), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Source != string(RpcSourceHttpRpc) {
+			t.Fatalf("expected source to equal 'httpRpc', got: %s", out.Source)
+		}
+		// initializers are composable
+		if out.RpcMeta == nil || out.RpcMeta.Method != "test-system/current-time.v1" {
+			t.Fatalf("expected rpc meta to be logged, got: %v", out.RpcMeta)
+		}
+	}
+}
+
+// requireWrappedLogger wraps RequireLogger
+func requireWrappedLogger(ctx *Context) *slog.Logger {
+	return RequireLogger(ctx).With("wrapped", true)
+}
+
+func logWrapped(ctx *Context) {
+	requireWrappedLogger(ctx).Info("wrapped")
+}
+
+func TestLogWithCallerFunctionSkip(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+
+	factory := NewFactory(&FactoryOptions{
+		Logger:        slog.New(slog.NewJSONHandler(buf, nil)),
+		LoggerOptions: (&LoggerOptions{}).WithCallerFunctionSkip(1),
+	})
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+
+	logWrapped(NewContext(context.Background(), factory, methodHandler))
+
+	out := struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Function.Name != "logWrapped" {
+		t.Fatalf("expected function to equal 'logWrapped', got: %s", out.Function.Name)
+	}
+}
+
+func TestLogSampler(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	tm := newMockTime(time.Now())
+
+	factory := NewFactory(&FactoryOptions{
+		Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+	})
+	factory.RegisterProvider(NewTimeProvider(func() Time {
+		return tm
+	}))
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		LogSampler: NewRateLogSampler(2, time.Second),
+	})
+
+	countLogs := func() int {
+		return strings.Count(buf.String(), "endpoint not found")
+	}
+	callUnknown := func(n int) {
+		for i := 0; i < n; i++ {
+			ctx := NewContext(context.Background(), factory, methodHandler)
+			methodHandler.CallMethod(ctx, "unknown/unknown.v1", RpcHttpMethodPost, nil, nil)
+		}
+	}
+
+	t.Run("rate sampler limits logs per period", func(t *testing.T) {
+		callUnknown(5)
+		if cnt := countLogs(); cnt != 2 {
+			t.Fatalf("expected 2 logs, got: %d", cnt)
+		}
+
+		tm.now = tm.now.Add(time.Second)
+		callUnknown(5)
+		if cnt := countLogs(); cnt != 4 {
+			t.Fatalf("expected 4 logs, got: %d", cnt)
+		}
+	})
+
+	t.Run("ratio sampler logs 1 in k", func(t *testing.T) {
+		sampler := NewRatioLogSampler(3)
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		cnt := 0
+		for i := 0; i < 9; i++ {
+			if sampler.Sample(ctx, LogSiteValidation) {
+				cnt++
+			}
+		}
+		if cnt != 3 {
+			t.Fatalf("expected 3 sampled entries, got: %d", cnt)
+		}
+	})
+}
+
+func TestLogWithoutLoggerInitializer(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+
+	factory := NewFactory(&FactoryOptions{
+		Logger:        slog.New(slog.NewJSONHandler(buf, nil)),
+		LoggerOptions: (&LoggerOptions{}).WithCallerFunction().WithSource(),
+	})
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+
+	type log struct {
+		Function *struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	parse := func(t *testing.T) *log {
+		t.Helper()
+		defer buf.Reset()
+		out := &log{}
+		if err := json.Unmarshal(buf.Bytes(), out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	t.Run("initializer is used by default", func(t *testing.T) {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		RequireLogger(ctx).Info("test")
+		if out := parse(t); out.Function == nil || out.Function.Name != "func2" {
+			t.Fatalf("expected caller function to be logged, got: %v", out.Function)
+		}
+	})
+
+	t.Run("disabled initializer will be skipped", func(t *testing.T) {
+		ctx := NewContext(context.Background(), factory, methodHandler).WithoutLoggerInitializer("function")
+		RequireLogger(ctx).Info("test")
+		if out := parse(t); out.Function != nil {
+			t.Fatalf("expected caller function not to be logged, got: %v", out.Function)
+		}
+	})
+}
+
+func BenchmarkRequireLogger(b *testing.B) {
+	factory := NewFactory(&FactoryOptions{
+		Logger:        NewNoOpLogger(),
+		LoggerOptions: (&LoggerOptions{}).WithCallerFunction(),
+	})
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+
+	b.Run("with caller function", func(b *testing.B) {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		for i := 0; i < b.N; i++ {
+			RequireLogger(ctx)
+		}
+	})
+
+	b.Run("without caller function", func(b *testing.B) {
+		ctx := NewContext(context.Background(), factory, methodHandler).WithoutLoggerInitializer("function")
+		for i := 0; i < b.N; i++ {
+			RequireLogger(ctx)
+		}
+	})
+}
@@ -1,56 +1,134 @@
 package jonson
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"reflect"
 	"regexp"
+	"runtime/debug"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // MethodDefinition is used by MustRegisterAPI
 type MethodDefinition struct {
-	System        string
-	Method        string
-	Version       uint64
-	HandlerFunc   any
-	methodContext reflect.Value
+	System      string
+	Method      string
+	Version     uint64
+	HandlerFunc any
+
+	// MaxParamsBytes limits the size of the method's params;
+	// overrides MethodHandlerOptions.MaxParamsBytes in case > 0.
+	// For methods registered using RegisterSystem, use MethodHandler.SetMaxParamsBytes.
+	MaxParamsBytes int
+
+	// Feature gates the method behind a feature flag: while the flag is disabled,
+	// calls will be rejected with ErrMethodNotFound before invoking the handler.
+	// Methods gated behind a flag are disabled in case no Features are provided.
+	// For methods registered using RegisterSystem, use MethodHandler.SetFeature.
+	Feature string
+
+	instance *systemInstance
 }
 
 var (
 	validIdentifierName = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
-	matchMethodName     = regexp.MustCompile(`^(.+)V([0-9]+)$`)
+	matchMethodName     = regexp.MustCompile(`^(.+)V([1-9][0-9]*)$`)
+	// matchEndpointLike matches method names which look like
+	// an endpoint but might contain typos (e.g. GetProfilev1)
+	matchEndpointLike = regexp.MustCompile(`^[A-Z].*[Vv][0-9]+$`)
 )
 
+// SplitMethodName splits a go method name following the <MethodName>V<version>
+// naming scheme into its kebab-cased name and version, e.g. GetProfileV2 into
+// get-profile and 2. Versions must be positive, must not contain leading zeros
+// (GetProfileV01) and must fit into an uint64; this keeps go method names and
+// routes (see GetDefaultMethodName) unambiguous. In case the method name does
+// not match the scheme, an empty name and version 0 will be returned.
 func SplitMethodName(method string) (string, uint64) {
 	sub := matchMethodName.FindStringSubmatch(method)
 	if len(sub) != 3 {
 		return "", 0
 	}
+	version, err := strconv.ParseUint(sub[2], 10, 64)
+	if err != nil {
+		// version overflows uint64
+		return "", 0
+	}
 	// we have found a valid method signature, build definition and try to register
-	methodName := ToKebabCase(sub[1])
-	version, _ := strconv.ParseUint(sub[2], 10, 64)
-	return methodName, version
+	return ToKebabCase(sub[1]), version
 }
 
 type apiEndpoint struct {
-	def           *MethodDefinition
-	handlerFunc   reflect.Value
-	methodContext reflect.Value
-	paramsPos     int
-	paramsType    reflect.Type
+	def          *MethodDefinition
+	handlerFunc  reflect.Value
+	instance     *systemInstance
+	paramsPos    int
+	paramsType   reflect.Type
+	dryRun       bool
+	httpGet      bool
+	requiresAuth bool
+	// streamField is the json name of the params' JsonStream field
+	streamField string
+	// args describe the handler's arguments (excluding the
+	// system instance) and are computed once during registration
+	args []endpointArg
+}
+
+// endpointArgKind defines how an argument will be resolved on each call
+type endpointArgKind int
+
+const (
+	endpointArgProvider endpointArgKind = iota
+	endpointArgContext
+	endpointArgParams
+)
+
+type endpointArg struct {
+	kind endpointArgKind
+	rt   reflect.Type
+}
+
+// Endpoint is a read-only description of a registered endpoint
+// passed to registration hooks, see MethodHandler.OnRegister
+type Endpoint struct {
+	// Name is the endpoint's route, e.g. account/get-profile.v1
+	Name string
+	// Requires contains the types provided to the method
+	// besides its params, e.g. *jonson.Context or jonson.HttpGet
+	Requires []reflect.Type
+	// ParamsType is the type of the method's params, e.g. *GetProfileV1Params;
+	// nil in case the method does not accept params
+	ParamsType reflect.Type
+	// ResultType is the type of the method's result;
+	// nil in case the method only returns an error
+	ResultType reflect.Type
+	// DryRun is true in case the method allows for dry runs
+	DryRun bool
+	// HttpGet is true in case the method requires HttpGet
+	HttpGet bool
+	// RequiresAuth is true in case the method requires RequiresAuth
+	RequiresAuth bool
 }
 
 type MethodHandler struct {
 	factory    *Factory
 	methodName func(system string, method string, version uint64) string
+	onRegister []func(def *MethodDefinition, ep *Endpoint)
+	// onFinalizeErrors will be called with the errors of failed finalizers
+	onFinalizeErrors []func(meta *RpcMeta, errs []error)
 
-	systems      map[reflect.Type]any
+	systems      map[reflect.Type]*systemInstance
 	endpoints    map[string]apiEndpoint
+	jsonStreams  bool
 	errorEncoder Secret
 	opts         *MethodHandlerOptions
 	logger       *slog.Logger
@@ -70,6 +148,29 @@ const (
 	MissingValidationLevelFatal  MissingValidationLevel = "fatal"
 )
 
+// NilResultPolicy defines how nil results of successful calls will be sent
+type NilResultPolicy string
+
+const (
+	// NilResultNull sends nil results as "result": null
+	NilResultNull NilResultPolicy = "null"
+	// NilResultEmptyObject sends nil results as "result": {}
+	NilResultEmptyObject NilResultPolicy = "emptyObject"
+)
+
+// VoidResultPolicy defines the result sent for successful calls
+// of void methods (methods returning an error only)
+type VoidResultPolicy string
+
+const (
+	// VoidResultNull sends "result": null
+	VoidResultNull VoidResultPolicy = "null"
+	// VoidResultEmptyObject sends "result": {}
+	VoidResultEmptyObject VoidResultPolicy = "emptyObject"
+	// VoidResultTrue sends "result": true
+	VoidResultTrue VoidResultPolicy = "true"
+)
+
 var validMissingValidationLevel = map[MissingValidationLevel]struct{}{
 	MissingValidationLevelIgnore: {},
 	MissingValidationLevelInfo:   {},
@@ -80,6 +181,151 @@ var validMissingValidationLevel = map[MissingValidationLevel]struct{}{
 
 type MethodHandlerOptions struct {
 	MissingValidationLevel MissingValidationLevel
+
+	// MisnamedMethodLevel defines how to report methods of a registered
+	// system which look like an endpoint but do not match the
+	// <MethodName>V<version> naming scheme, e.g. GetProfilev1 or GetProfileV0.
+	// Defaults to MissingValidationLevelIgnore.
+	MisnamedMethodLevel MissingValidationLevel
+
+	// AbortCanceledRequests checks the context for cancellation
+	// (e.g. the client went away or the deadline exceeded)
+	// before providers are resolved and before the handler is invoked.
+	// In case the context has been canceled, ErrRequestCanceled
+	// will be returned without doing any further work.
+	AbortCanceledRequests bool
+
+	// ProviderTimeout bounds the resolution of each provider required by a method
+	// (e.g. *Private calling a slow auth backend). Providers receive a context which
+	// will be canceled once the timeout (or the request's earlier deadline) exceeded;
+	// the call then fails with ErrProviderTimeout naming the provider's type.
+	// Providers need to pass the context on (e.g. to database or http calls) to be
+	// interrupted: the call fails once the provider returned; providers ignoring the
+	// context will not be interrupted. Values keeping the context stay usable after
+	// the resolution. Each resolution of a value not yet resolved within the call's
+	// context starts a timer.
+	// Defaults to 0 (no timeout).
+	ProviderTimeout time.Duration
+
+	// MaxBatchSize limits the number of calls within a single batch request.
+	// Batches exceeding the limit will be rejected with ErrInvalidRequest
+	// before processing any of the calls.
+	// Defaults to 0 (unlimited); it's recommended to set a limit
+	// to prevent clients from flooding the server with huge batches.
+	MaxBatchSize int
+
+	// MaxJsonDepth limits the nesting depth of incoming payloads;
+	// payloads exceeding the limit will be rejected with ErrParse
+	// before being decoded. The jsonrpc envelope counts as the first level,
+	// the params object as the second one.
+	// Defaults to 0 (unlimited); encoding/json then enforces its own limit.
+	MaxJsonDepth int
+
+	// MaxParamsBytes limits the size of each call's params; calls exceeding
+	// the limit will be rejected with ErrInvalidParams before decoding the params.
+	// The limit is checked once the whole request has been read into memory, hence
+	// it does not protect against huge bodies; use http.MaxBytesReader instead.
+	// Single calls streaming their params into a JsonStream (see JsonStream) are
+	// only checked for the fields preceding the stream field.
+	// Use MethodHandler.SetMaxParamsBytes to allow specific endpoints
+	// (e.g. bulk imports) to accept larger params.
+	// Defaults to 0 (unlimited).
+	MaxParamsBytes int
+
+	// OmitDebugInResponse removes the (encoded) debug information
+	// from errors returned to the client. The debug information will
+	// be logged instead. Defaults to false (debug information will be sent).
+	OmitDebugInResponse bool
+
+	// PoolRequests reuses the structs holding incoming requests
+	// and outgoing results across calls in order to reduce allocations
+	// for high-throughput servers. Pooled results will be reused once
+	// they have been written to the client; don't keep references to
+	// responses returned by the method handler if enabled.
+	// Defaults to false.
+	PoolRequests bool
+
+	// DecodeNumbersAsFloat64 decodes numbers within params into interface values
+	// (e.g. any or map[string]any) as float64 instead of json.Number.
+	// Numbers exceeding float64's precision (e.g. int64 ids) will lose precision.
+	// Fields of a concrete numeric type are not affected.
+	// Defaults to false.
+	DecodeNumbersAsFloat64 bool
+
+	// AllowUnknownFields accepts params containing fields which do not exist
+	// within the method's params struct; such fields will be dropped silently
+	// and cannot be checked by the params' validation.
+	// Defaults to false (unknown fields will be rejected with ErrInvalidParams).
+	AllowUnknownFields bool
+
+	// RejectDuplicateBatchIDs rejects calls within a batch
+	// which reuse the id of a previous call of the same batch;
+	// rejected calls will be answered with ErrInvalidRequest.
+	RejectDuplicateBatchIDs bool
+
+	// LogSampler allows us to sample high volume logs, such as
+	// validation errors, provider errors or unknown endpoints.
+	// By default, all entries will be logged.
+	LogSampler LogSampler
+
+	// LogPanicStacks logs the stack of each panic recovered while calling
+	// a method, including intentional panics of *Error (such as ErrUnauthorized
+	// panicked by a provider). Use it during development to find out where
+	// an error originated; keep it disabled in production.
+	LogPanicStacks bool
+
+	// ExampleSecretLevel defines how to report an AESSecret using a publicly
+	// known example key (e.g. the key of this repository's example); debug
+	// information encoded using such a key can be decoded by anyone.
+	// Use MissingValidationLevelFatal to refuse starting up and
+	// MissingValidationLevelIgnore for tests which use fixed keys.
+	// Defaults to MissingValidationLevelWarn.
+	ExampleSecretLevel MissingValidationLevel
+
+	// NilResultPolicy defines how nil results of successful calls will be sent.
+	// This affects methods returning a nil pointer or nil map; nil slices will
+	// always be sent as null. See VoidResultPolicy for void methods.
+	// Omitting the result is not an option: jsonrpc 2.0 requires
+	// the result member for successful calls.
+	// Defaults to NilResultNull.
+	NilResultPolicy NilResultPolicy
+
+	// VoidResultPolicy defines the result sent for successful calls of
+	// void methods (methods returning an error only). The policy applies
+	// to all transports: the HttpMethodHandler sends the result as body.
+	// Defaults to VoidResultNull.
+	VoidResultPolicy VoidResultPolicy
+
+	// DebugSecretLevel defines how to report a DebugSecret, which passes
+	// debug information in cleartext to the client. Set it depending on your
+	// environment, e.g. to MissingValidationLevelFatal in production.
+	// Defaults to MissingValidationLevelIgnore.
+	DebugSecretLevel MissingValidationLevel
+
+	// RequireNotificationChannel lets Context.Notify return ErrNoNotificationChannel
+	// in case the ongoing call's transport cannot push notifications (e.g. http).
+	// Defaults to false: such notifications will be dropped silently.
+	RequireNotificationChannel bool
+
+	// HandlerServerErrorLevel defines how to log errors returned by methods
+	// which map to a 5xx http status (e.g. ErrInternal or any non-*Error error).
+	// HandlerClientErrorLevel does the same for errors mapping to a 4xx http status
+	// (e.g. ErrUnauthorized). Entries contain the rpc meta, the account uuid of
+	// private calls and the params encoded using the secret.
+	// Validation and provider errors as well as panics are logged separately.
+	// MissingValidationLevelFatal will log as error: a call must never take down the server.
+	// Both default to MissingValidationLevelIgnore.
+	HandlerServerErrorLevel MissingValidationLevel
+	HandlerClientErrorLevel MissingValidationLevel
+
+	// RequestIDRedactor transforms request ids before they are logged;
+	// some clients encode personal data within their request ids.
+	// Use it to hash or drop ids or to encode them using the secret:
+	//
+	//	RequestIDRedactor: secret.Encode,
+	//
+	// Defaults to nil: ids will be logged as sent by the client.
+	RequestIDRedactor func(id string) string
 }
 
 func GetDefaultMethodName(system string, method string, version uint64) string {
@@ -97,16 +343,40 @@ func NewMethodHandler(
 	if _, ok := validMissingValidationLevel[opts.MissingValidationLevel]; !ok {
 		opts.MissingValidationLevel = MissingValidationLevelInfo
 	}
+	if _, ok := validMissingValidationLevel[opts.MisnamedMethodLevel]; !ok {
+		opts.MisnamedMethodLevel = MissingValidationLevelIgnore
+	}
+
+	if _, ok := validMissingValidationLevel[opts.ExampleSecretLevel]; !ok {
+		opts.ExampleSecretLevel = MissingValidationLevelWarn
+	}
+	if _, ok := validMissingValidationLevel[opts.DebugSecretLevel]; !ok {
+		opts.DebugSecretLevel = MissingValidationLevelIgnore
+	}
+	if _, ok := validMissingValidationLevel[opts.HandlerServerErrorLevel]; !ok {
+		opts.HandlerServerErrorLevel = MissingValidationLevelIgnore
+	}
+	if _, ok := validMissingValidationLevel[opts.HandlerClientErrorLevel]; !ok {
+		opts.HandlerClientErrorLevel = MissingValidationLevelIgnore
+	}
 
-	return &MethodHandler{
+	m := &MethodHandler{
 		factory:      factory,
 		methodName:   GetDefaultMethodName,
-		systems:      map[reflect.Type]any{},
+		systems:      map[reflect.Type]*systemInstance{},
 		endpoints:    map[string]apiEndpoint{},
 		errorEncoder: errorEncoder,
 		opts:         opts,
-		logger:       factory.logger,
+		logger:       factory.Logger(),
+	}
+
+	if secret, ok := errorEncoder.(*AESSecret); ok && secret.usesExampleKey() {
+		m.report(opts.ExampleSecretLevel, "method handler: the secret uses a publicly known example key; generate a new key using cmd/genkey")
+	}
+	if _, ok := errorEncoder.(*DebugSecret); ok {
+		m.report(opts.DebugSecretLevel, "method handler: the debug secret passes debug information in cleartext to the client")
 	}
+	return m
 }
 
 // GetSystem returns a system. The function will panic in
@@ -117,14 +387,158 @@ func (m *MethodHandler) GetSystem(sys any) any {
 	if !ok {
 		panic(fmt.Errorf("getSystem: system %v does not exist", tof))
 	}
-	return out
+	return out.get().Interface()
+}
+
+// GetSystemOf returns the registered system of type T.
+// In case the system does not exist, false will be returned.
+//
+//	account, ok := jonson.GetSystemOf[*Account](methodHandler)
+func GetSystemOf[T any](m *MethodHandler) (T, bool) {
+	instance, ok := m.systems[reflect.TypeOf((*T)(nil)).Elem()]
+	if !ok {
+		var out T
+		return out, false
+	}
+	out, ok := instance.get().Interface().(T)
+	return out, ok
+}
+
+// ReplaceSystem binds all endpoints of the registered system old to the system new,
+// e.g. to swap business logic behind a feature flag without restarting.
+// Only the bound instance changes: the set of routes remains untouched,
+// hence new must be of the same type as old or, for systems registered
+// using RegisterSystemOf, implement the registered interface.
+// ReplaceSystem is safe to be called while handling calls;
+// calls in flight will finish using old.
+func (m *MethodHandler) ReplaceSystem(old any, new any) {
+	for _, instance := range m.systems {
+		current := instance.get()
+		if current.Interface() != old {
+			continue
+		}
+		rv := reflect.ValueOf(new)
+		if !rv.IsValid() || !rv.Type().AssignableTo(current.Type()) {
+			panic(fmt.Errorf("replaceSystem: %T cannot replace system of type %v", new, current.Type()))
+		}
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			panic(errors.New("replaceSystem: expected non-nil system"))
+		}
+		// keep the registered type, e.g. the interface of RegisterSystemOf
+		bound := reflect.New(current.Type()).Elem()
+		bound.Set(rv)
+		instance.set(bound)
+		return
+	}
+	panic(fmt.Errorf("replaceSystem: system %T does not exist", old))
+}
+
+// systemInstance holds the instance the endpoints
+// of a registered system are bound to
+type systemInstance struct {
+	v atomic.Value
 }
 
-// RegisterSystem registers an entire system using reflect based method lookups
+func newSystemInstance(rv reflect.Value) *systemInstance {
+	s := &systemInstance{}
+	s.set(rv)
+	return s
+}
+
+func (s *systemInstance) get() reflect.Value {
+	return s.v.Load().(reflect.Value)
+}
+
+func (s *systemInstance) set(rv reflect.Value) {
+	s.v.Store(rv)
+}
+
+// RegisterSystem registers an entire system using reflect based method lookups.
+// The system's name will be derived from the struct's name.
 func (m *MethodHandler) RegisterSystem(sys any, routeDebugger ...func(s string)) {
+	rt := reflect.TypeOf(sys)
+	if rt.Kind() != reflect.Ptr || rt.Elem().Kind() != reflect.Struct {
+		panic(errors.New("registerSystem: expected ptr to struct"))
+	}
+	m.registerSystem(sys, ToKebabCase(rt.Elem().Name()), routeDebugger...)
+}
+
+// RegisterSystemAs registers an entire system under the given system name.
+// This allows us to compose a single system out of multiple structs, e.g.
+// AccountProfile and AccountSettings can both be registered as "account".
+// Registering the same endpoint twice will panic.
+func (m *MethodHandler) RegisterSystemAs(sys any, systemName string, routeDebugger ...func(s string)) {
+	m.registerSystem(sys, systemName, routeDebugger...)
+}
+
+// RegisterSystemOf registers sys using the method set of interface I:
+// the system's name will be derived from the interface's name and
+// only the interface's methods will be registered, dispatching to sys.
+// This allows us to register a fake implementation of a system in tests:
+//
+//	type Account interface {
+//		GetProfileV1(ctx *jonson.Context, params *GetProfileV1Params) (*GetProfileV1Result, error)
+//	}
+//
+//	jonson.RegisterSystemOf[Account](methodHandler, &FakeAccount{})
+//
+// The system can be retrieved using GetSystemOf[I].
+func RegisterSystemOf[I any](m *MethodHandler, sys I, routeDebugger ...func(s string)) {
+	it := reflect.TypeOf((*I)(nil)).Elem()
+	if it.Kind() != reflect.Interface {
+		panic(errors.New("registerSystemOf: expected interface type"))
+	}
+	rv := reflect.ValueOf(&sys).Elem()
+	if rv.IsNil() {
+		panic(errors.New("registerSystemOf: expected non-nil system"))
+	}
+	systemName := ToKebabCase(it.Name())
+	if !validIdentifierName.MatchString(systemName) {
+		panic(errors.New("registerSystemOf: invalid system name " + systemName))
+	}
+	instance := newSystemInstance(rv)
+	m.systems[it] = instance
+
+	for i := 0; i < it.NumMethod(); i++ {
+		itm := it.Method(i)
+		methodName, version := SplitMethodName(itm.Name)
+		if version == 0 {
+			continue
+		}
+		for _, v := range routeDebugger {
+			v(m.methodName(systemName, methodName, version))
+		}
+		m.RegisterMethod(&MethodDefinition{
+			System:      systemName,
+			Method:      methodName,
+			Version:     version,
+			HandlerFunc: interfaceMethodFunc(it, i).Interface(),
+			instance:    instance,
+		})
+	}
+}
+
+// interfaceMethodFunc returns a function calling the interface's i-th method
+// on the value passed as first argument; the function's signature
+// equals the signature of a method expression.
+func interfaceMethodFunc(it reflect.Type, i int) reflect.Value {
+	mt := it.Method(i).Type
+	in := []reflect.Type{it}
+	for j := 0; j < mt.NumIn(); j++ {
+		in = append(in, mt.In(j))
+	}
+	out := []reflect.Type{}
+	for j := 0; j < mt.NumOut(); j++ {
+		out = append(out, mt.Out(j))
+	}
+	return reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		return args[0].Method(i).Call(args[1:])
+	})
+}
+
+func (m *MethodHandler) registerSystem(sys any, systemName string, routeDebugger ...func(s string)) {
 	rv := reflect.ValueOf(sys)
 	rt := reflect.TypeOf(sys)
-	m.systems[rt] = sys
 
 	if rt.Kind() != reflect.Ptr {
 		panic(errors.New("registerSystem: expected ptr to struct"))
@@ -134,7 +548,11 @@ func (m *MethodHandler) RegisterSystem(sys any, routeDebugger ...func(s string))
 	if rte.Kind() != reflect.Struct {
 		panic(errors.New("registerSystem: expected ptr to struct"))
 	}
-	systemName := ToKebabCase(rte.Name())
+	if !validIdentifierName.MatchString(systemName) {
+		panic(errors.New("registerSystem: invalid system name " + systemName))
+	}
+	instance := newSystemInstance(rv)
+	m.systems[rt] = instance
 
 	for i := 0; i < rt.NumMethod(); i++ {
 		rtm := rt.Method(i)
@@ -146,16 +564,130 @@ func (m *MethodHandler) RegisterSystem(sys any, routeDebugger ...func(s string))
 			}
 
 			m.RegisterMethod(&MethodDefinition{
-				System:        systemName,
-				Method:        methodName,
-				Version:       version,
-				HandlerFunc:   rtm.Func.Interface(),
-				methodContext: rv,
+				System:      systemName,
+				Method:      methodName,
+				Version:     version,
+				HandlerFunc: rtm.Func.Interface(),
+				instance:    instance,
 			})
+			continue
+		}
+
+		if matchEndpointLike.MatchString(rtm.Name) {
+			m.report(m.opts.MisnamedMethodLevel, "registerSystem: "+rte.Name()+"."+rtm.Name+" looks like an endpoint but does not match <MethodName>V<version>; method has not been registered")
 		}
 	}
 }
 
+// report reports a message using the given level
+func (m *MethodHandler) report(level MissingValidationLevel, msg string) {
+	switch level {
+	case MissingValidationLevelIgnore:
+		// do nothing
+	case MissingValidationLevelInfo:
+		m.logger.Info(msg)
+	case MissingValidationLevelWarn:
+		m.logger.Warn(msg)
+	case MissingValidationLevelError:
+		m.logger.Error(msg)
+	case MissingValidationLevelFatal:
+		fallthrough
+	default:
+		panic(msg)
+	}
+}
+
+// require requires the given type using panic recovery
+func (m *MethodHandler) require(ctx *Context, rt reflect.Type) (v any, err error) {
+	if m.opts.ProviderTimeout > 0 {
+		return m.requireWithTimeout(ctx, rt)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = m.recoverError(r)
+		}
+	}()
+	v = ctx.Require(rt)
+	return
+}
+
+// requireWithTimeout resolves the provider using a context which will be
+// canceled once the timeout exceeded; providers need to pass the context on
+// in order to be interrupted, the call will fail once the provider returned.
+func (m *MethodHandler) requireWithTimeout(ctx *Context, rt reflect.Type) (v any, err error) {
+	// values resolved before do not need to be bounded
+	if v, err := ctx.GetValue(rt); err == nil {
+		return v, nil
+	}
+	pctx := newProviderContext(ctx.parent, m.opts.ProviderTimeout)
+	resolving := ctx.resolving
+	ctx.resolving = pctx
+	defer func() {
+		ctx.resolving = resolving
+		if pctx.release() {
+			v, err = nil, m.providerTimeoutError(rt)
+		}
+		if pctx.passesCancel() {
+			// the provider kept the context's done channel (e.g. to start
+			// a transaction): pass on cancellations until the call is done
+			ctx.Defer(func(err error) {
+				pctx.stop()
+			})
+		}
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			err = m.recoverError(r)
+		}
+	}()
+	v = ctx.Require(rt)
+	return
+}
+
+func (m *MethodHandler) providerTimeoutError(rt reflect.Type) *Error {
+	m.logger.Warn(fmt.Sprintf("method handler: provider for type '%s' timed out", rt.String()), "timeout", m.opts.ProviderTimeout)
+	return ErrProviderTimeout.CloneWithData(&ErrorData{
+		Debug: m.errorEncoder.Encode(fmt.Sprintf("provider for type '%s' exceeded %s", rt.String(), m.opts.ProviderTimeout)),
+	})
+}
+
+// SetMaxParamsBytes overrides MethodHandlerOptions.MaxParamsBytes
+// for the given registered method, e.g. "import/bulk.v1".
+// Call it during startup before handling any calls.
+func (m *MethodHandler) SetMaxParamsBytes(method string, n int) {
+	endpoint, ok := m.endpoints[method]
+	if !ok {
+		panic(fmt.Errorf("setMaxParamsBytes: method %s does not exist", method))
+	}
+	endpoint.def.MaxParamsBytes = n
+}
+
+// maxParamsBytes returns the params size limit of the endpoint
+func (m *MethodHandler) maxParamsBytes(endpoint apiEndpoint) int {
+	if endpoint.def.MaxParamsBytes > 0 {
+		return endpoint.def.MaxParamsBytes
+	}
+	return m.opts.MaxParamsBytes
+}
+
+// OnRegister adds a hook which will be called for each endpoint
+// registered afterwards, e.g. to build a route index or attach
+// metadata to endpoints within a plugin.
+// Hooks must not modify the passed definition.
+func (m *MethodHandler) OnRegister(fn func(def *MethodDefinition, ep *Endpoint)) {
+	m.onRegister = append(m.onRegister, fn)
+}
+
+// OnFinalizeErrors adds a hook which will be called in case finalizing
+// a call's context failed (e.g. a transaction could not be committed).
+// The hook receives the call's rpc meta (nil in case the context
+// does not belong to a call) and the original errors returned by the
+// finalizers, before they are masked for the client, e.g. to pass them
+// on to an access log. Hooks need to be registered before serving calls.
+func (m *MethodHandler) OnFinalizeErrors(fn func(meta *RpcMeta, errs []error)) {
+	m.onFinalizeErrors = append(m.onFinalizeErrors, fn)
+}
+
 // RegisterMethod registers a new method
 func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 	if !validIdentifierName.MatchString(def.System) {
@@ -164,12 +696,20 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 	if !validIdentifierName.MatchString(def.Method) {
 		panic(errors.New("method handler: invalid method"))
 	}
+	if def.Version == 0 {
+		// versions start at 1, see SplitMethodName
+		panic(errors.New("method handler: invalid version"))
+	}
 
 	endpoint := def.System + "/" + def.Method + ".v" + strconv.FormatUint(def.Version, 10)
 	if _, exists := m.endpoints[endpoint]; exists {
 		panic(errors.New("method handler: endpoint already registered"))
 	}
+	m.registerEndpoint(endpoint, def)
+}
 
+// registerEndpoint registers the method under the given endpoint
+func (m *MethodHandler) registerEndpoint(endpoint string, def *MethodDefinition) {
 	rv := reflect.ValueOf(def.HandlerFunc)
 	rt := rv.Type()
 	if rt.Kind() != reflect.Func {
@@ -177,7 +717,7 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 	}
 
 	paramShift := 0
-	if !def.methodContext.IsNil() {
+	if def.instance != nil {
 		// we have received a bounded method. we need to pass its context as first argument
 		paramShift = 1
 	}
@@ -186,6 +726,7 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 	var (
 		handlerName         = m.methodName(def.System, def.Method, def.Version)
 		seenTypes           = map[reflect.Type]struct{}{}
+		requires            = []reflect.Type{}
 		typeParams          reflect.Type
 		argPosParams        = -1
 		paramsSafeguardType = reflect.TypeOf((*paramsSafeguard)(nil)).Elem()
@@ -199,6 +740,7 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 		TypeContext,
 		TypeHttpRequest,
 		TypeHttpResponseWriter,
+		TypeResponseHeaders,
 		TypeWSClient,
 		TypeSecret,
 	)
@@ -214,6 +756,7 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 		// check if we have a provider
 		if isTypeSupported(providerTypes, rti) {
 			seenTypes[rti] = struct{}{}
+			requires = append(requires, rti)
 			continue
 		}
 
@@ -232,21 +775,12 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 			if !rti.Implements(validatedParamsType) {
 				// not implemented
 				errStr := handlerName + "'s param '" + rti.String() + "' does not implement 'JonsonValidate(v *jonson.Validator)' method;\n"
-				switch m.opts.MissingValidationLevel {
-				case MissingValidationLevelIgnore:
-					// do nothing
-				case MissingValidationLevelInfo:
-					m.logger.Info(errStr)
-				case MissingValidationLevelWarn:
-					m.logger.Warn(errStr)
-				case MissingValidationLevelError:
-					m.logger.Error(errStr)
-				case MissingValidationLevelFatal:
-					fallthrough
-				default:
-					panic(errStr)
-				}
+				m.report(m.opts.MissingValidationLevel, errStr)
+			}
 
+			// are the params' default values valid?
+			if err := checkDefaults(rti); err != nil {
+				panic(errors.New("method handler: " + handlerName + "'s param '" + rti.String() + "' has an invalid default value: " + err.Error()))
 			}
 
 			argPosParams = i
@@ -267,12 +801,63 @@ func (m *MethodHandler) RegisterMethod(def *MethodDefinition) {
 		panic(errors.New("method handler: " + handlerName + " must return error interface as last argument"))
 	}
 
+	args := make([]endpointArg, 0, rt.NumIn()-paramShift)
+	for i := paramShift; i < rt.NumIn(); i++ {
+		arg := endpointArg{
+			kind: endpointArgProvider,
+			rt:   rt.In(i),
+		}
+		switch {
+		case i == argPosParams:
+			arg.kind = endpointArgParams
+		case arg.rt == TypeContext:
+			arg.kind = endpointArgContext
+		}
+		args = append(args, arg)
+	}
+
+	// the auth marker must match the actual requirements
+	if hasRequiresAuth(rt) && !requiresPrivate(rt) {
+		panic(errors.New("method handler: " + handlerName + " requires RequiresAuth but does not require " + TypePrivate.String()))
+	}
+
+	streamField := ""
+	if argPosParams >= 0 {
+		streamField = jsonStreamField(typeParams)
+		m.jsonStreams = m.jsonStreams || streamField != ""
+	}
+
 	m.endpoints[endpoint] = apiEndpoint{
-		def:           def,
-		handlerFunc:   rv,
-		methodContext: def.methodContext,
-		paramsPos:     argPosParams,
-		paramsType:    typeParams,
+		def:          def,
+		handlerFunc:  rv,
+		instance:     def.instance,
+		paramsPos:    argPosParams,
+		paramsType:   typeParams,
+		dryRun:       allowsDryRun(rt),
+		httpGet:      acceptsHttpGet(rt),
+		requiresAuth: hasRequiresAuth(rt),
+		streamField:  streamField,
+		args:         args,
+	}
+
+	if len(m.onRegister) == 0 {
+		return
+	}
+	ep := &Endpoint{
+		Name:         endpoint,
+		Requires:     requires,
+		DryRun:       allowsDryRun(rt),
+		HttpGet:      acceptsHttpGet(rt),
+		RequiresAuth: hasRequiresAuth(rt),
+	}
+	if argPosParams >= 0 {
+		ep.ParamsType = rt.In(argPosParams)
+	}
+	if rt.NumOut() == 2 {
+		ep.ResultType = rt.Out(0)
+	}
+	for _, fn := range m.onRegister {
+		fn(def, ep)
 	}
 }
 
@@ -283,23 +868,24 @@ func (m *MethodHandler) CallMethod(_ctx *Context, method string, rpcHttpMethod R
 	}
 
 	// we need to make sure to create a new context here;
-	ctx := _ctx.Fork()
+	// we only keep those values that have
+	// been marked explicitly shareable
+	ctx := _ctx.forkShareable(_ctx)
 
-	for _, v := range _ctx.values {
-		if !v.valid {
-			continue
-		}
-		// we only keep those values that have
-		// been marked explicitly shareable
-		if _, ok := v.val.(Shareable); ok {
-			ctx.StoreValue(v.rt, v.val)
-		}
-	}
-
-	ctx.StoreValue(TypeRpcMeta, &RpcMeta{
+	meta := &RpcMeta{
 		Method:     method,
 		HttpMethod: rpcHttpMethod,
 		Source:     RpcSourceInternal,
+	}
+	// keep the client information of the calling rpc
+	if v, err := _ctx.GetValue(TypeRpcMeta); err == nil {
+		meta.IPAddress = v.(*RpcMeta).IPAddress
+		meta.UserAgent = v.(*RpcMeta).UserAgent
+	}
+	ctx.StoreValue(TypeRpcMeta, meta)
+	// response headers of internal calls will be discarded
+	ctx.StoreValue(TypeResponseHeaders, &ResponseHeaders{
+		Header: http.Header{},
 	})
 
 	res, err := m.callMethod(ctx, &RpcRequest{
@@ -320,20 +906,110 @@ func (m *MethodHandler) CallMethod(_ctx *Context, method string, rpcHttpMethod R
 	return nil, nil
 }
 
+// processRpcStream processes the messages read from body.
+// Single calls will be decoded while reading the body without buffering it;
+// batches (and payloads which need to be inspected as a whole,
+// see MaxJsonDepth) will be read entirely and passed to processRpcMessages.
+func (m *MethodHandler) processRpcStream(
+	source RpcSource,
+	httpMethod RpcHttpMethod,
+	r *http.Request,
+	w http.ResponseWriter,
+	headers http.Header,
+	ws *WSClient,
+	body io.Reader,
+) (resp []any, batch bool) {
+	br := bufio.NewReader(body)
+
+	// clients might send leading whitespace which is valid json
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			break
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			br.UnreadByte()
+			break
+		}
+	}
+
+	if c, err := br.Peek(1); err != nil || c[0] != '{' || m.opts.MaxJsonDepth > 0 {
+		data, err := io.ReadAll(br)
+		if err != nil {
+			m.logger.Warn("method handler: read error", "error", err)
+			resp = []any{NewRpcErrorResponse(nil, ErrParse)}
+			return
+		}
+		return m.processRpcMessages(source, httpMethod, r, w, headers, ws, data)
+	}
+
+	// single call: decode the request right away
+	dec := json.NewDecoder(br)
+	rpcRequest := m.acquireRpcRequest()
+	defer m.releaseRpcRequest(rpcRequest)
+	if m.jsonStreams {
+		// the method's params might be read while calling the method
+		stream, err := m.readStreamedRpcRequest(dec, rpcRequest)
+		if err != nil {
+			m.logger.Warn("method handler: parse error: ", "error", err)
+			resp = []any{NewRpcErrorResponse(nil, ErrParse)}
+			return
+		}
+		if stream != nil {
+			defer stream.close()
+			if rpcResponse := m.processRpcMessage(source, httpMethod, r, w, headers, ws, rpcRequest, nil); rpcResponse != nil {
+				resp = []any{rpcResponse}
+			}
+			return
+		}
+	} else if err := dec.Decode(rpcRequest); err != nil {
+		m.logger.Warn("method handler: parse error: ", "error", err)
+		resp = []any{NewRpcErrorResponse(nil, ErrParse)}
+		return
+	}
+
+	// remaining data after the call's separator will be passed as bindata
+	var bindata []byte
+	rest, err := io.ReadAll(io.MultiReader(dec.Buffered(), br))
+	if err != nil {
+		m.logger.Warn("method handler: read error", "error", err)
+		resp = []any{NewRpcErrorResponse(nil, ErrParse)}
+		return
+	}
+	if len(rest) > 1 {
+		bindata = rest[1:]
+	}
+
+	if rpcResponse := m.processRpcMessage(source, httpMethod, r, w, headers, ws, rpcRequest, bindata); rpcResponse != nil {
+		resp = []any{rpcResponse}
+	}
+	return
+}
+
 func (m *MethodHandler) processRpcMessages(
 	source RpcSource,
 	httpMethod RpcHttpMethod,
 	r *http.Request,
 	w http.ResponseWriter,
+	headers http.Header,
 	ws *WSClient,
 	data []byte,
 ) (resp []any, batch bool) {
+	// clients might send leading whitespace which is valid json
+	data = bytes.TrimLeft(data, " \t\r\n")
 	if len(data) == 0 {
 		m.logger.Info("method handler: empty body received")
 		resp = []any{NewRpcErrorResponse(nil, ErrParse)}
 		return
 	}
 
+	// refuse deeply nested payloads before decoding them
+	if m.opts.MaxJsonDepth > 0 && exceedsJsonDepth(data, m.opts.MaxJsonDepth) {
+		m.logger.Warn("method handler: max json depth exceeded", "maxJsonDepth", m.opts.MaxJsonDepth)
+		resp = []any{NewRpcErrorResponse(nil, ErrParse)}
+		return
+	}
+
 	var (
 		rpcRequests []json.RawMessage
 		bindata     []byte
@@ -357,6 +1033,13 @@ func (m *MethodHandler) processRpcMessages(
 			return
 		}
 
+		// fail on too many calls within a single batch
+		if m.opts.MaxBatchSize > 0 && len(rpcRequests) > m.opts.MaxBatchSize {
+			m.logger.Warn("method handler: max batch size exceeded", "size", len(rpcRequests), "maxBatchSize", m.opts.MaxBatchSize)
+			resp = []any{NewRpcErrorResponse(nil, ErrInvalidRequest)}
+			return
+		}
+
 		batch = true
 
 	} else if data[0] == '{' {
@@ -381,19 +1064,35 @@ func (m *MethodHandler) processRpcMessages(
 		bindata = data[off+1:]
 	}
 
+	seenIDs := map[string]struct{}{}
+
 	for _, _rpcRequest := range rpcRequests {
 		// try to unmarshal the request message into an
 		// rpc request format
-		rpcRequest := &RpcRequest{}
+		rpcRequest := m.acquireRpcRequest()
 		if err := json.Unmarshal(_rpcRequest, rpcRequest); err != nil {
 			m.logger.Warn("method handler: parse error: ", "error", err)
 			resp = append(resp, NewRpcErrorResponse(nil, ErrParse))
+			m.releaseRpcRequest(rpcRequest)
 			continue
 		}
-		if rpcResponse := m.processRpcMessage(source, httpMethod, r, w, ws, rpcRequest, bindata); rpcResponse != nil {
+		if batch && m.opts.RejectDuplicateBatchIDs && rpcRequest.ID != nil {
+			id := string(bytes.TrimSpace(rpcRequest.ID))
+			if id != "null" {
+				if _, seen := seenIDs[id]; seen {
+					m.logger.Warn("method handler: duplicate id within batch", "id", m.logRequestID(rpcRequest.ID))
+					resp = append(resp, NewRpcErrorResponse(rpcRequest.ID, ErrInvalidRequest))
+					m.releaseRpcRequest(rpcRequest)
+					continue
+				}
+				seenIDs[id] = struct{}{}
+			}
+		}
+		if rpcResponse := m.processRpcMessage(source, httpMethod, r, w, headers, ws, rpcRequest, bindata); rpcResponse != nil {
 			// ares is nil if we don't have to add a response (notifications)
 			resp = append(resp, rpcResponse)
 		}
+		m.releaseRpcRequest(rpcRequest)
 		// even if we had bindata set, make sure to clear it after passing it to the first handler
 		bindata = nil
 	}
@@ -406,6 +1105,7 @@ func (m *MethodHandler) processRpcMessage(
 	httpMethod RpcHttpMethod,
 	r *http.Request,
 	w http.ResponseWriter,
+	headers http.Header,
 	ws *WSClient,
 	rpcRequest *RpcRequest,
 	bindata []byte,
@@ -415,8 +1115,15 @@ func (m *MethodHandler) processRpcMessage(
 	ctx.StoreValue(TypeHttpRequest, &HttpRequest{
 		Request: r,
 	})
-	ctx.StoreValue(TypeHttpResponseWriter, &HttpResponseWriter{
-		ResponseWriter: w,
+	// the http handlers pass a wrapped writer in order to
+	// find out whether the call took over the response
+	ctx.StoreValue(TypeHttpResponseWriter, newHttpResponseWriter(w))
+	if headers == nil {
+		// headers cannot be sent (websocket), discard them
+		headers = http.Header{}
+	}
+	ctx.StoreValue(TypeResponseHeaders, &ResponseHeaders{
+		Header: headers,
 	})
 	if ws != nil {
 		ctx.StoreValue(TypeWSClient, ws)
@@ -427,6 +1134,8 @@ func (m *MethodHandler) processRpcMessage(
 		Method:     rpcRequest.Method,
 		HttpMethod: httpMethod,
 		Source:     source,
+		IPAddress:  IPAddress(r),
+		UserAgent:  r.UserAgent(),
 	})
 
 	// do the actual api call
@@ -437,13 +1146,13 @@ func (m *MethodHandler) processRpcMessage(
 
 	// error response
 	if err != nil {
-		if err, ok := err.(*Error); ok {
-			return NewRpcErrorResponse(rpcRequest.ID, err)
+		e, ok := err.(*Error)
+		if !ok {
+			e = ErrInternal.CloneWithData(&ErrorData{
+				Debug: m.errorEncoder.Encode(err.Error()),
+			})
 		}
-
-		return NewRpcErrorResponse(rpcRequest.ID, ErrInternal.CloneWithData(&ErrorData{
-			Debug: m.errorEncoder.Encode(err.Error()),
-		}))
+		return NewRpcErrorResponse(rpcRequest.ID, m.responseError(rpcRequest, e))
 	}
 
 	if rpcRequest.ID == nil {
@@ -451,35 +1160,183 @@ func (m *MethodHandler) processRpcMessage(
 		return nil
 	}
 
-	return NewRpcResultResponse(rpcRequest.ID, res)
+	if m.isVoidMethod(rpcRequest.Method) {
+		res = m.voidResult()
+	} else if m.opts.NilResultPolicy == NilResultEmptyObject && isNilResult(res) {
+		res = struct{}{}
+	}
 
+	return m.newRpcResultResponse(rpcRequest.ID, res)
+
+}
+
+var (
+	rpcRequestPool = sync.Pool{
+		New: func() any { return &RpcRequest{} },
+	}
+	rpcResultResponsePool = sync.Pool{
+		New: func() any { return &RpcResultResponse{} },
+	}
+)
+
+// acquireRpcRequest returns an empty request, taken
+// from the pool in case PoolRequests has been enabled
+func (m *MethodHandler) acquireRpcRequest() *RpcRequest {
+	if !m.opts.PoolRequests {
+		return &RpcRequest{}
+	}
+	return rpcRequestPool.Get().(*RpcRequest)
+}
+
+// releaseRpcRequest returns the request to the pool once it has been processed.
+// The request will be reset entirely: responses keep referencing the request's id,
+// hence the id's (and params') backing arrays must never be reused.
+func (m *MethodHandler) releaseRpcRequest(rpcRequest *RpcRequest) {
+	if !m.opts.PoolRequests {
+		return
+	}
+	*rpcRequest = RpcRequest{}
+	rpcRequestPool.Put(rpcRequest)
+}
+
+// newRpcResultResponse returns a result response, taken
+// from the pool in case PoolRequests has been enabled
+func (m *MethodHandler) newRpcResultResponse(id json.RawMessage, result any) *RpcResultResponse {
+	if !m.opts.PoolRequests {
+		return NewRpcResultResponse(id, result)
+	}
+	resp := rpcResultResponsePool.Get().(*RpcResultResponse)
+	resp.RpcResponseHeader = NewRpcResponseHeader(id)
+	resp.Result = result
+	return resp
+}
+
+// releaseResponses returns the result responses returned by processRpcMessages
+// to the pool; it must only be called once the responses have been marshaled.
+func (m *MethodHandler) releaseResponses(resp []any) {
+	if !m.opts.PoolRequests {
+		return
+	}
+	for i, v := range resp {
+		if r, ok := v.(*RpcResultResponse); ok {
+			*r = RpcResultResponse{}
+			rpcResultResponsePool.Put(r)
+		}
+		resp[i] = nil
+	}
+}
+
+// isVoidMethod returns true in case the method
+// only returns an error
+func (m *MethodHandler) isVoidMethod(method string) bool {
+	endpoint, ok := m.endpoints[method]
+	if !ok {
+		return false
+	}
+	return endpoint.handlerFunc.Type().NumOut() == 1
+}
+
+// voidResult returns the result sent for void methods
+func (m *MethodHandler) voidResult() any {
+	switch m.opts.VoidResultPolicy {
+	case VoidResultEmptyObject:
+		return struct{}{}
+	case VoidResultTrue:
+		return true
+	default:
+		return nil
+	}
+}
+
+// isNilResult returns true in case the result is nil,
+// a nil pointer or a nil map
+func isNilResult(res any) bool {
+	if res == nil {
+		return true
+	}
+	rv := reflect.ValueOf(res)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// responseError prepares an error to be sent to the client
+func (m *MethodHandler) responseError(rpcRequest *RpcRequest, err *Error) *Error {
+	if !m.opts.OmitDebugInResponse {
+		return err
+	}
+	// keep the (encoded) debug information within the logs only
+	if NewErrorInspector(err).Where(func(e *Error) bool {
+		return e.Data != nil && e.Data.Debug != ""
+	}).Count() > 0 {
+		m.logger.Info("method handler: omitted debug in response", "method", rpcRequest.Method, "error", err.String())
+	}
+	return err.Sanitize(&SanitizeOptions{
+		MaxDepth: -1,
+	})
 }
 
 func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RpcRequest, bindata []byte) (any, error) {
 	// retrieve rpc handler
 	handler, ok := m.endpoints[rpcRequest.Method]
 	if !ok {
-		m.logger.Warn("method handler: endpoint not found: ", "method", rpcRequest.Method)
+		if m.sample(ctx, LogSiteMethodNotFound) {
+			m.logger.Warn("method handler: endpoint not found: ", "method", rpcRequest.Method)
+		}
+		return nil, ErrMethodNotFound
+	}
+
+	// dark-launched methods must not be
+	// distinguishable from unknown methods
+	if !m.isFeatureEnabled(ctx, handler) {
 		return nil, ErrMethodNotFound
 	}
 
+	// dry runs must never call methods
+	// which did not explicitly opt in
+	dryRun := isDryRun(ctx)
+	if dryRun && !handler.dryRun {
+		return nil, ErrInvalidRequest.CloneWithData(&ErrorData{
+			Debug: m.errorEncoder.Encode("method " + rpcRequest.Method + " does not allow for dry runs"),
+		})
+	}
+
 	var (
-		rv         = handler.handlerFunc
-		rt         = rv.Type()
-		args       = make([]reflect.Value, rt.NumIn())
+		args       = make([]reflect.Value, len(handler.args)+1)
 		paramShift = 0
 	)
 
-	if !handler.methodContext.IsNil() {
-		// we have a methodContext we need to pass as hidden first argument
-		args[0] = handler.methodContext
+	if handler.instance != nil {
+		// we have a system instance we need to pass as hidden first argument
+		args[0] = handler.instance.get()
 		paramShift = 1
 	}
 
+	if err := m.checkCanceled(ctx); err != nil {
+		return nil, err
+	}
+
+	if handler.instance == nil {
+		// plain functions do not receive a system instance
+		args = args[1:]
+	}
+
 	// walk through arguments and assign them
-	for i := paramShift; i < rt.NumIn(); i++ {
-		// params
-		if i == handler.paramsPos {
+	for j, arg := range handler.args {
+		i := j + paramShift
+		switch arg.kind {
+		case endpointArgContext:
+			args[i] = reflect.ValueOf(ctx)
+			continue
+		case endpointArgParams:
+			if max := m.maxParamsBytes(handler); max > 0 && len(rpcRequest.Params) > max {
+				return nil, ErrInvalidParams.CloneWithData(&ErrorData{
+					Debug: m.errorEncoder.Encode(fmt.Sprintf("params exceed %d bytes", max)),
+				})
+			}
 			params := reflect.New(handler.paramsType)
 
 			// in case anything panics inside the
@@ -488,45 +1345,72 @@ func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RpcRequest, bindata
 			err := func() (err error) {
 				defer func() {
 					if r := recover(); r != nil {
-						err = getRecoverError(r)
+						err = m.recoverError(r)
 					}
 				}()
-				err = rpcRequest.UnmarshalAndValidate(m.errorEncoder, params.Interface(), bindata)
+				err = rpcRequest.unmarshalAndValidate(m.errorEncoder, params.Interface(), bindata, decodeOptions{
+					useNumber:             !m.opts.DecodeNumbersAsFloat64,
+					disallowUnknownFields: !m.opts.AllowUnknownFields,
+				})
 				return
 			}()
 
 			if err != nil {
-				m.logger.Info("method handler: validation error: ", "error", err)
+				if m.sample(ctx, LogSiteValidation) {
+					m.logger.Info("method handler: validation error: ", "error", err)
+				}
 				return nil, err
 			}
 			args[i] = params
 			continue
 		}
 
-		rti := rt.In(i)
-
 		// call provider using panic recovery
-		v, err := func() (v any, err error) {
-			defer func() {
-				if r := recover(); r != nil {
-					err = getRecoverError(r)
-				}
-			}()
-			v = ctx.Require(rti)
-			return
-		}()
-
+		v, err := m.require(ctx, arg.rt)
 		if err != nil {
-			m.logger.Warn(fmt.Sprintf("method handler: provider for type '%s' error", rti.String()), "error", err)
+			if m.sample(ctx, LogSiteProvider) {
+				m.logger.Warn(fmt.Sprintf("method handler: provider for type '%s' error", arg.rt.String()), "error", err)
+			}
 			return nil, err
 		}
 
 		args[i] = reflect.ValueOf(v)
 	}
 
+	// providers might have taken a while (network I/O),
+	// check again before doing the actual work
+	if err := m.checkCanceled(ctx); err != nil {
+		return nil, err
+	}
+
+	// params have been validated and providers have been resolved:
+	// skip the actual work
+	if dryRun {
+		return nil, nil
+	}
+
+	// call handler using panic recovery; a panicking
+	// handler must never take down other calls
+	// which are part of the same batch
+	handlerResult, err := func() (out []reflect.Value, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = m.recoverError(r)
+			}
+		}()
+		out = handler.handlerFunc.Call(args)
+		return
+	}()
+	if err != nil {
+		// handlers may panic with an *Error on purpose,
+		// e.g. by requiring a value using Require*
+		if _, ok := err.(*Error); !ok {
+			m.logger.Warn("method handler: handler panicked", "method", rpcRequest.Method, "error", err)
+		}
+		return nil, err
+	}
+
 	var (
-		err           error
-		handlerResult = handler.handlerFunc.Call(args)
 		// error is either on position 1 (data, err) or position 0 (err)
 		errIndex = len(handlerResult) - 1
 		res      any
@@ -542,6 +1426,7 @@ func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RpcRequest, bindata
 	}
 
 	if err != nil {
+		m.logHandlerError(ctx, rpcRequest, err)
 		return nil, err
 	}
 	if res != nil {
@@ -551,6 +1436,87 @@ func (m *MethodHandler) callMethod(ctx *Context, rpcRequest *RpcRequest, bindata
 	return nil, nil
 }
 
+// logHandlerError logs an error returned by a method
+// according to HandlerServerErrorLevel and HandlerClientErrorLevel
+func (m *MethodHandler) logHandlerError(ctx *Context, rpcRequest *RpcRequest, err error) {
+	level := m.opts.HandlerServerErrorLevel
+	if e, ok := err.(*Error); ok && errorHttpStatus(e) < http.StatusInternalServerError {
+		level = m.opts.HandlerClientErrorLevel
+	}
+	if level == MissingValidationLevelIgnore || !m.sample(ctx, LogSiteHandlerError) {
+		return
+	}
+
+	args := []any{"method", rpcRequest.Method, "error", err}
+	if rpcRequest.ID != nil {
+		args = append(args, "id", m.logRequestID(rpcRequest.ID))
+	}
+	if v, err := ctx.GetValue(TypeRpcMeta); err == nil {
+		meta := v.(*RpcMeta)
+		args = append(args, "source", string(meta.Source), "httpMethod", string(meta.HttpMethod))
+	}
+	// never trigger authentication for logging purposes
+	if v, err := ctx.GetValue(TypePrivate); err == nil {
+		args = append(args, "accountUuid", v.(*Private).AccountUuid())
+	}
+	if len(rpcRequest.Params) > 0 {
+		args = append(args, "params", m.errorEncoder.Encode(string(rpcRequest.Params)))
+	}
+
+	switch level {
+	case MissingValidationLevelInfo:
+		m.logger.Info("method handler: method returned error", args...)
+	case MissingValidationLevelWarn:
+		m.logger.Warn("method handler: method returned error", args...)
+	default:
+		m.logger.Error("method handler: method returned error", args...)
+	}
+}
+
+// logRequestID returns the request id to be logged
+// according to RequestIDRedactor
+func (m *MethodHandler) logRequestID(id json.RawMessage) string {
+	out := string(bytes.TrimSpace(id))
+	if m.opts.RequestIDRedactor == nil {
+		return out
+	}
+	return m.opts.RequestIDRedactor(out)
+}
+
+// sample returns true in case the log entry for site should be written
+func (m *MethodHandler) sample(ctx *Context, site LogSite) bool {
+	if m.opts.LogSampler == nil {
+		return true
+	}
+	return m.opts.LogSampler.Sample(ctx, site)
+}
+
+// checkCanceled returns ErrRequestCanceled in case
+// the context has already been canceled and canceled requests
+// should be aborted
+func (m *MethodHandler) checkCanceled(ctx *Context) error {
+	if !m.opts.AbortCanceledRequests {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		m.logger.Info("method handler: request canceled", "error", err)
+		return ErrRequestCanceled.CloneWithData(&ErrorData{
+			Debug: m.errorEncoder.Encode(err.Error()),
+		})
+	}
+	return nil
+}
+
+// recoverError converts a recovered panic into an error
+// and logs the panic's stack in case LogPanicStacks is enabled
+func (m *MethodHandler) recoverError(r any) error {
+	err := getRecoverError(r)
+	if m.opts.LogPanicStacks {
+		m.logger.Warn("method handler: recovered panic", "error", err, "stack", string(debug.Stack()))
+	}
+	return err
+}
+
 func getRecoverError(e any) error {
 	err, ok := e.(error)
 	if ok {
@@ -571,3 +1537,37 @@ func isTypeSupported(list []reflect.Type, rt reflect.Type) bool {
 	}
 	return false
 }
+
+// exceedsJsonDepth checks whether the nesting depth of objects and
+// arrays within data exceeds max. The check is iterative and does not
+// validate the payload; brackets within strings will be ignored.
+func exceedsJsonDepth(data []byte, max int) bool {
+	depth := 0
+	inString := false
+	escaped := false
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > max {
+				return true
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return false
+}
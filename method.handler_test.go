@@ -1,7 +1,16 @@
 package jonson
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -47,13 +56,9 @@ func (t *TestSystem) MeV1(ctx *Context, private *TestPrivate, _ HttpGet) (*MeV1R
 }
 
 func (t *TestSystem) MeErrorV1(ctx *Context, private *TestPrivate, _ HttpGet) (*MeV1Result, error) {
-	return nil, ErrInternal.CloneWithData(&ErrorData{
-		Details: []*Error{
-			{
-				Code:    10000,
-				Message: "failed to retrieve profiles",
-			},
-		},
+	return nil, ErrInternal.WithDetails(&Error{
+		Code:    10000,
+		Message: "failed to retrieve profiles",
 	})
 }
 
@@ -68,11 +73,55 @@ func (t *TestSystem) CheckRequirablesV1(ctx *Context, public *TestPublic, _ Http
 
 	RequireHttpRequest(ctx)
 	RequireHttpResponseWriter(ctx)
+	RequireResponseHeaders(ctx)
 	RequireSecret(ctx)
 	RequireRpcMeta(ctx)
 	return nil
 }
 
+// RpcMetaV1 returns the current rpc meta
+func (t *TestSystem) RpcMetaV1(ctx *Context, public *TestPublic) (*RpcMeta, error) {
+	return RequireRpcMeta(ctx), nil
+}
+
+// InternalRpcMetaV1 returns the rpc meta of an internal call
+func (t *TestSystem) InternalRpcMetaV1(ctx *Context, public *TestPublic) (*RpcMeta, error) {
+	res, err := ctx.CallMethod("test-system/rpc-meta.v1", RpcHttpMethodPost, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*RpcMeta), nil
+}
+
+type DeadlineV1Result struct {
+	HasDeadline bool          `json:"hasDeadline"`
+	Remaining   time.Duration `json:"remaining"`
+}
+
+// DeadlineV1 returns the current context's deadline
+func (t *TestSystem) DeadlineV1(ctx *Context, public *TestPublic) (*DeadlineV1Result, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return &DeadlineV1Result{}, nil
+	}
+	return &DeadlineV1Result{
+		HasDeadline: true,
+		Remaining:   time.Until(deadline),
+	}, nil
+}
+
+// SetHeaderV1 sets a response header
+func (t *TestSystem) SetHeaderV1(ctx *Context, public *TestPublic) error {
+	RequireResponseHeaders(ctx).Set("X-Test", "jonson")
+	return nil
+}
+
+// PanicV1 panics in order to test the method handler's
+// panic recovery
+func (t *TestSystem) PanicV1(ctx *Context, public *TestPublic) error {
+	panic("something went terribly wrong")
+}
+
 type GetProfileV1Params struct {
 	Params
 	Uuid string `json:"uuid"`
@@ -224,4 +273,1149 @@ func TestMethodHandler(t *testing.T) {
 
 	})
 
+	t.Run("a panicking provider can be required again within the same context", func(t *testing.T) {
+		testProvider.setLoggedIn(false)
+
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		require := func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = getRecoverError(r)
+				}
+			}()
+			RequireTestPrivate(ctx)
+			return nil
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := require(); err != ErrUnauthorized {
+				t.Fatalf("expected err unauthorized, got: %v", err)
+			}
+		}
+	})
+}
+
+func TestMethodHandlerAbortCanceledRequests(t *testing.T) {
+	factory := NewFactory()
+	factory.RegisterProvider(NewTestProvider())
+	factory.RegisterProvider(NewTimeProvider())
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		AbortCanceledRequests: true,
+	})
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	t.Run("pre-canceled context aborts the call", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ctx := NewContext(parent, factory, methodHandler)
+		_, err := methodHandler.CallMethod(ctx, "test-system/current-time.v1", RpcHttpMethodGet, nil, nil)
+		if err == nil {
+			t.Fatal("expected call to fail")
+		}
+		if errRes := err.(*Error); errRes.Code != ErrRequestCanceled.Code {
+			t.Fatalf("expected err request canceled, got: %v", errRes.Code)
+		}
+	})
+
+	t.Run("active context processes the call", func(t *testing.T) {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		_, err := methodHandler.CallMethod(ctx, "test-system/current-time.v1", RpcHttpMethodGet, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestMethodHandlerOmitDebugInResponse(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	factory := NewFactory(&FactoryOptions{
+		Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+	})
+	factory.RegisterProvider(NewTestProvider())
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		OmitDebugInResponse: true,
+	})
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	httpRpcHandler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+	wtr := httptest.NewRecorder()
+	httpRpcHandler.Handle(wtr, newHttpRpcRequest("test-system/panic.v1", nil))
+
+	rpcErr, err := parseHttpRpcResponse(wtr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rpcErr == nil || rpcErr.Code != ErrInternal.Code {
+		t.Fatalf("expected internal error, got: %v", rpcErr)
+	}
+	if rpcErr.Data != nil {
+		t.Fatalf("expected debug to be omitted, got: %v", rpcErr.Data)
+	}
+	if !strings.Contains(buf.String(), "something went terribly wrong") {
+		t.Fatalf("expected debug to be logged, got: %s", buf.String())
+	}
+}
+
+type MisnamedSystem struct {
+}
+
+func (m *MisnamedSystem) GetProfileV1(ctx *Context) error {
+	return nil
+}
+
+func (m *MisnamedSystem) GetProfilev2(ctx *Context) error {
+	return nil
+}
+
+type InvalidVersionSystem struct {
+}
+
+func (i *InvalidVersionSystem) GetProfileV1(ctx *Context) error {
+	return nil
+}
+
+func (i *InvalidVersionSystem) GetProfileV01(ctx *Context) error {
+	return nil
+}
+
+func (i *InvalidVersionSystem) GetProfileV0(ctx *Context) error {
+	return nil
+}
+
+func TestSplitMethodName(t *testing.T) {
+	tests := []struct {
+		input   string
+		name    string
+		version uint64
+	}{
+		{"GetProfileV1", "get-profile", 1},
+		{"GetProfileV10", "get-profile", 10},
+		{"GetProfileV4294967296", "get-profile", 4294967296},
+		{"GetProfileV18446744073709551615", "get-profile", 18446744073709551615},
+		{"MyAPIV2", "my-api", 2},
+		{"ID0ValueV3", "id0-value", 3},
+		// invalid versions
+		{"GetProfileV18446744073709551616", "", 0},
+		{"GetProfileV01", "", 0},
+		{"GetProfileV0", "", 0},
+		{"GetProfilev1", "", 0},
+		{"GetProfile", "", 0},
+		{"V1", "", 0},
+	}
+	for _, tt := range tests {
+		name, version := SplitMethodName(tt.input)
+		if name != tt.name || version != tt.version {
+			t.Errorf("SplitMethodName(%q) = %q, %d, expected %q, %d", tt.input, name, version, tt.name, tt.version)
+			continue
+		}
+		// routing and generation agree on the method's route
+		if version > 0 && GetDefaultMethodName("system", name, version) != "system/"+tt.name+".v"+strconv.FormatUint(tt.version, 10) {
+			t.Errorf("expected route of %q to contain version %d", tt.input, tt.version)
+		}
+	}
+}
+
+func TestMethodHandlerRegisterMethodVersion(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected version 0 to be rejected")
+		}
+	}()
+	NewMethodHandler(NewFactory(), NewDebugSecret(), nil).RegisterMethod(&MethodDefinition{
+		System:      "system",
+		Method:      "method",
+		Version:     0,
+		HandlerFunc: func(ctx *Context) error { return nil },
+	})
+}
+
+func TestMethodHandlerMisnamedMethodLevel(t *testing.T) {
+	t.Run("ignores misnamed methods by default", func(t *testing.T) {
+		methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		methodHandler.RegisterSystem(&MisnamedSystem{})
+	})
+
+	t.Run("panics on misnamed methods", func(t *testing.T) {
+		methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), &MethodHandlerOptions{
+			MisnamedMethodLevel: MissingValidationLevelFatal,
+		})
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Fatal("expected register system to panic")
+			}
+			if !strings.Contains(getRecoverError(r).Error(), "GetProfilev2") {
+				t.Fatalf("expected panic to name the misnamed method, got: %v", r)
+			}
+		}()
+		methodHandler.RegisterSystem(&MisnamedSystem{})
+	})
+
+	t.Run("logs misnamed methods", func(t *testing.T) {
+		buf := bytes.NewBuffer([]byte{})
+		factory := NewFactory(&FactoryOptions{
+			Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+		})
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+			MisnamedMethodLevel: MissingValidationLevelWarn,
+		})
+		methodHandler.RegisterSystem(&MisnamedSystem{})
+		if !strings.Contains(buf.String(), "GetProfilev2") || strings.Contains(buf.String(), "GetProfileV1") {
+			t.Fatalf("expected misnamed method to be logged only, got: %s", buf.String())
+		}
+	})
+
+	t.Run("reports invalid versions", func(t *testing.T) {
+		buf := bytes.NewBuffer([]byte{})
+		factory := NewFactory(&FactoryOptions{
+			Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+		})
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+			MisnamedMethodLevel: MissingValidationLevelWarn,
+		})
+		methodHandler.RegisterSystem(&InvalidVersionSystem{})
+		for _, name := range []string{"GetProfileV01 ", "GetProfileV0 "} {
+			if !strings.Contains(buf.String(), name) {
+				t.Fatalf("expected %s to be logged, got: %s", name, buf.String())
+			}
+		}
+		if _, ok := methodHandler.endpoints["invalid-version-system/get-profile.v1"]; !ok || len(methodHandler.endpoints) != 1 {
+			t.Fatalf("expected GetProfileV1 to be registered only, got: %v", methodHandler.endpoints)
+		}
+	})
+}
+
+func TestMethodHandlerExampleSecretLevel(t *testing.T) {
+	exampleSecret := NewAESSecret("962C27B021AD53CC1110E81E6F6C09D7A14F7911C508A43A")
+
+	newLoggingFactory := func() (*Factory, *bytes.Buffer) {
+		buf := bytes.NewBuffer([]byte{})
+		return NewFactory(&FactoryOptions{
+			Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+		}), buf
+	}
+
+	t.Run("warns about example keys by default", func(t *testing.T) {
+		factory, buf := newLoggingFactory()
+		NewMethodHandler(factory, exampleSecret, nil)
+		if !strings.Contains(buf.String(), "WARN") || !strings.Contains(buf.String(), "example key") {
+			t.Fatalf("expected example key to be logged, got: %s", buf.String())
+		}
+	})
+
+	t.Run("does not warn about other keys", func(t *testing.T) {
+		factory, buf := newLoggingFactory()
+		NewMethodHandler(factory, NewAESSecret("1FC1D3A1DEF6E3B3B1CB0B5E12A3D0F0"), nil)
+		if buf.Len() > 0 {
+			t.Fatalf("expected nothing to be logged, got: %s", buf.String())
+		}
+	})
+
+	t.Run("ignores example keys", func(t *testing.T) {
+		factory, buf := newLoggingFactory()
+		NewMethodHandler(factory, exampleSecret, &MethodHandlerOptions{
+			ExampleSecretLevel: MissingValidationLevelIgnore,
+		})
+		if buf.Len() > 0 {
+			t.Fatalf("expected nothing to be logged, got: %s", buf.String())
+		}
+	})
+
+	t.Run("panics on example keys", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected method handler to panic")
+			}
+		}()
+		NewMethodHandler(NewFactory(), exampleSecret, &MethodHandlerOptions{
+			ExampleSecretLevel: MissingValidationLevelFatal,
+		})
+	})
+}
+
+func TestMethodHandlerDebugSecretLevel(t *testing.T) {
+	t.Run("ignores debug secrets by default", func(t *testing.T) {
+		buf := bytes.NewBuffer([]byte{})
+		factory := NewFactory(&FactoryOptions{
+			Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+		})
+		NewMethodHandler(factory, NewDebugSecret(), nil)
+		if buf.Len() > 0 {
+			t.Fatalf("expected nothing to be logged, got: %s", buf.String())
+		}
+	})
+
+	t.Run("panics on debug secrets", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected method handler to panic")
+			}
+		}()
+		NewMethodHandler(NewFactory(), NewDebugSecret(), &MethodHandlerOptions{
+			DebugSecretLevel: MissingValidationLevelFatal,
+		})
+	})
+}
+
+type AccountProfile struct {
+}
+
+func (a *AccountProfile) GetProfileV1(ctx *Context) (string, error) {
+	return "profile", nil
+}
+
+type AccountSettings struct {
+}
+
+func (a *AccountSettings) GetSettingsV1(ctx *Context) (string, error) {
+	return "settings", nil
+}
+
+type AccountDuplicate struct {
+}
+
+func (a *AccountDuplicate) GetProfileV1(ctx *Context) (string, error) {
+	return "duplicate", nil
+}
+
+func TestMethodHandlerRegisterSystemAs(t *testing.T) {
+	factory := NewFactory()
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	methodHandler.RegisterSystemAs(&AccountProfile{}, "account")
+	methodHandler.RegisterSystemAs(&AccountSettings{}, "account")
+
+	t.Run("composes a single system", func(t *testing.T) {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		for method, expected := range map[string]string{
+			"account/get-profile.v1":  "profile",
+			"account/get-settings.v1": "settings",
+		} {
+			res, err := methodHandler.CallMethod(ctx, method, RpcHttpMethodPost, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != expected {
+				t.Fatalf("expected %s to return %s, got: %v", method, expected, res)
+			}
+		}
+	})
+
+	t.Run("returns typed systems", func(t *testing.T) {
+		if sys, ok := GetSystemOf[*AccountProfile](methodHandler); !ok || sys == nil {
+			t.Fatal("expected account profile system to exist")
+		}
+		if _, ok := GetSystemOf[*TestSystem](methodHandler); ok {
+			t.Fatal("expected test system not to exist")
+		}
+	})
+
+	t.Run("panics on duplicate endpoints", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected duplicate registration to panic")
+			}
+		}()
+		methodHandler.RegisterSystemAs(&AccountDuplicate{}, "account")
+	})
+}
+
+type GreetingV1Params struct {
+	Params
+	Name string `json:"name"`
+}
+
+func (g *GreetingV1Params) JonsonValidate(v *Validator) {}
+
+type Greeter interface {
+	GreetingV1(ctx *Context, params *GreetingV1Params) (string, error)
+	PingV1(ctx *Context) error
+	language() string
+}
+
+type EnglishGreeter struct{}
+
+func (e *EnglishGreeter) GreetingV1(ctx *Context, params *GreetingV1Params) (string, error) {
+	return "hello " + params.Name, nil
+}
+
+func (e *EnglishGreeter) PingV1(ctx *Context) error { return nil }
+
+func (e *EnglishGreeter) language() string { return "en" }
+
+// FarewellV1 is not part of the Greeter interface
+func (e *EnglishGreeter) FarewellV1(ctx *Context) (string, error) {
+	return "bye", nil
+}
+
+type FakeGreeter struct{}
+
+func (f *FakeGreeter) GreetingV1(ctx *Context, params *GreetingV1Params) (string, error) {
+	return "fake " + params.Name, nil
+}
+
+func (f *FakeGreeter) PingV1(ctx *Context) error { return nil }
+
+func (f *FakeGreeter) language() string { return "fake" }
+
+func TestMethodHandlerRegisterSystemOf(t *testing.T) {
+	call := func(t *testing.T, sys Greeter) (any, error) {
+		factory := NewFactory()
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+		routes := []string{}
+		RegisterSystemOf[Greeter](methodHandler, sys, func(s string) {
+			routes = append(routes, s)
+		})
+		if strings.Join(routes, ",") != "greeter/greeting.v1,greeter/ping.v1" {
+			t.Fatalf("expected interface methods to be registered, got: %v", routes)
+		}
+		if got, ok := GetSystemOf[Greeter](methodHandler); !ok || got != sys {
+			t.Fatal("expected system to be registered using the interface type")
+		}
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		return methodHandler.CallMethod(ctx, "greeter/greeting.v1", RpcHttpMethodPost, &GreetingV1Params{Name: "Silvio"}, nil)
+	}
+
+	t.Run("dispatches to the implementation", func(t *testing.T) {
+		res, err := call(t, &EnglishGreeter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res != "hello Silvio" {
+			t.Fatalf("expected greeting, got: %v", res)
+		}
+	})
+
+	t.Run("dispatches to a fake implementation", func(t *testing.T) {
+		res, err := call(t, &FakeGreeter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res != "fake Silvio" {
+			t.Fatalf("expected fake greeting, got: %v", res)
+		}
+	})
+
+	t.Run("panics on nil systems", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected nil system to panic")
+			}
+		}()
+		RegisterSystemOf[Greeter](NewMethodHandler(NewFactory(), NewDebugSecret(), nil), nil)
+	})
+}
+
+type VersionedSystem struct {
+	version string
+}
+
+func (v *VersionedSystem) VersionV1(ctx *Context) (string, error) {
+	return v.version, nil
+}
+
+func TestMethodHandlerReplaceSystem(t *testing.T) {
+	factory := NewFactory()
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	stable := &VersionedSystem{version: "stable"}
+	methodHandler.RegisterSystem(stable)
+	english := &EnglishGreeter{}
+	RegisterSystemOf[Greeter](methodHandler, english)
+
+	call := func(t *testing.T, method string, payload any) any {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		res, err := methodHandler.CallMethod(ctx, method, RpcHttpMethodPost, payload, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	t.Run("rebinds endpoints to the new instance", func(t *testing.T) {
+		canary := &VersionedSystem{version: "canary"}
+		methodHandler.ReplaceSystem(stable, canary)
+		if res := call(t, "versioned-system/version.v1", nil); res != "canary" {
+			t.Fatalf("expected canary to be called, got: %v", res)
+		}
+		if sys, _ := GetSystemOf[*VersionedSystem](methodHandler); sys != canary {
+			t.Fatal("expected canary to be returned as system")
+		}
+		methodHandler.ReplaceSystem(canary, stable)
+		if res := call(t, "versioned-system/version.v1", nil); res != "stable" {
+			t.Fatalf("expected stable to be called, got: %v", res)
+		}
+	})
+
+	t.Run("rebinds systems registered using an interface", func(t *testing.T) {
+		fake := &FakeGreeter{}
+		methodHandler.ReplaceSystem(english, fake)
+		defer methodHandler.ReplaceSystem(fake, english)
+		if res := call(t, "greeter/greeting.v1", &GreetingV1Params{Name: "Silvio"}); res != "fake Silvio" {
+			t.Fatalf("expected fake greeter to be called, got: %v", res)
+		}
+	})
+
+	t.Run("is safe for concurrent calls", func(t *testing.T) {
+		canary := &VersionedSystem{version: "canary"}
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					ctx := NewContext(context.Background(), factory, methodHandler)
+					methodHandler.CallMethod(ctx, "versioned-system/version.v1", RpcHttpMethodPost, nil, nil)
+				}
+			}()
+		}
+		for i := 0; i < 10; i++ {
+			methodHandler.ReplaceSystem(stable, canary)
+			methodHandler.ReplaceSystem(canary, stable)
+		}
+		wg.Wait()
+	})
+
+	t.Run("panics on invalid replacements", func(t *testing.T) {
+		for name, fn := range map[string]func(){
+			"unknown system":   func() { methodHandler.ReplaceSystem(&VersionedSystem{}, &VersionedSystem{}) },
+			"mismatching type": func() { methodHandler.ReplaceSystem(stable, &AccountProfile{}) },
+			"nil system":       func() { methodHandler.ReplaceSystem(stable, (*VersionedSystem)(nil)) },
+		} {
+			func() {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Fatalf("expected %s to panic", name)
+					}
+				}()
+				fn()
+			}()
+		}
+	})
+}
+
+func TestMethodHandlerLogPanicStacks(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+	factory := NewFactory(&FactoryOptions{
+		Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+	})
+	testProvider := NewTestProvider()
+	factory.RegisterProvider(testProvider)
+
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		LogPanicStacks: true,
+	})
+	methodHandler.RegisterSystem(NewTestSystem())
+
+	ctx := NewContext(context.Background(), factory, methodHandler)
+	_, err := methodHandler.CallMethod(ctx, "test-system/me.v1", RpcHttpMethodGet, nil, nil)
+	if err != ErrUnauthorized {
+		t.Fatalf("expected err unauthorized, got: %v", err)
+	}
+
+	// the stack contains the provider which panicked
+	if !strings.Contains(buf.String(), "NewTestPrivate") {
+		t.Fatalf("expected stack to be logged, got: %s", buf.String())
+	}
+}
+
+func TestMethodHandlerHandlerPanics(t *testing.T) {
+	call := func(t *testing.T, handlerFunc any) (error, string) {
+		t.Helper()
+		buf := bytes.NewBuffer([]byte{})
+		factory := NewFactory(&FactoryOptions{
+			Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+		})
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+		methodHandler.RegisterMethod(&MethodDefinition{
+			System:      "panic",
+			Method:      "call",
+			Version:     1,
+			HandlerFunc: handlerFunc,
+		})
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		_, err := methodHandler.CallMethod(ctx, "panic/call.v1", RpcHttpMethodPost, nil, nil)
+		return err, buf.String()
+	}
+
+	t.Run("logs unexpected panics", func(t *testing.T) {
+		err, logs := call(t, func(ctx *Context) error {
+			panic("something went terribly wrong")
+		})
+		if err == nil {
+			t.Fatal("expected call to fail")
+		}
+		if !strings.Contains(logs, "handler panicked") {
+			t.Fatalf("expected panic to be logged, got: %s", logs)
+		}
+	})
+
+	t.Run("does not log rpc errors", func(t *testing.T) {
+		err, logs := call(t, func(ctx *Context) error {
+			panic(ErrUnauthorized)
+		})
+		if err != ErrUnauthorized {
+			t.Fatalf("expected err unauthorized, got: %v", err)
+		}
+		if strings.Contains(logs, "handler panicked") {
+			t.Fatalf("expected rpc error not to be logged, got: %s", logs)
+		}
+	})
+}
+
+func TestMethodHandlerOnRegister(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	endpoints := map[string]*Endpoint{}
+	methodHandler.OnRegister(func(def *MethodDefinition, ep *Endpoint) {
+		if def.System != "greeter" {
+			t.Fatalf("expected definition of greeter system, got: %s", def.System)
+		}
+		endpoints[ep.Name] = ep
+	})
+	RegisterSystemOf[Greeter](methodHandler, &EnglishGreeter{})
+
+	if len(endpoints) != 2 {
+		t.Fatalf("expected hook to be called for 2 endpoints, got: %d", len(endpoints))
+	}
+
+	greeting := endpoints["greeter/greeting.v1"]
+	if greeting.ParamsType != reflect.TypeOf(&GreetingV1Params{}) {
+		t.Fatalf("expected params type, got: %v", greeting.ParamsType)
+	}
+	if greeting.ResultType != reflect.TypeOf("") {
+		t.Fatalf("expected result type string, got: %v", greeting.ResultType)
+	}
+	if len(greeting.Requires) != 1 || greeting.Requires[0] != TypeContext {
+		t.Fatalf("expected context to be required, got: %v", greeting.Requires)
+	}
+
+	ping := endpoints["greeter/ping.v1"]
+	if ping.ParamsType != nil || ping.ResultType != nil {
+		t.Fatalf("expected void method without params, got: %v, %v", ping.ParamsType, ping.ResultType)
+	}
+}
+
+type FailingSystem struct{}
+
+type FailV1Params struct {
+	Params
+	Reason string `json:"reason"`
+}
+
+func (f *FailV1Params) JonsonValidate(v *Validator) {}
+
+func (f *FailingSystem) FailV1(ctx *Context, params *FailV1Params) error {
+	return errors.New(params.Reason)
+}
+
+func (f *FailingSystem) DenyV1(ctx *Context) error {
+	return ErrUnauthorized
+}
+
+func TestMethodHandlerHandlerErrorLevel(t *testing.T) {
+	call := func(t *testing.T, opts *MethodHandlerOptions, method string, payload any) string {
+		buf := bytes.NewBuffer([]byte{})
+		factory := NewFactory(&FactoryOptions{
+			Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+		})
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), opts)
+		methodHandler.RegisterSystem(&FailingSystem{})
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		if _, err := methodHandler.CallMethod(ctx, method, RpcHttpMethodPost, payload, nil); err == nil {
+			t.Fatal("expected error")
+		}
+		return buf.String()
+	}
+
+	t.Run("does not log returned errors by default", func(t *testing.T) {
+		if out := call(t, nil, "failing-system/fail.v1", &FailV1Params{Reason: "db down"}); strings.Contains(out, "method returned error") {
+			t.Fatalf("expected no log entry, got: %s", out)
+		}
+	})
+
+	t.Run("logs server errors with params", func(t *testing.T) {
+		out := call(t, &MethodHandlerOptions{
+			HandlerServerErrorLevel: MissingValidationLevelWarn,
+		}, "failing-system/fail.v1", &FailV1Params{Reason: "db down"})
+		for _, v := range []string{`"level":"WARN"`, "method returned error", `"source":"internal"`, "db down", `\"reason\"`} {
+			if !strings.Contains(out, v) {
+				t.Fatalf("expected log entry to contain %s, got: %s", v, out)
+			}
+		}
+	})
+
+	t.Run("logs client errors using their own level", func(t *testing.T) {
+		opts := func() *MethodHandlerOptions {
+			return &MethodHandlerOptions{
+				HandlerServerErrorLevel: MissingValidationLevelWarn,
+			}
+		}
+		if out := call(t, opts(), "failing-system/deny.v1", nil); strings.Contains(out, "method returned error") {
+			t.Fatalf("expected client error not to be logged, got: %s", out)
+		}
+		o := opts()
+		o.HandlerClientErrorLevel = MissingValidationLevelFatal
+		if out := call(t, o, "failing-system/deny.v1", nil); !strings.Contains(out, `"level":"ERROR"`) {
+			t.Fatalf("expected client error to be logged as error, got: %s", out)
+		}
+	})
+}
+
+func TestMethodHandlerRequestIDRedactor(t *testing.T) {
+	call := func(t *testing.T, redactor func(id string) string) string {
+		buf := bytes.NewBuffer([]byte{})
+		factory := NewFactory(&FactoryOptions{
+			Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+		})
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+			RejectDuplicateBatchIDs: true,
+			HandlerClientErrorLevel: MissingValidationLevelWarn,
+			RequestIDRedactor:       redactor,
+		})
+		methodHandler.RegisterSystem(&FailingSystem{})
+		req := newHttpRpcBatchRequest(
+			&RpcRequest{Version: "2.0", ID: []byte(`"jane@example.com"`), Method: "failing-system/deny.v1"},
+			&RpcRequest{Version: "2.0", ID: []byte(`"jane@example.com"`), Method: "failing-system/deny.v1"},
+		)
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(httptest.NewRecorder(), req)
+		return buf.String()
+	}
+
+	t.Run("logs raw ids by default", func(t *testing.T) {
+		out := call(t, nil)
+		if strings.Count(out, "jane@example.com") != 2 {
+			t.Fatalf("expected raw id to be logged twice, got: %s", out)
+		}
+	})
+
+	t.Run("logs redacted ids", func(t *testing.T) {
+		out := call(t, func(id string) string {
+			return "redacted"
+		})
+		if strings.Contains(out, "jane@example.com") {
+			t.Fatalf("expected id to be redacted, got: %s", out)
+		}
+		if strings.Count(out, `"id":"redacted"`) != 2 {
+			t.Fatalf("expected redacted id to be logged twice, got: %s", out)
+		}
+	})
+}
+
+func TestMethodHandlerMaxParamsBytes(t *testing.T) {
+	factory := NewFactory()
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		MaxParamsBytes: 32,
+	})
+	RegisterSystemOf[Greeter](methodHandler, &EnglishGreeter{})
+	methodHandler.RegisterMethod(&MethodDefinition{
+		System:  "bulk",
+		Method:  "greeting",
+		Version: 1,
+		HandlerFunc: func(ctx *Context, params *GreetingV1Params) (string, error) {
+			return "hello " + params.Name, nil
+		},
+		MaxParamsBytes: 1024,
+	})
+
+	call := func(method string, name string) error {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		_, err := methodHandler.CallMethod(ctx, method, RpcHttpMethodPost, &GreetingV1Params{Name: name}, nil)
+		return err
+	}
+	long := strings.Repeat("a", 64)
+
+	t.Run("accepts params within the limit", func(t *testing.T) {
+		if err := call("greeter/greeting.v1", "Silvio"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("rejects params exceeding the limit", func(t *testing.T) {
+		err := call("greeter/greeting.v1", long)
+		if rpcErr, ok := err.(*Error); !ok || rpcErr.Code != ErrInvalidParams.Code {
+			t.Fatalf("expected invalid params, got: %v", err)
+		}
+	})
+
+	t.Run("uses the limit of the method definition", func(t *testing.T) {
+		if err := call("bulk/greeting.v1", long); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("overrides the limit of registered systems", func(t *testing.T) {
+		methodHandler.SetMaxParamsBytes("greeter/greeting.v1", 1024)
+		if err := call("greeter/greeting.v1", long); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestMethodHandlerPoolRequests(t *testing.T) {
+	factory := NewFactory()
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		PoolRequests: true,
+	})
+	RegisterSystemOf[Greeter](methodHandler, &EnglishGreeter{})
+	handler := NewHttpRpcHandler(methodHandler, "/rpc")
+
+	call := func(t *testing.T, names ...string) {
+		reqs := []*RpcRequest{}
+		for i, name := range names {
+			params, _ := json.Marshal(&GreetingV1Params{Name: name})
+			reqs = append(reqs, &RpcRequest{
+				Version: "2.0",
+				ID:      json.RawMessage(strconv.Itoa(i + 1)),
+				Method:  "greeter/greeting.v1",
+				Params:  params,
+			})
+		}
+		w := httptest.NewRecorder()
+		handler.Handle(w, newHttpRpcBatchRequest(reqs...))
+
+		var resp []struct {
+			ID     json.RawMessage `json:"id"`
+			Result string          `json:"result"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("expected batch response, got: %s", w.Body.String())
+		}
+		if len(resp) != len(names) {
+			t.Fatalf("expected %d responses, got: %s", len(names), w.Body.String())
+		}
+		for i, name := range names {
+			if string(resp[i].ID) != strconv.Itoa(i+1) || !strings.Contains(resp[i].Result, name) {
+				t.Fatalf("expected response %d to belong to %s, got: %s", i+1, name, w.Body.String())
+			}
+		}
+	}
+
+	t.Run("keeps ids and results of batches intact", func(t *testing.T) {
+		call(t, "Alice", "Bob", "Carol")
+	})
+
+	t.Run("does not leak previous calls into reused objects", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			call(t, "Dave", "Eve")
+		}
+	})
+}
+
+type measureV1Params struct {
+	Params
+	Value   any `json:"value"`
+	Samples int `json:"samples"`
+}
+
+func TestMethodHandlerDecodeOptions(t *testing.T) {
+	call := func(t *testing.T, opts *MethodHandlerOptions, params any) (any, error) {
+		t.Helper()
+		factory := NewFactory()
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), opts)
+		methodHandler.RegisterMethod(&MethodDefinition{
+			System:  "sensor",
+			Method:  "measure",
+			Version: 1,
+			HandlerFunc: func(ctx *Context, params *measureV1Params) (string, error) {
+				return reflect.TypeOf(params.Value).String(), nil
+			},
+		})
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		return methodHandler.CallMethod(ctx, "sensor/measure.v1", RpcHttpMethodPost, params, nil)
+	}
+
+	t.Run("decodes numbers as json.Number by default", func(t *testing.T) {
+		res, err := call(t, nil, map[string]any{"value": 1.5})
+		if err != nil || res != "json.Number" {
+			t.Fatalf("expected json.Number, got: %v, %v", res, err)
+		}
+	})
+
+	t.Run("decodes numbers as float64", func(t *testing.T) {
+		res, err := call(t, &MethodHandlerOptions{DecodeNumbersAsFloat64: true}, map[string]any{"value": 1.5})
+		if err != nil || res != "float64" {
+			t.Fatalf("expected float64, got: %v, %v", res, err)
+		}
+	})
+
+	t.Run("rejects unknown fields by default", func(t *testing.T) {
+		_, err := call(t, nil, map[string]any{"value": 1, "unit": "celsius"})
+		if rpcErr, ok := err.(*Error); !ok || rpcErr.Code != ErrInvalidParams.Code {
+			t.Fatalf("expected invalid params, got: %v", err)
+		}
+	})
+
+	t.Run("allows unknown fields", func(t *testing.T) {
+		_, err := call(t, &MethodHandlerOptions{AllowUnknownFields: true}, map[string]any{"value": 1, "unit": "celsius"})
+		if err != nil {
+			t.Fatalf("expected unknown field to be dropped, got: %v", err)
+		}
+	})
+
+	t.Run("reports type errors without unknown fields", func(t *testing.T) {
+		_, err := call(t, &MethodHandlerOptions{AllowUnknownFields: true}, map[string]any{"samples": "many", "unit": "celsius"})
+		rpcErr, ok := err.(*Error)
+		if !ok || rpcErr.Code != ErrInvalidParams.Code {
+			t.Fatalf("expected invalid params, got: %v", err)
+		}
+		if len(rpcErr.Data.Details) != 1 || rpcErr.Data.Details[0].Data.Path[0] != "samples" {
+			t.Fatalf("expected only the type error to be reported, got: %v", rpcErr.Data.Details)
+		}
+	})
+}
+
+type SlowProvider struct {
+	delay time.Duration
+}
+
+type Slow struct {
+	done <-chan struct{}
+}
+
+func (p *SlowProvider) NewSlow(ctx *Context) *Slow {
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		panic(ctx.Err())
+	}
+	return &Slow{done: ctx.Done()}
+}
+
+func TestMethodHandlerProviderTimeout(t *testing.T) {
+	call := func(t *testing.T, delay time.Duration, parent context.Context, handle func(slow *Slow) error) (error, string) {
+		t.Helper()
+		buf := bytes.NewBuffer([]byte{})
+		factory := NewFactory(&FactoryOptions{
+			Logger: slog.New(slog.NewJSONHandler(buf, nil)),
+		})
+		factory.RegisterProvider(&SlowProvider{delay: delay})
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+			ProviderTimeout: time.Millisecond * 50,
+		})
+		methodHandler.RegisterMethod(&MethodDefinition{
+			System:  "slow",
+			Method:  "call",
+			Version: 1,
+			HandlerFunc: func(ctx *Context, slow *Slow) error {
+				return handle(slow)
+			},
+		})
+		ctx := NewContext(parent, factory, methodHandler)
+		_, err := methodHandler.CallMethod(ctx, "slow/call.v1", RpcHttpMethodPost, nil, nil)
+		return err, buf.String()
+	}
+
+	t.Run("fails with the provider's type once the timeout exceeded", func(t *testing.T) {
+		err, out := call(t, time.Second*5, context.Background(), func(slow *Slow) error {
+			t.Fatal("expected method not to be called")
+			return nil
+		})
+		if rpcErr, ok := err.(*Error); !ok || rpcErr.Code != ErrProviderTimeout.Code {
+			t.Fatalf("expected ErrProviderTimeout, got: %v", err)
+		}
+		if !strings.Contains(out, "provider for type '*jonson.Slow' timed out") {
+			t.Fatalf("expected provider type to be logged, got: %s", out)
+		}
+	})
+
+	t.Run("uses the request's earlier deadline", func(t *testing.T) {
+		parent, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+		started := time.Now()
+		err, _ := call(t, time.Second*5, parent, func(slow *Slow) error {
+			return nil
+		})
+		if rpcErr, ok := err.(*Error); !ok || rpcErr.Code != ErrProviderTimeout.Code {
+			t.Fatalf("expected ErrProviderTimeout, got: %v", err)
+		}
+		if time.Since(started) > time.Millisecond*45 {
+			t.Fatalf("expected request deadline to be used, took: %s", time.Since(started))
+		}
+	})
+
+	t.Run("keeps the context of resolved providers usable", func(t *testing.T) {
+		err, _ := call(t, 0, context.Background(), func(slow *Slow) error {
+			time.Sleep(time.Millisecond * 100)
+			select {
+			case <-slow.done:
+				return errors.New("expected context not to be canceled after resolution")
+			default:
+				return nil
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+type providerStdKey struct{}
+
+// StubbornProvider ignores its context
+type StubbornProvider struct {
+	finalized bool
+}
+
+type Stubborn struct {
+	provider *StubbornProvider
+}
+
+func (s *Stubborn) Finalize(errs []error) error {
+	s.provider.finalized = true
+	return nil
+}
+
+func (p *StubbornProvider) NewStubborn(ctx *Context) *Stubborn {
+	time.Sleep(time.Millisecond * 100)
+	return &Stubborn{provider: p}
+}
+
+func TestMethodHandlerProviderTimeoutContext(t *testing.T) {
+	newMethodHandler := func(factory *Factory, handlerFunc any) *MethodHandler {
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+			ProviderTimeout: time.Millisecond * 50,
+		})
+		methodHandler.RegisterMethod(&MethodDefinition{
+			System:      "provider",
+			Method:      "call",
+			Version:     1,
+			HandlerFunc: handlerFunc,
+		})
+		return methodHandler
+	}
+
+	t.Run("keeps std values stored by providers", func(t *testing.T) {
+		factory := NewFactory()
+		factory.RegisterProviderFunc(func(ctx *Context) *TestPrivate {
+			ctx.WithStdValue(providerStdKey{}, "tenant")
+			return &TestPrivate{}
+		})
+		methodHandler := newMethodHandler(factory, func(ctx *Context, private *TestPrivate) (string, error) {
+			v, _ := StdValue[string](ctx, providerStdKey{})
+			return v, nil
+		})
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		res, err := methodHandler.CallMethod(ctx, "provider/call.v1", RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res != "tenant" {
+			t.Fatalf("expected std value stored by provider to be kept, got: %v", res)
+		}
+	})
+
+	t.Run("fails providers ignoring their context once they returned", func(t *testing.T) {
+		provider := &StubbornProvider{}
+		factory := NewFactory()
+		factory.RegisterProvider(provider)
+		methodHandler := newMethodHandler(factory, func(ctx *Context, s *Stubborn) error {
+			t.Error("expected method not to be called")
+			return nil
+		})
+
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		_, err := methodHandler.CallMethod(ctx, "provider/call.v1", RpcHttpMethodPost, nil, nil)
+		if rpcErr, ok := err.(*Error); !ok || rpcErr.Code != ErrProviderTimeout.Code {
+			t.Fatalf("expected ErrProviderTimeout, got: %v", err)
+		}
+		if !provider.finalized {
+			t.Fatal("expected provided value to be finalized with the call")
+		}
+	})
+
+	t.Run("stops passing on cancellations once released", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		pctx := newProviderContext(parent, time.Minute)
+		pctx.release()
+		cancel()
+		if pctx.Err() != nil {
+			t.Fatalf("expected released context not to be canceled, got: %s", pctx.Err())
+		}
+	})
+
+	t.Run("passes on cancellations to done channels handed out", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		pctx := newProviderContext(parent, time.Minute)
+		done := pctx.Done()
+		pctx.release()
+		if !pctx.passesCancel() {
+			t.Fatal("expected cancellations to be passed on")
+		}
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			t.Fatal("expected done channel to be closed once the parent has been canceled")
+		}
+		pctx.stop()
+	})
+}
+
+type BenchSystem struct{}
+
+func (b *BenchSystem) ProfileV1(ctx *Context, tm Time, pub *TestPublic, logger *slog.Logger, meta *RequestMeta, params *GreetingV1Params) (string, error) {
+	return params.Name, nil
+}
+
+func BenchmarkMethodHandlerCallMethod(b *testing.B) {
+	factory := NewFactory()
+	factory.RegisterProvider(NewTimeProvider())
+	factory.RegisterProvider(NewTestProvider())
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(&BenchSystem{})
+
+	req := &RpcRequest{
+		Version: "2.0",
+		Method:  "bench-system/profile.v1",
+		Params:  json.RawMessage(`{"name":"alice"}`),
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		if _, err := methodHandler.callMethod(ctx, req, nil); err != nil {
+			b.Fatal(err)
+		}
+		ctx.Finalize(nil)
+	}
+}
+
+func BenchmarkMethodHandlerProcessRpcMessages(b *testing.B) {
+	batch := []*RpcRequest{}
+	for i := 0; i < 10; i++ {
+		batch = append(batch, &RpcRequest{
+			Version: "2.0",
+			ID:      json.RawMessage(strconv.Itoa(i)),
+			Method:  "bench-system/profile.v1",
+			Params:  json.RawMessage(`{"name":"alice"}`),
+		})
+	}
+	data, _ := json.Marshal(batch)
+
+	for _, pool := range []bool{false, true} {
+		b.Run("pool="+strconv.FormatBool(pool), func(b *testing.B) {
+			factory := NewFactory()
+			factory.RegisterProvider(NewTimeProvider())
+			factory.RegisterProvider(NewTestProvider())
+			methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+				PoolRequests: pool,
+			})
+			methodHandler.RegisterSystem(&BenchSystem{})
+			r := httptest.NewRequest("POST", "/rpc", nil)
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				resp, _ := methodHandler.processRpcMessages(RpcSourceHttpRpc, RpcHttpMethodPost, r, nil, nil, nil, data)
+				if len(resp) != len(batch) {
+					b.Fatalf("expected %d responses, got: %d", len(batch), len(resp))
+				}
+				if _, err := json.Marshal(resp); err != nil {
+					b.Fatal(err)
+				}
+				methodHandler.releaseResponses(resp)
+			}
+		})
+	}
 }
@@ -0,0 +1,192 @@
+package jonson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// decodeOptions configure the json decoder used to decode params
+type decodeOptions struct {
+	// useNumber decodes numbers into interface values as json.Number instead of float64
+	useNumber bool
+	// disallowUnknownFields rejects params containing fields unknown to the target struct
+	disallowUnknownFields bool
+}
+
+var defaultDecodeOptions = decodeOptions{
+	useNumber:             true,
+	disallowUnknownFields: true,
+}
+
+func (o decodeOptions) newDecoder(data []byte) *json.Decoder {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if o.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if o.useNumber {
+		dec.UseNumber()
+	}
+	return dec
+}
+
+// decodeErrorDetails returns field level details for a failed decode of params into out.
+// Each top-level field will be decoded separately in order to report all
+// fields carrying invalid types or unknown fields instead of the first one only.
+// Syntax errors do not carry field information; nil will be returned.
+func decodeErrorDetails(params json.RawMessage, out any, err error, opts decodeOptions) []*Error {
+	rt := reflect.TypeOf(out)
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+
+	fields := map[string]json.RawMessage{}
+	if rt.Kind() != reflect.Struct || json.Unmarshal(params, &fields) != nil {
+		if detail := decodeErrorDetail(err); detail != nil {
+			return []*Error{detail}
+		}
+		return nil
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	jsonFields := structJsonFields(rt)
+	details := []*Error{}
+	for _, key := range keys {
+		ft, ok := lookupJsonField(jsonFields, key)
+		if !ok {
+			if opts.disallowUnknownFields {
+				details = append(details, newDecodeErrorDetail([]string{key}, "unknown field"))
+			}
+			continue
+		}
+
+		dec := opts.newDecoder(fields[key])
+		if err := dec.Decode(reflect.New(ft).Interface()); err != nil {
+			if detail := decodeErrorDetail(err); detail != nil {
+				detail.Data.Path = append([]string{key}, detail.Data.Path...)
+				details = append(details, detail)
+			}
+		}
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// decodeErrorDetail converts a single decode error into an error
+// carrying the field's path; nil will be returned for errors without
+// field information
+func decodeErrorDetail(err error) *Error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		path := []string{}
+		if typeErr.Field != "" {
+			path = strings.Split(typeErr.Field, ".")
+		}
+		return newDecodeErrorDetail(path, "invalid type: expected "+jsonKind(typeErr.Type)+", got "+typeErr.Value)
+	}
+
+	// the json package does not expose a typed error for unknown fields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if name, err := strconv.Unquote(field); err == nil {
+			return newDecodeErrorDetail([]string{name}, "unknown field")
+		}
+	}
+	return nil
+}
+
+func newDecodeErrorDetail(path []string, message string) *Error {
+	return &Error{
+		Code:    ErrInvalidParams.Code,
+		Message: message,
+		Data: &ErrorData{
+			Path: path,
+		},
+	}
+}
+
+// structJsonFields returns the struct's fields by their json names,
+// including the fields of embedded structs
+func structJsonFields(rt reflect.Type) map[string]reflect.Type {
+	out := map[string]reflect.Type{}
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			et := f.Type
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				for k, v := range structJsonFields(et) {
+					if _, ok := out[k]; !ok {
+						out[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out[name] = f.Type
+	}
+	return out
+}
+
+// lookupJsonField looks up the field by its json name;
+// same as the json package, keys are matched case-insensitively
+// in case there is no exact match
+func lookupJsonField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if ft, ok := fields[key]; ok {
+		return ft, true
+	}
+	for name, ft := range fields {
+		if strings.EqualFold(name, key) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
+
+// jsonKind returns the json kind of the given type using
+// the same names as json.UnmarshalTypeError.Value
+func jsonKind(rt reflect.Type) string {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	switch rt.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return rt.String()
+	}
+}
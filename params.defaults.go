@@ -0,0 +1,131 @@
+package jonson
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var typeDuration = reflect.TypeOf(time.Duration(0))
+
+// applyDefaults sets the values of the `default` struct tags
+// to all fields which still hold their zero value after decoding.
+// Nested structs and non-nil pointers to structs will be handled as well.
+func applyDefaults(rv reflect.Value) error {
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+
+		tag, ok := f.Tag.Lookup("default")
+		if !ok {
+			if err := applyDefaults(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if !fv.IsZero() {
+			continue
+		}
+		v, err := parseDefault(f.Type, tag)
+		if err != nil {
+			return fmt.Errorf("field '%s': %w", f.Name, err)
+		}
+		fv.Set(v)
+	}
+	return nil
+}
+
+// checkDefaults makes sure all `default` struct tags
+// of the given type can be parsed
+func checkDefaults(rt reflect.Type) error {
+	return checkTypeDefaults(rt, map[reflect.Type]bool{})
+}
+
+// checkTypeDefaults checks each struct type once;
+// params may refer to themselves (e.g. trees or linked lists)
+func checkTypeDefaults(rt reflect.Type, visited map[reflect.Type]bool) error {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct || visited[rt] {
+		return nil
+	}
+	visited[rt] = true
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, ok := f.Tag.Lookup("default")
+		if !ok {
+			if err := checkTypeDefaults(f.Type, visited); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := parseDefault(f.Type, tag); err != nil {
+			return fmt.Errorf("field '%s': %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// parseDefault parses a default tag's value for the given type
+func parseDefault(rt reflect.Type, tag string) (reflect.Value, error) {
+	v := reflect.New(rt).Elem()
+	if rt == typeDuration {
+		d, err := time.ParseDuration(tag)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	}
+
+	switch rt.Kind() {
+	case reflect.String:
+		v.SetString(tag)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(tag)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(tag, 10, rt.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(tag, 10, rt.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(tag, rt.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(f)
+	default:
+		return v, fmt.Errorf("unsupported default type: %v; strings, numbers, booleans and time.Duration are the only supported types", rt)
+	}
+	return v, nil
+}
@@ -5,7 +5,18 @@ type paramsSafeguard interface {
 	_isParams()
 }
 
-// Params must be embedded as first element in all value containers
+// Params must be embedded as first element in all value containers.
+// Fields may declare a default value using the `default` struct tag:
+//
+//	type ListV1Params struct {
+//		jonson.Params
+//		Limit int `json:"limit" default:"20"`
+//	}
+//
+// Defaults will be applied after decoding and before validation
+// to all fields still holding their zero value; hence an explicitly
+// passed zero value (e.g. "limit": 0) will be replaced by the default as well.
+// Strings, numbers, booleans and time.Duration are supported.
 type Params struct {
 }
 
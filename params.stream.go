@@ -0,0 +1,358 @@
+package jonson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrJsonStreamClosed will be returned by JsonStream.Decode
+	// in case the stream is being read after the method returned
+	ErrJsonStreamClosed = errors.New("jonson: json stream has been closed")
+
+	errJsonStreamNoArray = errors.New("jonson: json stream expects an array")
+	// fields following the stream field of streamed params
+	// cannot be passed to the method anymore
+	errJsonStreamTrailingFields = errors.New("jonson: json stream must be the last field of the request")
+)
+
+var typeJsonStream = reflect.TypeOf((*JsonStream)(nil))
+
+// JsonStream allows params to receive a (possibly huge) array
+// which will be decoded one element at a time, e.g. for bulk imports:
+//
+//	type ImportV1Params struct {
+//		jonson.Params
+//		Source  string             `json:"source"`
+//		Records *jonson.JsonStream `json:"records"`
+//	}
+//
+//	func (s *System) ImportV1(ctx *jonson.Context, params *ImportV1Params) error {
+//		for params.Records.More() {
+//			record := &Record{}
+//			if err := params.Records.Decode(record); err != nil {
+//				return err
+//			}
+//		}
+//		return params.Records.Err()
+//	}
+//
+// Single calls received by the HttpRpcHandler will be read from the request body
+// while the handler decodes the elements, without buffering the array. This requires
+// the envelope's id and method to precede the params and the stream field to be the last
+// field of the params and the request; in case any fields follow the stream field,
+// the stream will fail with an error once the array has been read.
+// In all other cases (e.g. batches, websockets or CallMethod), the array will be buffered.
+// The stream must not be used once the method returned.
+type JsonStream struct {
+	raw  json.RawMessage
+	dec  *json.Decoder
+	opts decodeOptions
+	// streamed streams read the request body
+	// which needs to end after the array
+	streamed bool
+
+	started bool
+	done    bool
+	err     error
+}
+
+// NewJsonStream returns a stream reading the given array,
+// e.g. to pass a stream to CallMethod
+func NewJsonStream(data json.RawMessage) *JsonStream {
+	return &JsonStream{
+		raw:  data,
+		opts: defaultDecodeOptions,
+	}
+}
+
+// newStreamedJsonStream returns a stream reading the next value of dec
+func newStreamedJsonStream(dec *json.Decoder, opts decodeOptions) *JsonStream {
+	// the options only affect values decoded from now on
+	if opts.disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if opts.useNumber {
+		dec.UseNumber()
+	}
+	return &JsonStream{
+		dec:      dec,
+		opts:     opts,
+		streamed: true,
+	}
+}
+
+// UnmarshalJSON buffers the array in case the stream could not be read directly
+func (s *JsonStream) UnmarshalJSON(data []byte) error {
+	s.raw = append(json.RawMessage{}, data...)
+	s.opts = defaultDecodeOptions
+	return nil
+}
+
+// MarshalJSON returns the buffered array; streams
+// being read from a request body cannot be marshaled
+func (s *JsonStream) MarshalJSON() ([]byte, error) {
+	if s.dec != nil {
+		return nil, errors.New("jonson: streamed json stream cannot be marshaled")
+	}
+	if s.raw == nil {
+		return []byte("null"), nil
+	}
+	return s.raw, nil
+}
+
+// More reports whether there is another element to be decoded
+func (s *JsonStream) More() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	if !s.started {
+		s.start()
+		if s.done || s.err != nil {
+			return false
+		}
+	}
+	if s.dec.More() {
+		return true
+	}
+	// consume the array's closing bracket
+	if _, err := s.dec.Token(); err != nil {
+		s.err = err
+	}
+	s.finish()
+	return false
+}
+
+// Decode decodes the next element into v;
+// call More before in order to check for remaining elements
+func (s *JsonStream) Decode(v any) error {
+	if !s.More() {
+		if s.err != nil {
+			return s.err
+		}
+		return errors.New("jonson: json stream has no more elements")
+	}
+	if err := s.dec.Decode(v); err != nil {
+		s.err = err
+		return err
+	}
+	return nil
+}
+
+// Err returns the error which occurred while reading the stream
+func (s *JsonStream) Err() error {
+	return s.err
+}
+
+func (s *JsonStream) start() {
+	s.started = true
+	if s.dec == nil {
+		if s.raw == nil {
+			s.done = true
+			return
+		}
+		s.dec = s.opts.newDecoder(s.raw)
+	}
+	tok, err := s.dec.Token()
+	if err != nil {
+		s.err = err
+		return
+	}
+	if tok == nil {
+		// null
+		s.finish()
+		return
+	}
+	if tok != json.Delim('[') {
+		s.err = errJsonStreamNoArray
+	}
+}
+
+// finish marks the stream as done; streamed streams make
+// sure that neither params nor envelope contain any fields
+// following the stream field as they would be lost otherwise
+func (s *JsonStream) finish() {
+	s.done = true
+	if !s.streamed || s.err != nil {
+		return
+	}
+	// end of params, end of envelope
+	for i := 0; i < 2; i++ {
+		if s.dec.More() {
+			s.err = errJsonStreamTrailingFields
+			return
+		}
+		if err := expectJsonDelim(s.dec, '}'); err != nil {
+			s.err = err
+			return
+		}
+	}
+}
+
+// close makes sure the stream will not be read after the method returned
+func (s *JsonStream) close() {
+	if s.err == nil {
+		s.err = ErrJsonStreamClosed
+	}
+}
+
+// jsonStreamField returns the json name of the params' field
+// holding a *JsonStream; an empty string will be returned in case
+// the params do not contain such a field
+func jsonStreamField(rt reflect.Type) string {
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return ""
+	}
+	out := ""
+	for name, ft := range structJsonFields(rt) {
+		if ft != typeJsonStream {
+			continue
+		}
+		if out != "" {
+			panic(errors.New("params '" + rt.String() + "' may only contain a single json stream"))
+		}
+		out = name
+	}
+	return out
+}
+
+// setJsonStream passes the streamed stream (if any) to the params' stream field
+// and makes sure buffered streams decode their elements using the given options
+func setJsonStream(out any, streamed *JsonStream, opts decodeOptions) {
+	rv := reflect.ValueOf(out)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		if rv.Type().Field(i).Type != typeJsonStream || !rv.Type().Field(i).IsExported() {
+			continue
+		}
+		if streamed != nil {
+			rv.Field(i).Set(reflect.ValueOf(streamed))
+			return
+		}
+		if s, ok := rv.Field(i).Interface().(*JsonStream); ok && s != nil && s.dec == nil {
+			s.opts = opts
+		}
+	}
+}
+
+// readStreamedRpcRequest decodes a single request token by token. In case the
+// request's method receives a json stream and the envelope's id and method precede
+// the params, decoding stops at the stream field's value; the returned stream will
+// then read the remaining array while the method is being called.
+func (m *MethodHandler) readStreamedRpcRequest(dec *json.Decoder, rpcRequest *RpcRequest) (*JsonStream, error) {
+	if err := expectJsonDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	for dec.More() {
+		key, err := readJsonKey(dec)
+		if err != nil {
+			return nil, err
+		}
+		// same as the json package, keys are matched case-insensitively
+		switch strings.ToLower(key) {
+		case "jsonrpc":
+			err = dec.Decode(&rpcRequest.Version)
+		case "id":
+			err = dec.Decode(&rpcRequest.ID)
+		case "method":
+			err = dec.Decode(&rpcRequest.Method)
+		case "params":
+			handler, ok := m.endpoints[rpcRequest.Method]
+			if !ok || handler.streamField == "" || rpcRequest.ID == nil {
+				err = dec.Decode(&rpcRequest.Params)
+				break
+			}
+			stream, err := m.readStreamedParams(dec, rpcRequest, handler.streamField)
+			if err != nil || stream != nil {
+				return stream, err
+			}
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, expectJsonDelim(dec, '}')
+}
+
+// readStreamedParams reads the params' fields up to the stream field;
+// the fields read so far will be passed as the request's params.
+// In case the params do not contain the stream field,
+// all params will be read and nil will be returned.
+func (m *MethodHandler) readStreamedParams(dec *json.Decoder, rpcRequest *RpcRequest, field string) (*JsonStream, error) {
+	if err := expectJsonDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	var (
+		fields = map[string]json.RawMessage{}
+		stream *JsonStream
+	)
+	for dec.More() {
+		key, err := readJsonKey(dec)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(key, field) {
+			stream = newStreamedJsonStream(dec, decodeOptions{
+				useNumber:             !m.opts.DecodeNumbersAsFloat64,
+				disallowUnknownFields: !m.opts.AllowUnknownFields,
+			})
+			break
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		fields[key] = v
+	}
+	if stream == nil {
+		if err := expectJsonDelim(dec, '}'); err != nil {
+			return nil, err
+		}
+	}
+	params, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	rpcRequest.Params = params
+	rpcRequest.stream = stream
+	return stream, nil
+}
+
+func readJsonKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", errors.New("jonson: expected object key")
+	}
+	return key, nil
+}
+
+func expectJsonDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return errors.New("jonson: expected '" + delim.String() + "'")
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package jonson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type importV1Record struct {
+	Name string `json:"name"`
+}
+
+type importV1Params struct {
+	Params
+	Source  string      `json:"source"`
+	Records *JsonStream `json:"records"`
+}
+
+func newImportMethodHandler(fn func(ctx *Context, params *importV1Params) (any, error)) *MethodHandler {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterMethod(&MethodDefinition{
+		System:      "import",
+		Method:      "records",
+		Version:     1,
+		HandlerFunc: fn,
+	})
+	return methodHandler
+}
+
+// importRecords returns the source followed by all record names
+func importRecords(ctx *Context, params *importV1Params) (any, error) {
+	out := []string{params.Source}
+	for params.Records.More() {
+		record := &importV1Record{}
+		if err := params.Records.Decode(record); err != nil {
+			return nil, err
+		}
+		out = append(out, record.Name)
+	}
+	return strings.Join(out, ","), params.Records.Err()
+}
+
+func TestJsonStream(t *testing.T) {
+	call := func(t *testing.T, methodHandler *MethodHandler, payload string) *httptest.ResponseRecorder {
+		t.Helper()
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/rpc", strings.NewReader(payload))
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, req)
+		return wtr
+	}
+
+	t.Run("decodes records of a single call", func(t *testing.T) {
+		var res string
+		errResp, err := parseHttpRpcResponse(call(t, newImportMethodHandler(importRecords),
+			`{"jsonrpc":"2.0","id":1,"method":"import/records.v1","params":{"source":"csv","records":[{"name":"a"},{"name":"b"}]}}`), &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil || res != "csv,a,b" {
+			t.Fatalf("expected records to be decoded, got: %v, %s", errResp, res)
+		}
+	})
+
+	t.Run("reads records while receiving the body", func(t *testing.T) {
+		received := make(chan struct{})
+		methodHandler := newImportMethodHandler(func(ctx *Context, params *importV1Params) (any, error) {
+			cnt := 0
+			for params.Records.More() {
+				if err := params.Records.Decode(&importV1Record{}); err != nil {
+					return nil, err
+				}
+				cnt++
+				if cnt == 1 {
+					close(received)
+				}
+			}
+			return cnt, params.Records.Err()
+		})
+
+		body, writer := io.Pipe()
+		go func() {
+			writer.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"import/records.v1","params":{"source":"csv","records":[{"name":"a"}`))
+			select {
+			case <-received:
+			case <-time.After(time.Second * 5):
+				writer.CloseWithError(errors.New("first record has not been received"))
+				return
+			}
+			writer.Write([]byte(`,{"name":"b"}]}}`))
+			writer.Close()
+		}()
+
+		wtr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/rpc", body)
+		NewHttpRpcHandler(methodHandler, "/rpc").Handle(wtr, req)
+
+		var res int
+		errResp, err := parseHttpRpcResponse(wtr, &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil || res != 2 {
+			t.Fatalf("expected 2 records, got: %v, %d", errResp, res)
+		}
+	})
+
+	t.Run("buffers records if the params precede the method", func(t *testing.T) {
+		var res string
+		errResp, err := parseHttpRpcResponse(call(t, newImportMethodHandler(importRecords),
+			`{"jsonrpc":"2.0","params":{"records":[{"name":"a"}],"source":"csv"},"id":1,"method":"import/records.v1"}`), &res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp != nil || res != "csv,a" {
+			t.Fatalf("expected records to be decoded, got: %v, %s", errResp, res)
+		}
+	})
+
+	t.Run("buffers records within batches", func(t *testing.T) {
+		resp, err := parseHttpRpcBatchResponse(call(t, newImportMethodHandler(importRecords),
+			`[{"jsonrpc":"2.0","id":1,"method":"import/records.v1","params":{"source":"csv","records":[{"name":"a"}]}}]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp) != 1 || resp[0].Error != nil || string(*resp[0].Result) != `"csv,a"` {
+			t.Fatalf("expected records to be decoded, got: %v", resp)
+		}
+	})
+
+	t.Run("rejects unknown fields within records", func(t *testing.T) {
+		errResp, err := parseHttpRpcResponse(call(t, newImportMethodHandler(importRecords),
+			`{"jsonrpc":"2.0","id":1,"method":"import/records.v1","params":{"source":"csv","records":[{"title":"a"}]}}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil {
+			t.Fatal("expected unknown field to be rejected")
+		}
+	})
+
+	t.Run("rejects streams not being an array", func(t *testing.T) {
+		errResp, err := parseHttpRpcResponse(call(t, newImportMethodHandler(importRecords),
+			`{"jsonrpc":"2.0","id":1,"method":"import/records.v1","params":{"source":"csv","records":{"name":"a"}}}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil {
+			t.Fatal("expected object to be rejected")
+		}
+	})
+
+	t.Run("rejects params following the stream", func(t *testing.T) {
+		errResp, err := parseHttpRpcResponse(call(t, newImportMethodHandler(importRecords),
+			`{"jsonrpc":"2.0","id":1,"method":"import/records.v1","params":{"records":[{"name":"a"}],"source":"csv"}}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil {
+			t.Fatal("expected params following the stream to be rejected")
+		}
+	})
+
+	t.Run("rejects envelope fields following the stream", func(t *testing.T) {
+		errResp, err := parseHttpRpcResponse(call(t, newImportMethodHandler(importRecords),
+			`{"id":1,"method":"import/records.v1","params":{"source":"csv","records":null},"jsonrpc":"2.0"}`), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errResp == nil {
+			t.Fatal("expected envelope fields following the stream to be rejected")
+		}
+	})
+
+	t.Run("closes the stream once the method returned", func(t *testing.T) {
+		var stream *JsonStream
+		methodHandler := newImportMethodHandler(func(ctx *Context, params *importV1Params) (any, error) {
+			stream = params.Records
+			return nil, nil
+		})
+		call(t, methodHandler, `{"jsonrpc":"2.0","id":1,"method":"import/records.v1","params":{"source":"csv","records":[{"name":"a"}]}}`)
+		if stream.More() {
+			t.Fatal("expected closed stream not to have more elements")
+		}
+		if err := stream.Decode(&importV1Record{}); err != ErrJsonStreamClosed {
+			t.Fatalf("expected ErrJsonStreamClosed, got: %v", err)
+		}
+	})
+
+	t.Run("passes streams to CallMethod", func(t *testing.T) {
+		methodHandler := newImportMethodHandler(importRecords)
+		ctx := NewContext(context.Background(), methodHandler.factory, methodHandler)
+		res, err := methodHandler.CallMethod(ctx, "import/records.v1", RpcHttpMethodPost, &importV1Params{
+			Source:  "api",
+			Records: NewJsonStream(json.RawMessage(`[{"name":"a"},{"name":"b"}]`)),
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res != "api,a,b" {
+			t.Fatalf("expected records to be decoded, got: %v", res)
+		}
+	})
+}
@@ -14,6 +14,10 @@ func ToPascalCase(input string) string {
 
 	pascal := ""
 	for _, word := range words {
+		// leading, trailing or repeated separators produce empty words
+		if word == "" {
+			continue
+		}
 		pascal += strings.ToUpper(word[0:1]) + word[1:]
 	}
 	return pascal
@@ -25,6 +25,11 @@ func TestToPascalCase(t *testing.T) {
 		{"BatteryLifeValue", "BatteryLifeValue"},
 		{"Id0Value", "Id0Value"},
 		{"ID0Value", "Id0Value"},
+		{"MyAPIv2", "MyApIv2"},
+		{"_id", "Id"},
+		{"user__name", "UserName"},
+		{"a-", "A"},
+		{"-", ""},
 	}
 	for _, tt := range tests {
 		if got := ToPascalCase(tt.input); got != tt.expected {
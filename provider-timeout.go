@@ -0,0 +1,113 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// providerContext bounds the resolution of a provider: it will be canceled
+// once the timeout exceeded while the provider is being resolved.
+// Once released, only the parent's cancellation will be passed on in case the
+// provider kept the done channel; values keeping the context (e.g. transactions)
+// stay usable after the resolution.
+type providerContext struct {
+	parent   context.Context
+	deadline time.Time
+	done     chan struct{}
+	timer    *time.Timer
+	// stop unregisters the parent's cancellation
+	stop func() bool
+
+	mux      sync.Mutex
+	err      error
+	active   bool
+	timedOut bool
+	// observed is true once the done channel has been handed out
+	observed bool
+}
+
+func newProviderContext(parent context.Context, timeout time.Duration) *providerContext {
+	// the request's deadline might be earlier
+	deadline := time.Now().Add(timeout)
+	if d, ok := parent.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c := &providerContext{
+		parent:   parent,
+		deadline: deadline,
+		done:     make(chan struct{}),
+		active:   true,
+	}
+	c.stop = context.AfterFunc(parent, func() {
+		c.cancel(parent.Err(), false)
+	})
+	c.timer = time.AfterFunc(time.Until(deadline), func() {
+		c.cancel(context.DeadlineExceeded, true)
+	})
+	return c
+}
+
+func (c *providerContext) cancel(err error, fromTimer bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.err != nil || (fromTimer && !c.active) {
+		return
+	}
+	c.err = err
+	// the request's deadline might have been exceeded as well
+	c.timedOut = c.active && errors.Is(err, context.DeadlineExceeded)
+	close(c.done)
+}
+
+// release ends the resolution and returns true
+// in case the provider exceeded the timeout.
+// The parent's cancellation will no longer be passed on
+// unless the done channel has been handed out, see passesCancel.
+func (c *providerContext) release() bool {
+	c.timer.Stop()
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.active = false
+	if !c.observed {
+		c.stop()
+	}
+	return c.timedOut
+}
+
+// passesCancel returns true in case the parent's cancellation
+// will still be passed on after the resolution; call stop once
+// the values keeping the context are no longer used
+func (c *providerContext) passesCancel() bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.observed && c.err == nil
+}
+
+func (c *providerContext) Deadline() (time.Time, bool) {
+	c.mux.Lock()
+	active := c.active
+	c.mux.Unlock()
+	if active {
+		return c.deadline, true
+	}
+	return c.parent.Deadline()
+}
+
+func (c *providerContext) Done() <-chan struct{} {
+	c.mux.Lock()
+	c.observed = true
+	c.mux.Unlock()
+	return c.done
+}
+
+func (c *providerContext) Err() error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.err
+}
+
+func (c *providerContext) Value(key any) any {
+	return c.parent.Value(key)
+}
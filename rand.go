@@ -0,0 +1,109 @@
+package jonson
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"reflect"
+)
+
+var TypeRand = reflect.TypeOf((*Rand)(nil)).Elem()
+
+// RequireRand returns the random source of the ongoing request
+// which is used to generate ids and tokens.
+func RequireRand(ctx *Context) Rand {
+	if v := ctx.Require(TypeRand); v != nil {
+		return v.(Rand)
+	}
+	return nil
+}
+
+// RandProvider allows us to provide a random source within our application.
+// Similar to the TimeProvider, the random source can be replaced within our tests
+// by a deterministic one (see jonsontest.SeededRand) in order to assert generated ids.
+type RandProvider struct {
+	inst func() Rand
+}
+
+// NewRandProvider returns a new RandProvider
+func NewRandProvider(inst ...func() Rand) *RandProvider {
+	out := &RandProvider{
+		// by default, we will provide a cryptographically secure source
+		inst: func() Rand {
+			return NewRealRand()
+		},
+	}
+	// in case any rand instances have been provided,
+	// let's pick the last one
+	for _, v := range inst {
+		out.inst = v
+	}
+	return out
+}
+
+func (r *RandProvider) NewRand(ctx *Context) Rand {
+	return r.inst()
+}
+
+// Rand is the interface that can be used within your application.
+// You can mock this interface within your tests.
+type Rand interface {
+	Shareable
+	ShareableAcrossImpersonation
+	// UUID returns a new random (version 4) uuid
+	UUID() string
+	// Bytes returns n random bytes
+	Bytes(n int) []byte
+}
+
+// RealRand implements Rand using crypto/rand
+type RealRand struct {
+	Shareable
+	ShareableAcrossImpersonation
+}
+
+// type safeguard
+var _ Rand = &RealRand{}
+
+// UUID returns a new random uuid
+func (r *RealRand) UUID() string {
+	var b [16]byte
+	copy(b[:], r.Bytes(len(b)))
+	return FormatUUID(b)
+}
+
+// Bytes returns n cryptographically secure random bytes
+func (r *RealRand) Bytes(n int) []byte {
+	out := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, out); err != nil {
+		// the system's random source is broken; don't hand out predictable values
+		panic(err)
+	}
+	return out
+}
+
+// NewRealRand returns a rand instance which reads from
+// crypto/rand. You will probably use this
+// instance for your production build.
+func NewRealRand() *RealRand {
+	return &RealRand{}
+}
+
+// FormatUUID formats the given random bytes as version 4 uuid,
+// e.g. to implement your own Rand
+func FormatUUID(b [16]byte) string {
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	out := make([]byte, 36)
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out)
+}
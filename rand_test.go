@@ -0,0 +1,44 @@
+package jonson
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+func TestRealRand(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	t.Run("generates uuids", func(t *testing.T) {
+		rnd := NewRealRand()
+		a, b := rnd.UUID(), rnd.UUID()
+		if !uuidV4.MatchString(a) || a == b {
+			t.Fatalf("expected distinct version 4 uuids, got: %s, %s", a, b)
+		}
+	})
+
+	t.Run("generates bytes", func(t *testing.T) {
+		if b := NewRealRand().Bytes(32); len(b) != 32 {
+			t.Fatalf("expected 32 bytes, got: %d", len(b))
+		}
+	})
+
+	t.Run("formats uuids", func(t *testing.T) {
+		var b [16]byte
+		for i := range b {
+			b[i] = 0xff
+		}
+		if id := FormatUUID(b); id != "ffffffff-ffff-4fff-bfff-ffffffffffff" {
+			t.Fatalf("expected version and variant bits to be set, got: %s", id)
+		}
+	})
+
+	t.Run("is provided by the rand provider", func(t *testing.T) {
+		fac := NewFactory()
+		fac.RegisterProvider(NewRandProvider())
+		ctx := NewContext(context.Background(), fac, nil)
+		if _, ok := RequireRand(ctx).(*RealRand); !ok {
+			t.Fatal("expected real rand to be provided by default")
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package jonson
+
+import (
+	"reflect"
+	"sync"
+)
+
+// RequestMeta allows us to attach request specific
+// meta data (such as a tenant id or an api key id) to the ongoing request.
+// In contrast to RpcMeta, RequestMeta is mutable and can be
+// populated by providers and read by any downstream handler.
+type RequestMeta struct {
+	// RequestMeta is shareable:
+	// values set by the initial call will be available
+	// to internal calls done within the same request
+	Shareable
+
+	mux    sync.RWMutex
+	values map[string]any
+}
+
+var TypeRequestMeta = reflect.TypeOf((**RequestMeta)(nil)).Elem()
+
+// RequireRequestMeta returns the request meta of the ongoing request.
+// The request meta will be available by default.
+func RequireRequestMeta(ctx *Context) *RequestMeta {
+	if v := ctx.Require(TypeRequestMeta); v != nil {
+		return v.(*RequestMeta)
+	}
+	return nil
+}
+
+// Set sets a value for the given key
+func (r *RequestMeta) Set(key string, val any) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.values[key] = val
+}
+
+// Get returns the value for the given key;
+// in case the key does not exist, false will be returned
+func (r *RequestMeta) Get(key string) (any, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	v, ok := r.values[key]
+	return v, ok
+}
+
+// Values returns a copy of all values
+func (r *RequestMeta) Values() map[string]any {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	out := make(map[string]any, len(r.values))
+	for k, v := range r.values {
+		out[k] = v
+	}
+	return out
+}
+
+// requestMetaProvider provides an empty request meta
+// to each request. The provider is registered by default.
+type requestMetaProvider struct {
+}
+
+func newRequestMetaProvider() *requestMetaProvider {
+	return &requestMetaProvider{}
+}
+
+func (r *requestMetaProvider) NewRequestMeta(ctx *Context) *RequestMeta {
+	return &RequestMeta{
+		values: map[string]any{},
+	}
+}
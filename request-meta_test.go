@@ -0,0 +1,72 @@
+package jonson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+type RequestMetaSystem struct {
+}
+
+func (r *RequestMetaSystem) TenantV1(ctx *Context) (string, error) {
+	v, _ := RequireRequestMeta(ctx).Get("tenant")
+	s, _ := v.(string)
+	return s, nil
+}
+
+func TestRequestMeta(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+
+	factory := NewFactory(&FactoryOptions{
+		Logger:        slog.New(slog.NewJSONHandler(buf, nil)),
+		LoggerOptions: (&LoggerOptions{}).WithRequestMeta(),
+	})
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), &MethodHandlerOptions{
+		MissingValidationLevel: MissingValidationLevelIgnore,
+	})
+	methodHandler.RegisterSystem(&RequestMetaSystem{})
+
+	t.Run("request meta is shared with internal calls", func(t *testing.T) {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		RequireRequestMeta(ctx).Set("tenant", "acme")
+
+		res, err := ctx.CallMethod("request-meta-system/tenant.v1", RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.(string) != "acme" {
+			t.Fatalf("expected tenant to equal 'acme', got: %v", res)
+		}
+	})
+
+	t.Run("values returns a copy", func(t *testing.T) {
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		meta := RequireRequestMeta(ctx)
+		meta.Set("tenant", "acme")
+		meta.Values()["tenant"] = "other"
+
+		if v, _ := meta.Get("tenant"); v != "acme" {
+			t.Fatalf("expected tenant to equal 'acme', got: %v", v)
+		}
+	})
+
+	t.Run("request meta is logged", func(t *testing.T) {
+		buf.Reset()
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		RequireRequestMeta(ctx).Set("tenant", "acme")
+		RequireLogger(ctx).Info("test")
+
+		out := struct {
+			RequestMeta map[string]any `json:"requestMeta"`
+		}{}
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.RequestMeta["tenant"] != "acme" {
+			t.Fatalf("expected logged tenant to equal 'acme', got: %v", out.RequestMeta["tenant"])
+		}
+	})
+}
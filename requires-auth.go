@@ -0,0 +1,51 @@
+package jonson
+
+import "reflect"
+
+// RequiresAuth documents that a method requires an authenticated caller,
+// hence *Private. The marker allows the generator and rpc.discover to
+// annotate endpoints requiring authentication; the method handler
+// will panic during registration in case the method does not require *Private.
+// Example:
+// func (s *System) GetProfileV1(ctx *jonson.Context, caller *jonson.Private, _ jonson.RequiresAuth) error{}
+type RequiresAuth interface {
+	__requiresAuth()
+}
+type requiresAuth struct{}
+
+func (r *requiresAuth) __requiresAuth() {}
+
+var TypeRequiresAuth = reflect.TypeOf((*RequiresAuth)(nil)).Elem()
+
+// requiresAuthProvider provides the RequiresAuth marker.
+// The requiresAuthProvider will be provided automatically.
+type requiresAuthProvider struct {
+}
+
+func newRequiresAuthProvider() *requiresAuthProvider {
+	return &requiresAuthProvider{}
+}
+
+func (r *requiresAuthProvider) NewRequiresAuth(ctx *Context) RequiresAuth {
+	return &requiresAuth{}
+}
+
+// hasRequiresAuth returns true in case the handler requires RequiresAuth
+func hasRequiresAuth(rt reflect.Type) bool {
+	for i := 0; i < rt.NumIn(); i++ {
+		if rt.In(i) == TypeRequiresAuth {
+			return true
+		}
+	}
+	return false
+}
+
+// requiresPrivate returns true in case the handler requires *Private
+func requiresPrivate(rt reflect.Type) bool {
+	for i := 0; i < rt.NumIn(); i++ {
+		if rt.In(i) == TypePrivate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package jonson
+
+import (
+	"context"
+	"testing"
+)
+
+type RequiresAuthSystem struct{}
+
+func (r *RequiresAuthSystem) ProfileV1(ctx *Context, caller *Private, _ RequiresAuth) (string, error) {
+	return caller.AccountUuid(), nil
+}
+
+func (r *RequiresAuthSystem) PingV1(ctx *Context) error {
+	return nil
+}
+
+type MismatchedAuthSystem struct{}
+
+func (m *MismatchedAuthSystem) ProfileV1(ctx *Context, _ RequiresAuth) error {
+	return nil
+}
+
+func TestRequiresAuth(t *testing.T) {
+	client := &testAuthClient{}
+	factory := NewFactory()
+	factory.RegisterProvider(NewAuthProvider(client))
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+
+	endpoints := map[string]*Endpoint{}
+	methodHandler.OnRegister(func(def *MethodDefinition, ep *Endpoint) {
+		endpoints[ep.Name] = ep
+	})
+	methodHandler.RegisterSystem(&RequiresAuthSystem{})
+	methodHandler.RegisterDiscover("Test API", "1.0.0")
+
+	t.Run("marks endpoints requiring authentication", func(t *testing.T) {
+		if !endpoints["requires-auth-system/profile.v1"].RequiresAuth {
+			t.Fatal("expected profile to require authentication")
+		}
+		if endpoints["requires-auth-system/ping.v1"].RequiresAuth {
+			t.Fatal("expected ping not to require authentication")
+		}
+
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		res, err := methodHandler.CallMethod(ctx, DiscoverMethod, RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatalf("expected discover to succeed, got: %s", err)
+		}
+		for _, v := range res.(*OpenRpcDocument).Methods {
+			if expected := v.Name == "requires-auth-system/profile.v1"; v.RequiresAuth != expected {
+				t.Fatalf("expected %s to require auth: %t", v.Name, expected)
+			}
+		}
+	})
+
+	t.Run("methods requiring authentication are called as usual", func(t *testing.T) {
+		client.isAuthorized = false
+		ctx := NewContext(context.Background(), factory, methodHandler)
+		if _, err := methodHandler.CallMethod(ctx, "requires-auth-system/profile.v1", RpcHttpMethodPost, nil, nil); err == nil {
+			t.Fatal("expected unauthorized call to fail")
+		}
+
+		client.isAuthorized = true
+		ctx = NewContext(context.Background(), factory, methodHandler)
+		res, err := methodHandler.CallMethod(ctx, "requires-auth-system/profile.v1", RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatalf("expected authorized call to succeed, got: %s", err)
+		}
+		if res.(string) != testAccountUuid {
+			t.Fatalf("expected account uuid, got: %v", res)
+		}
+	})
+
+	t.Run("panics in case the marker does not match the requirements", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic")
+			}
+		}()
+		NewMethodHandler(factory, NewDebugSecret(), nil).RegisterSystem(&MismatchedAuthSystem{})
+	})
+}
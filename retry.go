@@ -0,0 +1,78 @@
+package jonson
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// RetryOptions define how CallMethodWithRetry retries failing calls
+type RetryOptions struct {
+	// MaxAttempts defines the maximum number of calls, including the first call
+	MaxAttempts int
+	// Backoff returns the duration to wait before the given retry;
+	// the first retry is 1
+	Backoff func(retry int) time.Duration
+	// RetryableCodes defines the error codes which will be retried;
+	// all other errors will be returned right away
+	RetryableCodes []int
+}
+
+// NewRetryOptions returns retry options retrying internal errors
+// up to two times using an exponential backoff starting at 100ms
+func NewRetryOptions() *RetryOptions {
+	return &RetryOptions{
+		MaxAttempts:    3,
+		Backoff:        ExponentialBackoff(100 * time.Millisecond),
+		RetryableCodes: []int{ErrInternal.Code},
+	}
+}
+
+// ExponentialBackoff returns a backoff doubling base with every retry
+func ExponentialBackoff(base time.Duration) func(retry int) time.Duration {
+	return func(retry int) time.Duration {
+		return base << (retry - 1)
+	}
+}
+
+// isRetryable returns true in case err carries a retryable code
+func (r *RetryOptions) isRetryable(err error) bool {
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	return slices.Contains(r.RetryableCodes, rpcErr.Code)
+}
+
+// CallMethodWithRetry works like CallMethod but retries calls failing with a
+// retryable error code. Each attempt will be called within its own forked context.
+// The backoff uses the Time provider in case it has been registered, which allows us
+// to skip waiting within our tests. In case the context has been canceled, even while
+// waiting for the next attempt, no further attempts will be made and the last error will be returned.
+// In case no options are provided, NewRetryOptions() will be used.
+func (c *Context) CallMethodWithRetry(method string, rpcHttpMethod RpcHttpMethod, payload any, opts *RetryOptions) (any, error) {
+	if opts == nil {
+		opts = NewRetryOptions()
+	}
+
+	var (
+		res any
+		err error
+	)
+	for attempt := 1; ; attempt++ {
+		res, err = c.CallMethod(method, rpcHttpMethod, payload, nil)
+		if err == nil || attempt >= opts.MaxAttempts || !opts.isRetryable(err) || c.Err() != nil {
+			return res, err
+		}
+		if opts.Backoff == nil {
+			continue
+		}
+		tm := Time(NewRealTime())
+		if c.factory.hasProvider(TypeTime) {
+			tm = RequireTime(c)
+		}
+		if tm.SleepContext(c, opts.Backoff(attempt)) != nil {
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package jonson
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type FlakySystem struct {
+	failures int
+	calls    int
+}
+
+func (f *FlakySystem) FlakyV1(ctx *Context) (string, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return "", ErrInternal
+	}
+	return "ok", nil
+}
+
+func (f *FlakySystem) UnauthorizedV1(ctx *Context) error {
+	f.calls++
+	return ErrUnauthorized
+}
+
+// sleepRecordingTime records the durations slept
+type sleepRecordingTime struct {
+	*mockTime
+	sleeps []time.Duration
+}
+
+func (s *sleepRecordingTime) Sleep(d time.Duration) {
+	s.sleeps = append(s.sleeps, d)
+}
+
+func (s *sleepRecordingTime) SleepContext(ctx context.Context, d time.Duration) error {
+	s.sleeps = append(s.sleeps, d)
+	return ctx.Err()
+}
+
+func TestCallMethodWithRetry(t *testing.T) {
+	setup := func(failures int) (*Context, *FlakySystem, *sleepRecordingTime) {
+		tm := &sleepRecordingTime{mockTime: newMockTime(time.Now())}
+		factory := NewFactory()
+		factory.RegisterProvider(NewTimeProvider(func() Time {
+			return tm
+		}))
+		methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+		system := &FlakySystem{failures: failures}
+		methodHandler.RegisterSystem(system)
+		return NewContext(context.Background(), factory, methodHandler), system, tm
+	}
+
+	t.Run("retries retryable errors using the backoff", func(t *testing.T) {
+		ctx, system, tm := setup(2)
+		res, err := ctx.CallMethodWithRetry("flaky-system/flaky.v1", RpcHttpMethodPost, nil, nil)
+		if err != nil {
+			t.Fatalf("expected call to succeed, got: %s", err)
+		}
+		if res != "ok" {
+			t.Fatalf("expected result to equal 'ok', got: %v", res)
+		}
+		if system.calls != 3 {
+			t.Fatalf("expected 3 calls, got: %d", system.calls)
+		}
+		if len(tm.sleeps) != 2 || tm.sleeps[0] != 100*time.Millisecond || tm.sleeps[1] != 200*time.Millisecond {
+			t.Fatalf("expected exponential backoff, got: %v", tm.sleeps)
+		}
+	})
+
+	t.Run("returns the last error once max attempts have been reached", func(t *testing.T) {
+		ctx, system, _ := setup(5)
+		_, err := ctx.CallMethodWithRetry("flaky-system/flaky.v1", RpcHttpMethodPost, nil, &RetryOptions{
+			MaxAttempts:    2,
+			RetryableCodes: []int{ErrInternal.Code},
+		})
+		if err == nil || err.(*Error).Code != ErrInternal.Code {
+			t.Fatalf("expected internal error, got: %v", err)
+		}
+		if system.calls != 2 {
+			t.Fatalf("expected 2 calls, got: %d", system.calls)
+		}
+	})
+
+	t.Run("does not retry non-retryable errors", func(t *testing.T) {
+		ctx, system, _ := setup(0)
+		_, err := ctx.CallMethodWithRetry("flaky-system/unauthorized.v1", RpcHttpMethodPost, nil, nil)
+		if err == nil || err.(*Error).Code != ErrUnauthorized.Code {
+			t.Fatalf("expected unauthorized error, got: %v", err)
+		}
+		if system.calls != 1 {
+			t.Fatalf("expected a single call, got: %d", system.calls)
+		}
+	})
+}
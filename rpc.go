@@ -1,7 +1,6 @@
 package jonson
 
 import (
-	"bytes"
 	"encoding/json"
 	"net/http"
 	"reflect"
@@ -10,12 +9,21 @@ import (
 // Rpc internal errors
 var (
 	ErrParse                  = &Error{Code: -32700, Message: "Parse error"}
+	ErrInvalidRequest         = &Error{Code: -32600, Message: "Invalid Request"}
 	ErrMethodNotFound         = &Error{Code: -32601, Message: "Method not found"}
 	ErrInvalidParams          = &Error{Code: -32602, Message: "Invalid params"}
 	ErrInternal               = &Error{Code: -32603, Message: "Internal error"}
 	ErrServerMethodNotAllowed = &Error{Code: -32000, Message: "Server error: method not allowed"}
 	ErrUnauthorized           = &Error{Code: -32001, Message: "Not authorized"}
 	ErrUnauthenticated        = &Error{Code: -32002, Message: "Not authenticated"}
+	ErrRequestCanceled        = &Error{Code: -32003, Message: "Request canceled"}
+	ErrServiceUnavailable     = &Error{Code: -32004, Message: "Service unavailable"}
+	ErrNotImplemented         = &Error{Code: -32005, Message: "Not implemented"}
+	ErrNotFound               = &Error{Code: -32006, Message: "Not found"}
+	ErrConflict               = &Error{Code: -32007, Message: "Conflict"}
+	ErrProviderTimeout        = &Error{Code: -32008, Message: "Provider timeout"}
+	ErrPartialFailure         = &Error{Code: -32009, Message: "Partial failure"}
+	ErrTooManyRequests        = &Error{Code: -32010, Message: "Too many requests"}
 )
 
 // RpcRequest object
@@ -24,6 +32,9 @@ type RpcRequest struct {
 	ID      json.RawMessage `json:"id"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params"`
+
+	// stream is being read from the request body, see JsonStream
+	stream *JsonStream
 }
 
 // RpcNotification object
@@ -48,18 +59,30 @@ func NewRpcNotification(method string, payload any) *RpcNotification {
 	}
 }
 
-// UnmarshalAndValidate fills the given interface with the supplied params
+// UnmarshalAndValidate fills the given interface with the supplied params.
+// In case the params cannot be decoded due to invalid types or unknown fields,
+// the returned error's details will contain an error per field; missing fields
+// are not detected while decoding and need to be checked by the params' validation.
+// Numbers decoded into interface values will be json.Number and unknown fields
+// will be rejected; see MethodHandlerOptions to change the decoding of params.
 func (r *RpcRequest) UnmarshalAndValidate(errEncoder Secret, out any, bindata []byte) error {
+	return r.unmarshalAndValidate(errEncoder, out, bindata, defaultDecodeOptions)
+}
+
+func (r *RpcRequest) unmarshalAndValidate(errEncoder Secret, out any, bindata []byte, opts decodeOptions) error {
 
-	dec := json.NewDecoder(bytes.NewReader([]byte(r.Params)))
-	dec.DisallowUnknownFields()
-	dec.UseNumber()
+	dec := opts.newDecoder(r.Params)
 	if err := dec.Decode(out); err != nil {
+		// type mismatches and unknown fields will be
+		// reported as details carrying the field's path
 		return ErrInvalidParams.CloneWithData(&ErrorData{
-			Debug: errEncoder.Encode(err.Error()),
+			Details: decodeErrorDetails(r.Params, out, err, opts),
+			Debug:   errEncoder.Encode(err.Error()),
 		})
 	}
 
+	setJsonStream(out, r.stream, opts)
+
 	// optional: if bindata is set set a field called BinData in the target struct
 	if bindata != nil {
 		rv := reflect.ValueOf(out).Elem()
@@ -72,6 +95,14 @@ func (r *RpcRequest) UnmarshalAndValidate(errEncoder Secret, out any, bindata []
 		}
 	}
 
+	// defaults will be applied before validating
+	// the params; they are checked during registration
+	if err := applyDefaults(reflect.ValueOf(out)); err != nil {
+		return ErrInternal.CloneWithData(&ErrorData{
+			Debug: errEncoder.Encode(err.Error()),
+		})
+	}
+
 	// start validation process in case the
 	// params can be validated
 	canValidate, ok := out.(ValidatedParams)
@@ -170,6 +201,13 @@ type RpcMeta struct {
 	Method     string
 	HttpMethod RpcHttpMethod
 	Source     RpcSource
+
+	// IPAddress and UserAgent identify the client;
+	// for websocket connections, the values of the request
+	// opening the connection will be used.
+	// Internal calls inherit the values of the calling rpc.
+	IPAddress string
+	UserAgent string
 }
 
 var TypeRpcMeta = reflect.TypeOf((**RpcMeta)(nil)).Elem()
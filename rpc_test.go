@@ -0,0 +1,190 @@
+package jonson
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DecodeV1Address struct {
+	Street string `json:"street"`
+}
+
+type DecodeV1Params struct {
+	Params
+	Uuid    string           `json:"uuid"`
+	Age     int              `json:"age"`
+	Address *DecodeV1Address `json:"address"`
+}
+
+func TestUnmarshalAndValidate(t *testing.T) {
+	unmarshal := func(t *testing.T, params string) *Error {
+		t.Helper()
+		req := &RpcRequest{
+			Version: "2.0",
+			Method:  "test-system/decode.v1",
+			Params:  json.RawMessage(params),
+		}
+		err := req.UnmarshalAndValidate(NewDebugSecret(), &DecodeV1Params{}, nil)
+		if err == nil {
+			return nil
+		}
+		return err.(*Error)
+	}
+	assertDetails := func(t *testing.T, err *Error, expected map[string]string) {
+		t.Helper()
+		if err == nil || err.Code != ErrInvalidParams.Code {
+			t.Fatalf("expected invalid params error, got: %v", err)
+		}
+		if len(err.Data.Details) != len(expected) {
+			t.Fatalf("expected %d details, got: %v", len(expected), err.Data.Details)
+		}
+		for _, v := range err.Data.Details {
+			path := strings.Join(v.Data.Path, ".")
+			if msg, ok := expected[path]; !ok || msg != v.Message {
+				t.Fatalf("unexpected detail %s: %s", path, v.Message)
+			}
+		}
+	}
+
+	t.Run("valid params do not return an error", func(t *testing.T) {
+		if err := unmarshal(t, `{"uuid": "abc", "age": 42}`); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("reports all fields with invalid types", func(t *testing.T) {
+		assertDetails(t, unmarshal(t, `{"uuid": 123, "age": "old"}`), map[string]string{
+			"uuid": "invalid type: expected string, got number",
+			"age":  "invalid type: expected number, got string",
+		})
+	})
+
+	t.Run("reports nested fields with invalid types", func(t *testing.T) {
+		assertDetails(t, unmarshal(t, `{"address": {"street": true}}`), map[string]string{
+			"address.street": "invalid type: expected string, got bool",
+		})
+	})
+
+	t.Run("reports unknown fields", func(t *testing.T) {
+		assertDetails(t, unmarshal(t, `{"uuid": "abc", "name": "Silvio", "address": {"zip": "12345"}}`), map[string]string{
+			"name":        "unknown field",
+			"address.zip": "unknown field",
+		})
+	})
+
+	t.Run("syntax errors do not carry details", func(t *testing.T) {
+		err := unmarshal(t, `{"uuid": `)
+		if err == nil || err.Code != ErrInvalidParams.Code {
+			t.Fatalf("expected invalid params error, got: %v", err)
+		}
+		if len(err.Data.Details) != 0 {
+			t.Fatalf("expected no details, got: %v", err.Data.Details)
+		}
+	})
+}
+
+type DefaultsV1Options struct {
+	Verbose bool `json:"verbose" default:"true"`
+}
+
+type DefaultsV1Params struct {
+	Params
+	Limit   int                `json:"limit" default:"20"`
+	Order   string             `json:"order" default:"asc"`
+	Timeout time.Duration      `json:"timeout" default:"5s"`
+	Options *DefaultsV1Options `json:"options"`
+}
+
+type InvalidDefaultsV1Params struct {
+	Params
+	Limit int `json:"limit" default:"twenty"`
+}
+
+type DefaultsV1Node struct {
+	Name   string          `json:"name" default:"node"`
+	Parent *DefaultsV1Node `json:"parent"`
+}
+
+type TreeDefaultsV1Params struct {
+	Params
+	Node *DefaultsV1Node `json:"node"`
+}
+
+type TreeDefaultsSystem struct{}
+
+func (d *TreeDefaultsSystem) TreeDefaultsV1(ctx *Context, params *TreeDefaultsV1Params) error {
+	return nil
+}
+
+type DefaultsSystem struct{}
+
+func (d *DefaultsSystem) InvalidDefaultsV1(ctx *Context, params *InvalidDefaultsV1Params) error {
+	return nil
+}
+
+func TestUnmarshalAndValidateDefaults(t *testing.T) {
+	unmarshal := func(t *testing.T, params string) *DefaultsV1Params {
+		t.Helper()
+		req := &RpcRequest{
+			Version: "2.0",
+			Method:  "test-system/defaults.v1",
+			Params:  json.RawMessage(params),
+		}
+		out := &DefaultsV1Params{}
+		if err := req.UnmarshalAndValidate(NewDebugSecret(), out, nil); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	t.Run("applies defaults to absent fields", func(t *testing.T) {
+		out := unmarshal(t, `{"options": {}}`)
+		if out.Limit != 20 || out.Order != "asc" || out.Timeout != 5*time.Second {
+			t.Fatalf("expected defaults to be applied, got: %+v", out)
+		}
+		if !out.Options.Verbose {
+			t.Fatal("expected defaults of nested structs to be applied")
+		}
+	})
+
+	t.Run("keeps passed values", func(t *testing.T) {
+		out := unmarshal(t, `{"limit": 5, "order": "desc"}`)
+		if out.Limit != 5 || out.Order != "desc" {
+			t.Fatalf("expected passed values to be kept, got: %+v", out)
+		}
+	})
+
+	t.Run("explicit zero values are replaced", func(t *testing.T) {
+		if out := unmarshal(t, `{"limit": 0}`); out.Limit != 20 {
+			t.Fatalf("expected default to be applied, got: %d", out.Limit)
+		}
+	})
+
+	t.Run("invalid defaults fail during registration", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected registration to panic")
+			}
+		}()
+		NewMethodHandler(NewFactory(), NewDebugSecret(), nil).RegisterSystem(&DefaultsSystem{})
+	})
+
+	t.Run("self-referencing params can be registered", func(t *testing.T) {
+		NewMethodHandler(NewFactory(), NewDebugSecret(), nil).RegisterSystem(&TreeDefaultsSystem{})
+
+		req := &RpcRequest{
+			Version: "2.0",
+			Method:  "tree-defaults-system/tree-defaults.v1",
+			Params:  json.RawMessage(`{"node": {"parent": {"name": "root"}}}`),
+		}
+		out := &TreeDefaultsV1Params{}
+		if err := req.UnmarshalAndValidate(NewDebugSecret(), out, nil); err != nil {
+			t.Fatal(err)
+		}
+		if out.Node.Name != "node" || out.Node.Parent.Name != "root" || out.Node.Parent.Parent != nil {
+			t.Fatalf("expected defaults of nested nodes to be applied, got: %+v", out.Node)
+		}
+	})
+}
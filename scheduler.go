@@ -0,0 +1,239 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrSchedulerShutdown will be returned in case a job
+// could not be scheduled since the scheduler is shutting down
+var ErrSchedulerShutdown = errors.New("scheduler: shutting down")
+
+type SchedulerOptions struct {
+	// PollInterval defines how often the scheduler checks for due jobs.
+	// In case PollInterval is <= 0, no background polling will happen
+	// and due jobs need to be run by calling RunDue, e.g. within tests.
+	PollInterval time.Duration
+}
+
+func NewSchedulerOptions() *SchedulerOptions {
+	return &SchedulerOptions{
+		PollInterval: time.Second,
+	}
+}
+
+// SchedulerProvider runs jobs once they are due, e.g. to remind
+// an account within an hour. Whether a job is due will be decided using
+// the Time provided at the time of scheduling, hence moving a mocked time
+// forward followed by a call to RunDue fires due jobs deterministically.
+// Jobs are kept in memory and run one after another, each with a fresh context;
+// use the WorkerProvider for long-running work.
+// Pass Shutdown to the GracefulProvider in order to stop the scheduler
+// during a graceful shutdown:
+//
+//	scheduler := jonson.NewSchedulerProvider(nil)
+//	fac.RegisterProvider(scheduler)
+//	graceful := jonson.NewGracefulProvider().WithShutdownFunc(scheduler.Shutdown)
+type SchedulerProvider struct {
+	opts *SchedulerOptions
+
+	mux     sync.Mutex
+	jobs    []*schedulerJob
+	closed  bool
+	running sync.WaitGroup
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+type schedulerJob struct {
+	at            time.Time
+	time          Time
+	factory       *Factory
+	methodHandler *MethodHandler
+	fn            func(ctx *Context)
+}
+
+// NewSchedulerProvider returns a new scheduler provider and starts polling for due jobs.
+// In case no options are provided, NewSchedulerOptions() will be used.
+func NewSchedulerProvider(opts *SchedulerOptions) *SchedulerProvider {
+	if opts == nil {
+		opts = NewSchedulerOptions()
+	}
+	s := &SchedulerProvider{
+		opts: opts,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if opts.PollInterval > 0 {
+		go s.poll()
+	} else {
+		close(s.done)
+	}
+	return s
+}
+
+func (s *SchedulerProvider) NewScheduler(ctx *Context) *Scheduler {
+	return &Scheduler{
+		provider: s,
+		ctx:      ctx,
+	}
+}
+
+func (s *SchedulerProvider) poll() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-ticker.C:
+			s.RunDue()
+		}
+	}
+}
+
+// Pending returns the number of jobs waiting to be run
+func (s *SchedulerProvider) Pending() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.jobs)
+}
+
+// RunDue runs all jobs which are due in order of their scheduled time
+// and returns the number of jobs that have been run.
+// Jobs will be dropped without being run in case the server is
+// shutting down (see Graceful.IsDown) or the scheduler has been shut down.
+func (s *SchedulerProvider) RunDue() int {
+	s.mux.Lock()
+	if s.closed {
+		s.mux.Unlock()
+		return 0
+	}
+	due := []*schedulerJob{}
+	pending := s.jobs[:0]
+	for _, job := range s.jobs {
+		if !job.time.Now().Before(job.at) {
+			due = append(due, job)
+		} else {
+			pending = append(pending, job)
+		}
+	}
+	s.jobs = pending
+	s.running.Add(1)
+	s.mux.Unlock()
+	defer s.running.Done()
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].at.Before(due[j].at)
+	})
+	cnt := 0
+	for _, job := range due {
+		if s.run(job) {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// run runs the job unless the server is shutting down
+func (s *SchedulerProvider) run(job *schedulerJob) (ran bool) {
+	ctx := NewContext(context.Background(), job.factory, job.methodHandler)
+	if job.factory.hasProvider(TypeGraceful) && RequireGraceful(ctx).IsDown() {
+		ctx.Finalize(nil)
+		return false
+	}
+
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			if job.methodHandler != nil {
+				err = job.methodHandler.recoverError(r)
+			} else {
+				err = getRecoverError(r)
+			}
+		}
+		if err = ctx.Finalize(err); err != nil {
+			job.factory.Logger().Warn("scheduler: job failed", "error", err)
+		}
+	}()
+	ran = true
+	job.fn(ctx)
+	return ran
+}
+
+// Shutdown stops accepting new jobs, drops all pending jobs and waits
+// for running jobs to be completed. In case the given context is
+// done before, its error will be returned.
+func (s *SchedulerProvider) Shutdown(ctx context.Context) error {
+	s.mux.Lock()
+	if !s.closed {
+		s.closed = true
+		s.jobs = nil
+		close(s.quit)
+	}
+	s.mux.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		<-s.done
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Scheduler schedules jobs to be run in the future
+type Scheduler struct {
+	provider *SchedulerProvider
+	ctx      *Context
+}
+
+var TypeScheduler = reflect.TypeOf((**Scheduler)(nil)).Elem()
+
+// RequireScheduler returns the scheduler
+func RequireScheduler(ctx *Context) *Scheduler {
+	if v := ctx.Require(TypeScheduler); v != nil {
+		return v.(*Scheduler)
+	}
+	return nil
+}
+
+// Schedule schedules fn to be run once the given time has been reached.
+// The job will not be scheduled in case the server is shutting down
+// (see Graceful.IsDown). Panics within fn will be recovered and logged.
+func (s *Scheduler) Schedule(at time.Time, fn func(ctx *Context)) error {
+	if s.ctx.factory.hasProvider(TypeGraceful) && RequireGraceful(s.ctx).IsDown() {
+		return ErrSchedulerShutdown
+	}
+
+	var tm Time = NewRealTime()
+	if s.ctx.factory.hasProvider(TypeTime) {
+		tm = RequireTime(s.ctx)
+	}
+
+	s.provider.mux.Lock()
+	defer s.provider.mux.Unlock()
+	if s.provider.closed {
+		return ErrSchedulerShutdown
+	}
+	s.provider.jobs = append(s.provider.jobs, &schedulerJob{
+		at:            at,
+		time:          tm,
+		factory:       s.ctx.factory,
+		methodHandler: s.ctx.methodHandler,
+		fn:            fn,
+	})
+	return nil
+}
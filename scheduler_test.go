@@ -0,0 +1,186 @@
+package jonson
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("runs due jobs once time has been advanced", func(t *testing.T) {
+		tm := newMockTime(now)
+		scheduler := NewSchedulerProvider(&SchedulerOptions{})
+		fac := NewFactory()
+		fac.RegisterProvider(scheduler)
+		fac.RegisterProvider(NewTimeProvider(func() Time { return tm }))
+
+		ctx := NewContext(context.Background(), fac, nil)
+		fired := []string{}
+		for _, v := range []struct {
+			name string
+			in   time.Duration
+		}{
+			{"later", time.Hour * 2},
+			{"soon", time.Hour},
+		} {
+			err := RequireScheduler(ctx).Schedule(now.Add(v.in), func(jobCtx *Context) {
+				if jobCtx == ctx {
+					t.Fatal("expected job to be called with a fresh context")
+				}
+				fired = append(fired, v.name)
+			})
+			if err != nil {
+				t.Fatalf("expected job to be scheduled: %s", err)
+			}
+		}
+
+		if cnt := scheduler.RunDue(); cnt != 0 {
+			t.Fatalf("expected no job to be due, got: %d", cnt)
+		}
+
+		tm.now = now.Add(time.Hour)
+		if cnt := scheduler.RunDue(); cnt != 1 || len(fired) != 1 || fired[0] != "soon" {
+			t.Fatalf("expected job 'soon' to be run, got: %v", fired)
+		}
+		if scheduler.Pending() != 1 {
+			t.Fatalf("expected 1 pending job, got: %d", scheduler.Pending())
+		}
+
+		tm.now = now.Add(time.Hour * 3)
+		if cnt := scheduler.RunDue(); cnt != 1 || len(fired) != 2 || fired[1] != "later" {
+			t.Fatalf("expected job 'later' to be run, got: %v", fired)
+		}
+		if scheduler.Pending() != 0 {
+			t.Fatalf("expected no pending jobs, got: %d", scheduler.Pending())
+		}
+	})
+
+	t.Run("runs due jobs in order of their scheduled time", func(t *testing.T) {
+		tm := newMockTime(now)
+		scheduler := NewSchedulerProvider(&SchedulerOptions{})
+		fac := NewFactory()
+		fac.RegisterProvider(scheduler)
+		fac.RegisterProvider(NewTimeProvider(func() Time { return tm }))
+
+		ctx := NewContext(context.Background(), fac, nil)
+		fired := []int{}
+		for _, v := range []int{3, 1, 2} {
+			RequireScheduler(ctx).Schedule(now.Add(time.Minute*time.Duration(v)), func(ctx *Context) {
+				fired = append(fired, v)
+			})
+		}
+
+		tm.now = now.Add(time.Hour)
+		scheduler.RunDue()
+		if len(fired) != 3 || fired[0] != 1 || fired[1] != 2 || fired[2] != 3 {
+			t.Fatalf("expected jobs to be run in order, got: %v", fired)
+		}
+	})
+
+	t.Run("recovers panics within jobs", func(t *testing.T) {
+		tm := newMockTime(now)
+		scheduler := NewSchedulerProvider(&SchedulerOptions{})
+		fac := NewFactory()
+		fac.RegisterProvider(scheduler)
+		fac.RegisterProvider(NewTimeProvider(func() Time { return tm }))
+
+		ctx := NewContext(context.Background(), fac, nil)
+		called := false
+		RequireScheduler(ctx).Schedule(now, func(ctx *Context) {
+			panic("job failed")
+		})
+		RequireScheduler(ctx).Schedule(now, func(ctx *Context) {
+			called = true
+		})
+
+		if cnt := scheduler.RunDue(); cnt != 2 {
+			t.Fatalf("expected 2 jobs to be run, got: %d", cnt)
+		}
+		if !called {
+			t.Fatal("expected job after panic to be run")
+		}
+	})
+
+	t.Run("does not fire jobs while the server is shutting down", func(t *testing.T) {
+		tm := newMockTime(now)
+		graceful := NewGracefulProvider()
+		scheduler := NewSchedulerProvider(&SchedulerOptions{})
+		fac := NewFactory()
+		fac.RegisterProvider(scheduler)
+		fac.RegisterProvider(graceful)
+		fac.RegisterProvider(NewTimeProvider(func() Time { return tm }))
+
+		ctx := NewContext(context.Background(), fac, nil)
+		called := false
+		err := RequireScheduler(ctx).Schedule(now.Add(time.Hour), func(ctx *Context) {
+			called = true
+		})
+		if err != nil {
+			t.Fatalf("expected job to be scheduled: %s", err)
+		}
+
+		// mimic a server shutdown
+		close(graceful.checkStatusChan)
+
+		tm.now = now.Add(time.Hour)
+		if cnt := scheduler.RunDue(); cnt != 0 || called {
+			t.Fatal("expected job not to be run during shutdown")
+		}
+		if err := RequireScheduler(ctx).Schedule(now, func(ctx *Context) {}); err != ErrSchedulerShutdown {
+			t.Fatalf("expected ErrSchedulerShutdown, got: %v", err)
+		}
+	})
+
+	t.Run("drops pending jobs on shutdown", func(t *testing.T) {
+		tm := newMockTime(now)
+		scheduler := NewSchedulerProvider(&SchedulerOptions{})
+		fac := NewFactory()
+		fac.RegisterProvider(scheduler)
+		fac.RegisterProvider(NewTimeProvider(func() Time { return tm }))
+
+		ctx := NewContext(context.Background(), fac, nil)
+		called := false
+		RequireScheduler(ctx).Schedule(now.Add(time.Hour), func(ctx *Context) {
+			called = true
+		})
+
+		if err := scheduler.Shutdown(context.Background()); err != nil {
+			t.Fatalf("expected shutdown to succeed: %s", err)
+		}
+		tm.now = now.Add(time.Hour)
+		if cnt := scheduler.RunDue(); cnt != 0 || called {
+			t.Fatal("expected job not to be run after shutdown")
+		}
+		if err := RequireScheduler(ctx).Schedule(now, func(ctx *Context) {}); err != ErrSchedulerShutdown {
+			t.Fatalf("expected ErrSchedulerShutdown, got: %v", err)
+		}
+	})
+
+	t.Run("polls for due jobs", func(t *testing.T) {
+		scheduler := NewSchedulerProvider(&SchedulerOptions{
+			PollInterval: time.Millisecond * 10,
+		})
+		fac := NewFactory()
+		fac.RegisterProvider(scheduler)
+
+		ctx := NewContext(context.Background(), fac, nil)
+		fired := make(chan struct{})
+		err := RequireScheduler(ctx).Schedule(time.Now(), func(ctx *Context) {
+			close(fired)
+		})
+		if err != nil {
+			t.Fatalf("expected job to be scheduled: %s", err)
+		}
+
+		select {
+		case <-fired:
+		case <-time.After(time.Second * 5):
+			t.Fatal("expected job to be run by polling")
+		}
+		if err := scheduler.Shutdown(context.Background()); err != nil {
+			t.Fatalf("expected shutdown to succeed: %s", err)
+		}
+	})
+}
@@ -8,7 +8,9 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"reflect"
 	"strconv"
 )
@@ -37,19 +39,73 @@ type AESSecret struct {
 
 var _ Secret = (&AESSecret{})
 
+// NewAESSecret returns a new AES secret using the hex encoded key.
+// The key needs to be 16, 24 or 32 bytes long;
+// in case the key is invalid, NewAESSecret panics.
 func NewAESSecret(aesKeyHex string) *AESSecret {
+	secret, err := newAESSecret(aesKeyHex)
+	if err != nil {
+		panic(err.Error())
+	}
+	return secret
+}
+
+// NewAESSecretFromEnv returns a new AES secret using the hex encoded key
+// stored within the given environment variable. In case the variable is
+// missing or the key is invalid, an error will be returned.
+func NewAESSecretFromEnv(name string) (*AESSecret, error) {
+	aesKeyHex, ok := os.LookupEnv(name)
+	if !ok || aesKeyHex == "" {
+		return nil, errors.New("error encoder: environment variable " + name + " is not set")
+	}
+	secret, err := newAESSecret(aesKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w (environment variable %s)", err, name)
+	}
+	return secret, nil
+}
+
+// MustNewAESSecretFromEnv works like NewAESSecretFromEnv
+// but panics in case the secret cannot be created
+func MustNewAESSecretFromEnv(name string) *AESSecret {
+	secret, err := NewAESSecretFromEnv(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return secret
+}
+
+func newAESSecret(aesKeyHex string) (*AESSecret, error) {
 	aesCypher, err := hex.DecodeString(aesKeyHex)
 	if err != nil {
-		panic("error encoder: %w" + err.Error())
+		return nil, errors.New("error encoder: " + err.Error())
 	}
 
 	if len(aesCypher) != 16 && len(aesCypher) != 24 && len(aesCypher) != 32 {
-		panic("error encoder: AES cypher needs to be 16, 24 or 32 bytes long, got: " + strconv.Itoa(len(aesCypher)))
+		return nil, errors.New("error encoder: AES cypher needs to be 16, 24 or 32 bytes long, got: " + strconv.Itoa(len(aesCypher)))
 	}
 
 	return &AESSecret{
 		aesCypher: aesCypher,
+	}, nil
+}
+
+// exampleAESKeys contains publicly known keys, such as
+// the key used within this repository's example
+var exampleAESKeys = []string{
+	"962C27B021AD53CC1110E81E6F6C09D7A14F7911C508A43A",
+}
+
+// usesExampleKey returns true in case the secret
+// uses one of the publicly known example keys
+func (e *AESSecret) usesExampleKey() bool {
+	for _, v := range exampleAESKeys {
+		key, _ := hex.DecodeString(v)
+		if bytes.Equal(key, e.aesCypher) {
+			return true
+		}
 	}
+	return false
 }
 
 // Encode may be used to embed sensitive information
@@ -44,3 +44,41 @@ func TestDebugSecret(t *testing.T) {
 		t.Fatal("expected decoded text to equal original text, got: " + decoded)
 	}
 }
+
+func TestAESSecretFromEnv(t *testing.T) {
+	t.Run("returns the secret", func(t *testing.T) {
+		t.Setenv("JONSON_SECRET_KEY", "962C27B021AD53CC1110E81E6F6C09D7A14F7911C508A43A")
+		secret, err := NewAESSecretFromEnv("JONSON_SECRET_KEY")
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := secret.Decode(secret.Encode("Silvio"))
+		if err != nil || decoded != "Silvio" {
+			t.Fatalf("expected decoded text to equal original text, got: %s (%v)", decoded, err)
+		}
+	})
+
+	t.Run("fails in case the variable is missing", func(t *testing.T) {
+		if _, err := NewAESSecretFromEnv("JONSON_SECRET_KEY_MISSING"); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("fails in case the key is invalid", func(t *testing.T) {
+		for _, v := range []string{"no-hex", "962C27B021AD53CC"} {
+			t.Setenv("JONSON_SECRET_KEY", v)
+			if _, err := NewAESSecretFromEnv("JONSON_SECRET_KEY"); err == nil {
+				t.Fatalf("expected an error for key '%s'", v)
+			}
+		}
+	})
+
+	t.Run("must panics in case the key is invalid", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected a panic")
+			}
+		}()
+		MustNewAESSecretFromEnv("JONSON_SECRET_KEY_MISSING")
+	})
+}
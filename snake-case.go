@@ -0,0 +1,10 @@
+package jonson
+
+import (
+	"strings"
+)
+
+// ToSnakeCase converts the provided string to snake_case
+func ToSnakeCase(input string) string {
+	return strings.ReplaceAll(ToKebabCase(input), "-", "_")
+}
@@ -0,0 +1,35 @@
+package jonson
+
+import "testing"
+
+func TestToSnakeCase(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"camelCase", "camel_case"},
+		{"PascalCase", "pascal_case"},
+		{"snake_case", "snake_case"},
+		{"Pascal_Snake", "pascal_snake"},
+		{"SCREAMING-SNAKE", "screaming_snake"},
+		{"kebab-case", "kebab_case"},
+		{"Pascal-Kebab", "pascal_kebab"},
+		{"SCREAMING-KEBAB", "screaming_kebab"},
+		{"A", "a"},
+		{"AA", "aa"},
+		{"AAA", "aaa"},
+		{"AAAA", "aaaa"},
+		{"AaAa", "aa_aa"},
+		{"HTTPRequest", "http_request"},
+		{"BatteryLifeValue", "battery_life_value"},
+		{"Id0Value", "id0_value"},
+		{"ID0Value", "id0_value"},
+		{"MyAPIv2", "my_ap_iv2"},
+	}
+	for _, tt := range tests {
+		if got := ToSnakeCase(tt.input); got != tt.expected {
+			t.Errorf("ToSnakeCase(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package jonson
 
 import (
+	"context"
 	"reflect"
 	"time"
 )
@@ -52,6 +53,9 @@ type Time interface {
 	ShareableAcrossImpersonation
 	Now() time.Time
 	Sleep(time.Duration)
+	// SleepContext sleeps for the given duration but returns
+	// early with ctx.Err() in case the context has been canceled
+	SleepContext(ctx context.Context, dur time.Duration) error
 }
 
 // RealTime implements time
@@ -73,6 +77,18 @@ func (t *RealTime) Sleep(dur time.Duration) {
 	time.Sleep(dur)
 }
 
+// SleepContext sleeps for duration or until the context has been canceled
+func (t *RealTime) SleepContext(ctx context.Context, dur time.Duration) error {
+	timer := time.NewTimer(dur)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // NewTime returns a time instance which provides us with
 // real time information. You will probably use this
 // time instance for your production build.
@@ -0,0 +1,33 @@
+package jonson
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRealTimeSleepContext(t *testing.T) {
+	tm := NewRealTime()
+
+	t.Run("sleeps for duration", func(t *testing.T) {
+		if err := tm.SleepContext(context.Background(), time.Millisecond); err != nil {
+			t.Fatalf("expected no error, got: %s", err)
+		}
+	})
+
+	t.Run("returns early in case the context has been canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		nw := time.Now()
+		if err := tm.SleepContext(ctx, time.Minute); err != context.Canceled {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+		if time.Since(nw) > time.Second {
+			t.Fatal("expected sleep to return early")
+		}
+	})
+}
@@ -1,8 +1,10 @@
 package jonson
 
 import (
+	"cmp"
 	"fmt"
 	"reflect"
+	"time"
 )
 
 type ValidatedParams interface {
@@ -10,11 +12,16 @@ type ValidatedParams interface {
 }
 
 type Validator struct {
-	errors   []*Error
-	secret   Secret
-	basePath []string
+	errors      []*Error
+	secret      Secret
+	basePath    []string
+	stopOnError bool
 }
 
+// validatorStop is used to abort a validation
+// once an error has been added in stop-on-error mode
+type validatorStop struct{}
+
 func NewValidator(secret Secret, basePath ...string) *Validator {
 	return &Validator{
 		errors:   []*Error{},
@@ -56,6 +63,7 @@ func (e *validatorError) add() {
 		Data:    data,
 	}
 	e.validator.errors = append(e.validator.errors, err)
+	e.validator.stop()
 }
 
 // Message adds an error message which will be forwarded
@@ -95,17 +103,51 @@ func (e *validatorError) Validate(validateable ValidatedParams) *Error {
 	// mark this validator error as done
 	e.added = true
 
-	err := Validate(e.validator.secret, validateable, append(e.validator.basePath, e.path...)...)
-	e.added = true
+	// the error's path already contains the validator's base path
+	err := validate(e.validator.secret, validateable, e.validator.stopOnError, e.path...)
 	if err == nil {
 		return nil
 	}
 
 	// details will always exist since the validator will set the details
 	e.validator.errors = append(e.validator.errors, err.Data.Details...)
+	e.validator.stop()
 	return err
 }
 
+// StopOnError aborts the validation as soon as the first error
+// has been added, including errors of nested validations.
+// Use it in case subsequent checks depend on previous ones
+// and would only result in misleading errors.
+// By default, all errors will be collected.
+//
+//	func (p *Params) JonsonValidate(v *jonson.Validator) {
+//		v.StopOnError()
+//		// ...
+//	}
+func (e *Validator) StopOnError() *Validator {
+	e.stopOnError = true
+	return e
+}
+
+// Require adds an error for the given path in case cond is false
+// and aborts the current validation right away, regardless of StopOnError.
+func (e *Validator) Require(cond bool, path string, message string) {
+	if cond {
+		return
+	}
+	e.stopOnError = true
+	e.Path(path).Message(message)
+}
+
+// stop aborts the validation in case the validator
+// is in stop-on-error mode
+func (e *Validator) stop() {
+	if e.stopOnError {
+		panic(validatorStop{})
+	}
+}
+
 type validatorIndex struct {
 	index int
 }
@@ -130,8 +172,14 @@ func (e *Validator) Path(_path ...any) *validatorError {
 		}
 	}
 
+	// copy the base path: appending to the base path directly
+	// might override paths of previously created errors
+	path := make([]string, 0, len(e.basePath)+len(convertedPaths))
+	path = append(path, e.basePath...)
+	path = append(path, convertedPaths...)
+
 	return &validatorError{
-		path:      append(e.basePath, convertedPaths...),
+		path:      path,
 		validator: e,
 
 		code:    ErrInvalidParams.Code,
@@ -153,7 +201,102 @@ func (e *Validator) Error() *Error {
 
 // Validate validates the handled interface
 func Validate(secret Secret, validateable ValidatedParams, basePath ...string) *Error {
+	return validate(secret, validateable, false, basePath...)
+}
+
+func validate(secret Secret, validateable ValidatedParams, stopOnError bool, basePath ...string) (err *Error) {
 	collector := NewValidator(secret, basePath...)
+	collector.stopOnError = stopOnError
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(validatorStop); !ok {
+				panic(r)
+			}
+			err = collector.Error()
+		}
+	}()
 	validateable.JonsonValidate(collector)
 	return collector.Error()
 }
+
+// validatorComparison compares a field's value
+// with the value of another field
+type validatorComparison struct {
+	validator *Validator
+	path      string
+	value     any
+}
+
+// Compare starts a cross-field comparison, e.g.
+//
+//	v.Compare("start", p.Start).LessThan("end", p.End)
+//
+// In case the relation does not hold, an error will be added to the
+// first field's path. Supported values are numbers, strings and time.Time;
+// both values need to be of the same type.
+func (e *Validator) Compare(path string, value any) *validatorComparison {
+	return &validatorComparison{
+		validator: e,
+		path:      path,
+		value:     value,
+	}
+}
+
+// LessThan validates the value to be less than the other field's value
+func (c *validatorComparison) LessThan(path string, value any) *Validator {
+	return c.check(compare(c.value, value) < 0, "less than", path)
+}
+
+// LessThanOrEqual validates the value to be less than or equal to the other field's value
+func (c *validatorComparison) LessThanOrEqual(path string, value any) *Validator {
+	return c.check(compare(c.value, value) <= 0, "less than or equal to", path)
+}
+
+// GreaterThan validates the value to be greater than the other field's value
+func (c *validatorComparison) GreaterThan(path string, value any) *Validator {
+	return c.check(compare(c.value, value) > 0, "greater than", path)
+}
+
+// GreaterThanOrEqual validates the value to be greater than or equal to the other field's value
+func (c *validatorComparison) GreaterThanOrEqual(path string, value any) *Validator {
+	return c.check(compare(c.value, value) >= 0, "greater than or equal to", path)
+}
+
+// Equal validates the value to equal the other field's value
+func (c *validatorComparison) Equal(path string, value any) *Validator {
+	return c.check(compare(c.value, value) == 0, "equal to", path)
+}
+
+func (c *validatorComparison) check(ok bool, relation string, path string) *Validator {
+	if ok {
+		return c.validator
+	}
+	return c.validator.Path(c.path).Message(fmt.Sprintf("%s must be %s %s", c.path, relation, path))
+}
+
+// compare returns -1, 0 or +1 depending on a being less than,
+// equal to or greater than b
+func compare(a, b any) int {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() != vb.Kind() {
+		panic(fmt.Sprintf("validator: cannot compare %v with %v", reflect.TypeOf(a), reflect.TypeOf(b)))
+	}
+	switch va.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(va.Int(), vb.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(va.Uint(), vb.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(va.Float(), vb.Float())
+	case reflect.String:
+		return cmp.Compare(va.String(), vb.String())
+	default:
+		panic(fmt.Sprintf("validator: unsupported comparison type: %v; numbers, strings and time.Time are the only supported types", reflect.TypeOf(a)))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 type Profile struct {
@@ -166,3 +167,185 @@ func TestValidate(t *testing.T) {
 	}
 
 }
+
+type Gallery struct {
+	Albums []*Album `json:"albums"`
+}
+
+func (g *Gallery) JonsonValidate(v *Validator) {
+	for idx, album := range g.Albums {
+		v.Path("albums", v.Index(idx)).Validate(album)
+	}
+}
+
+type Album struct {
+	Images []*Image `json:"images"`
+}
+
+func (a *Album) JonsonValidate(v *Validator) {
+	for idx, img := range a.Images {
+		v.Path("images", v.Index(idx)).Validate(img)
+	}
+}
+
+func TestValidateNestedSlices(t *testing.T) {
+	validImage := func() *Image {
+		return &Image{
+			UUID: "d69b8e2c-3e72-47fe-9c06-5113d03e7d59",
+			URL:  "https://example.com",
+		}
+	}
+	gallery := &Gallery{
+		Albums: []*Album{
+			{Images: []*Image{validImage(), validImage()}},
+			{Images: []*Image{validImage(), validImage(), validImage()}},
+		},
+	}
+	gallery.Albums[0].Images[1].URL = ""
+	gallery.Albums[1].Images[2].UUID = ""
+
+	err := Validate(NewDebugSecret(), gallery, "gallery")
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if len(err.Data.Details) != 2 {
+		t.Fatalf("expected 2 errors, got: %d", len(err.Data.Details))
+	}
+
+	expected := []string{
+		"gallery.albums.[0].images.[1].url",
+		"gallery.albums.[1].images.[2].uuid",
+	}
+	for idx, v := range err.Data.Details {
+		if path := strings.Join(v.Data.Path, "."); path != expected[idx] {
+			t.Fatalf("expected path to equal %s, got: %s", expected[idx], path)
+		}
+	}
+}
+
+type StrictImage struct {
+	Image
+	required bool
+}
+
+func (s *StrictImage) JonsonValidate(v *Validator) {
+	if s.required {
+		v.Require(len(s.URL) > 0, "url", "url required")
+	}
+	s.Image.JonsonValidate(v)
+}
+
+type StrictProfile struct {
+	Profile
+}
+
+func (s *StrictProfile) JonsonValidate(v *Validator) {
+	v.StopOnError()
+	s.Profile.JonsonValidate(v)
+}
+
+func TestValidateStopOnError(t *testing.T) {
+	t.Run("collects all errors by default", func(t *testing.T) {
+		err := Validate(NewDebugSecret(), &Image{})
+		if err == nil || len(err.Data.Details) != 2 {
+			t.Fatalf("expected 2 errors, got: %v", err)
+		}
+	})
+
+	t.Run("stops on first error", func(t *testing.T) {
+		err := Validate(NewDebugSecret(), &StrictProfile{Profile: Profile{
+			Name:          "a",
+			ImageRequired: Image{},
+		}})
+		if err == nil || len(err.Data.Details) != 1 {
+			t.Fatalf("expected a single error, got: %v", err)
+		}
+		if err.Data.Details[0].Data.Path[0] != "name" {
+			t.Fatalf("expected 'name' to have an error, got: %v", err.Data.Details[0].Data.Path)
+		}
+	})
+
+	t.Run("stops on first error of nested validations", func(t *testing.T) {
+		err := Validate(NewDebugSecret(), &StrictProfile{Profile: Profile{
+			Name:          "Silvio",
+			ImageRequired: Image{},
+		}})
+		if err == nil || len(err.Data.Details) != 1 {
+			t.Fatalf("expected a single error, got: %v", err)
+		}
+		if path := strings.Join(err.Data.Details[0].Data.Path, "."); path != "imageRequired.url" {
+			t.Fatalf("expected 'imageRequired.url' to have an error, got: %s", path)
+		}
+	})
+
+	t.Run("require aborts the validation", func(t *testing.T) {
+		err := Validate(NewDebugSecret(), &StrictImage{required: true})
+		if err == nil || len(err.Data.Details) != 1 {
+			t.Fatalf("expected a single error, got: %v", err)
+		}
+		if err.Data.Details[0].Message != "url required" {
+			t.Fatalf("expected required message, got: %s", err.Data.Details[0].Message)
+		}
+	})
+
+	t.Run("require passes in case condition is met", func(t *testing.T) {
+		err := Validate(NewDebugSecret(), &StrictImage{
+			Image:    Image{URL: "https://example.com"},
+			required: true,
+		})
+		if err == nil || len(err.Data.Details) != 1 || err.Data.Details[0].Data.Path[0] != "uuid" {
+			t.Fatalf("expected uuid error only, got: %v", err)
+		}
+	})
+}
+
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Min   int       `json:"min"`
+	Max   int       `json:"max"`
+}
+
+func (p *Period) JonsonValidate(v *Validator) {
+	v.Compare("start", p.Start).LessThan("end", p.End)
+	v.Compare("min", p.Min).LessThanOrEqual("max", p.Max)
+}
+
+func TestValidateCompare(t *testing.T) {
+	nw := time.Now()
+
+	t.Run("valid relations do not add errors", func(t *testing.T) {
+		err := Validate(NewDebugSecret(), &Period{Start: nw, End: nw.Add(time.Hour), Min: 1, Max: 1})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("invalid relations add errors to the first field", func(t *testing.T) {
+		err := Validate(NewDebugSecret(), &Period{Start: nw, End: nw, Min: 2, Max: 1})
+		if err == nil || len(err.Data.Details) != 2 {
+			t.Fatalf("expected 2 errors, got: %v", err)
+		}
+		expected := []struct {
+			path    string
+			message string
+		}{
+			{"start", "start must be less than end"},
+			{"min", "min must be less than or equal to max"},
+		}
+		for idx, v := range err.Data.Details {
+			if v.Data.Path[0] != expected[idx].path || v.Message != expected[idx].message {
+				t.Fatalf("expected %s: %s, got: %s: %s", expected[idx].path, expected[idx].message, v.Data.Path[0], v.Message)
+			}
+		}
+	})
+
+	t.Run("panics on mismatching types", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("expected comparison to panic")
+			}
+		}()
+		compare(1, "1")
+	})
+}
@@ -0,0 +1,173 @@
+package jonson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+var (
+	// ErrWorkerQueueFull will be returned in case a job
+	// could not be enqueued due to a full queue
+	ErrWorkerQueueFull = errors.New("worker: queue full")
+	// ErrWorkerShutdown will be returned in case a job
+	// could not be enqueued since the workers are shutting down
+	ErrWorkerShutdown = errors.New("worker: shutting down")
+)
+
+type WorkerOptions struct {
+	// Concurrency defines the number of jobs processed in parallel
+	Concurrency int
+	// QueueSize defines the number of jobs waiting to be processed;
+	// once the queue is full, Enqueue will return ErrWorkerQueueFull
+	QueueSize int
+}
+
+func NewWorkerOptions() *WorkerOptions {
+	return &WorkerOptions{
+		Concurrency: 4,
+		QueueSize:   256,
+	}
+}
+
+// WorkerProvider runs jobs on a pool of background workers,
+// e.g. to do work after a response has been sent.
+// Each job will be called with a fresh context.
+// Pass Shutdown to the GracefulProvider in order to drain
+// the queue during a graceful shutdown:
+//
+//	workers := jonson.NewWorkerProvider(nil)
+//	fac.RegisterProvider(workers)
+//	graceful := jonson.NewGracefulProvider().WithShutdownFunc(workers.Shutdown)
+type WorkerProvider struct {
+	opts  *WorkerOptions
+	queue chan *workerJob
+
+	mux    sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type workerJob struct {
+	factory       *Factory
+	methodHandler *MethodHandler
+	fn            func(ctx *Context) error
+}
+
+// NewWorkerProvider returns a new worker provider and starts its workers.
+// In case no options are provided, NewWorkerOptions() will be used.
+func NewWorkerProvider(opts *WorkerOptions) *WorkerProvider {
+	if opts == nil {
+		opts = NewWorkerOptions()
+	}
+	w := &WorkerProvider{
+		opts:  opts,
+		queue: make(chan *workerJob, opts.QueueSize),
+	}
+	for i := 0; i < opts.Concurrency; i++ {
+		w.wg.Add(1)
+		go w.work()
+	}
+	return w
+}
+
+func (w *WorkerProvider) NewWorker(ctx *Context) *Worker {
+	return &Worker{
+		provider: w,
+		ctx:      ctx,
+	}
+}
+
+func (w *WorkerProvider) work() {
+	defer w.wg.Done()
+	for job := range w.queue {
+		w.run(job)
+	}
+}
+
+func (w *WorkerProvider) run(job *workerJob) {
+	ctx := NewContext(context.Background(), job.factory, job.methodHandler)
+	var err error
+	defer func() {
+		if r := recover(); r != nil {
+			if job.methodHandler != nil {
+				err = job.methodHandler.recoverError(r)
+			} else {
+				err = getRecoverError(r)
+			}
+		}
+		if err = ctx.Finalize(err); err != nil {
+			job.factory.Logger().Warn("worker: job failed", "error", err)
+		}
+	}()
+	err = job.fn(ctx)
+}
+
+// Shutdown stops accepting new jobs and waits for all
+// enqueued jobs to be processed. In case the given context is
+// done before, its error will be returned.
+func (w *WorkerProvider) Shutdown(ctx context.Context) error {
+	w.mux.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mux.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Worker enqueues jobs to the background workers
+type Worker struct {
+	provider *WorkerProvider
+	ctx      *Context
+}
+
+var TypeWorker = reflect.TypeOf((**Worker)(nil)).Elem()
+
+// RequireWorker returns the worker
+func RequireWorker(ctx *Context) *Worker {
+	if v := ctx.Require(TypeWorker); v != nil {
+		return v.(*Worker)
+	}
+	return nil
+}
+
+// Enqueue enqueues fn to be run by the next available worker.
+// The job will not be enqueued in case the queue is full or
+// the server is shutting down (see Graceful.IsDown).
+// Errors returned by the job will be logged.
+func (w *Worker) Enqueue(fn func(ctx *Context) error) error {
+	if w.ctx.factory.hasProvider(TypeGraceful) && RequireGraceful(w.ctx).IsDown() {
+		return ErrWorkerShutdown
+	}
+
+	w.provider.mux.RLock()
+	defer w.provider.mux.RUnlock()
+	if w.provider.closed {
+		return ErrWorkerShutdown
+	}
+
+	select {
+	case w.provider.queue <- &workerJob{
+		factory:       w.ctx.factory,
+		methodHandler: w.ctx.methodHandler,
+		fn:            fn,
+	}:
+		return nil
+	default:
+		return ErrWorkerQueueFull
+	}
+}
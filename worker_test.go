@@ -0,0 +1,127 @@
+package jonson
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorker(t *testing.T) {
+	t.Run("runs enqueued jobs within a fresh context", func(t *testing.T) {
+		workers := NewWorkerProvider(nil)
+		fac := NewFactory()
+		fac.RegisterProvider(workers)
+
+		ctx := NewContext(context.Background(), fac, nil)
+		jobCtx := make(chan *Context, 1)
+		err := RequireWorker(ctx).Enqueue(func(ctx *Context) error {
+			jobCtx <- ctx
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected job to be enqueued: %s", err)
+		}
+		if c := <-jobCtx; c == ctx {
+			t.Fatal("expected job to be called with a fresh context")
+		}
+	})
+
+	t.Run("drains the queue on shutdown", func(t *testing.T) {
+		workers := NewWorkerProvider(&WorkerOptions{
+			Concurrency: 2,
+			QueueSize:   10,
+		})
+		fac := NewFactory()
+		fac.RegisterProvider(workers)
+
+		ctx := NewContext(context.Background(), fac, nil)
+		var cnt atomic.Int32
+		for i := 0; i < 10; i++ {
+			err := RequireWorker(ctx).Enqueue(func(ctx *Context) error {
+				time.Sleep(time.Millisecond)
+				cnt.Add(1)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("expected job to be enqueued: %s", err)
+			}
+		}
+
+		if err := workers.Shutdown(context.Background()); err != nil {
+			t.Fatalf("expected shutdown to succeed: %s", err)
+		}
+		if cnt.Load() != 10 {
+			t.Fatalf("expected all jobs to be processed, got: %d", cnt.Load())
+		}
+		if err := RequireWorker(ctx).Enqueue(func(ctx *Context) error { return nil }); err != ErrWorkerShutdown {
+			t.Fatalf("expected ErrWorkerShutdown, got: %v", err)
+		}
+	})
+
+	t.Run("returns an error once the queue is full", func(t *testing.T) {
+		workers := NewWorkerProvider(&WorkerOptions{
+			Concurrency: 1,
+			QueueSize:   1,
+		})
+		fac := NewFactory()
+		fac.RegisterProvider(workers)
+
+		ctx := NewContext(context.Background(), fac, nil)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		RequireWorker(ctx).Enqueue(func(ctx *Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+		<-started
+
+		if err := RequireWorker(ctx).Enqueue(func(ctx *Context) error { return nil }); err != nil {
+			t.Fatalf("expected job to be enqueued: %s", err)
+		}
+		if err := RequireWorker(ctx).Enqueue(func(ctx *Context) error { return nil }); err != ErrWorkerQueueFull {
+			t.Fatalf("expected ErrWorkerQueueFull, got: %v", err)
+		}
+		close(release)
+		workers.Shutdown(context.Background())
+	})
+
+	t.Run("recovers panicking jobs", func(t *testing.T) {
+		workers := NewWorkerProvider(&WorkerOptions{
+			Concurrency: 1,
+			QueueSize:   2,
+		})
+		fac := NewFactory()
+		fac.RegisterProvider(workers)
+
+		ctx := NewContext(context.Background(), fac, NewMethodHandler(fac, NewDebugSecret(), nil))
+		called := false
+		RequireWorker(ctx).Enqueue(func(ctx *Context) error {
+			panic("job failed")
+		})
+		RequireWorker(ctx).Enqueue(func(ctx *Context) error {
+			called = true
+			return nil
+		})
+		workers.Shutdown(context.Background())
+		if !called {
+			t.Fatal("expected worker to continue after a panicking job")
+		}
+	})
+
+	t.Run("rejects jobs once the server is shutting down", func(t *testing.T) {
+		workers := NewWorkerProvider(nil)
+		graceful := NewGracefulProvider()
+		fac := NewFactory()
+		fac.RegisterProvider(workers)
+		fac.RegisterProvider(graceful)
+
+		close(graceful.checkStatusChan)
+
+		ctx := NewContext(context.Background(), fac, nil)
+		if err := RequireWorker(ctx).Enqueue(func(ctx *Context) error { return nil }); err != ErrWorkerShutdown {
+			t.Fatalf("expected ErrWorkerShutdown, got: %v", err)
+		}
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -21,6 +23,21 @@ func RequireWSClient(ctx *Context) *WSClient {
 	return nil
 }
 
+// Notify sends a notification to the client of the ongoing call regardless
+// of its transport, e.g. to report the progress of a long running method.
+// In case the transport cannot push notifications (such as http),
+// the notification will be dropped; set MethodHandlerOptions.RequireNotificationChannel
+// to receive ErrNoNotificationChannel instead.
+func (c *Context) Notify(method string, payload any) error {
+	if v, err := c.GetValue(TypeWSClient); err == nil {
+		return v.(*WSClient).SendNotification(NewRpcNotification(method, payload))
+	}
+	if c.methodHandler != nil && c.methodHandler.opts.RequireNotificationChannel {
+		return ErrNoNotificationChannel
+	}
+	return nil
+}
+
 // The websocket handler allows us to provide
 // websocket functionality to the server.
 type WebsocketHandler struct {
@@ -35,8 +52,87 @@ type WebsocketOptions struct {
 	PingPeriod     time.Duration
 	PongWait       time.Duration
 	WriteWait      time.Duration
+
+	// SendBufferSize is the number of outgoing messages
+	// buffered per client
+	SendBufferSize int
+	// BackpressurePolicy defines what happens in case a client's
+	// send buffer is full (e.g. due to a slow consumer)
+	BackpressurePolicy WebsocketBackpressurePolicy
+
+	// MaxConcurrentRequests limits the number of messages
+	// processed concurrently per client. Defaults to 0 (unlimited).
+	MaxConcurrentRequests int
+	// ConcurrencyPolicy defines what happens to messages exceeding
+	// MaxConcurrentRequests. Defaults to WebsocketConcurrencyQueue.
+	ConcurrencyPolicy WebsocketConcurrencyPolicy
+	// OrderedResponses processes a client's messages one after another,
+	// making sure responses are sent in the order the messages were received.
+	// A slow call delays all subsequent calls of the client; use batches
+	// or multiple connections for throughput. MaxConcurrentRequests then limits
+	// the number of messages waiting to be processed; using WebsocketConcurrencyQueue,
+	// reading (e.g. pongs) stops once the limit is reached, hence calls need to
+	// finish within PongWait. Defaults to false: messages are processed
+	// concurrently and responses are sent once available.
+	OrderedResponses bool
+
+	// OnPing is called once a ping has been sent to a client
+	OnPing func(client *WSClient)
+	// OnPong is called once a client answered a ping, e.g. to track
+	// the client's presence. Callbacks run within their own goroutine
+	// and do not block reading from or writing to the connection.
+	OnPong func(client *WSClient)
 }
 
+// WebsocketBackpressurePolicy defines the behavior
+// of a websocket client once its send buffer is full
+type WebsocketBackpressurePolicy string
+
+const (
+	// WebsocketBackpressureBlock waits for the buffer to drain;
+	// in case the buffer does not drain within WriteWait,
+	// ErrWSClientSendBufferFull will be returned
+	WebsocketBackpressureBlock WebsocketBackpressurePolicy = "block"
+	// WebsocketBackpressureDropOldest drops the oldest buffered message
+	// in favor of the new message
+	WebsocketBackpressureDropOldest WebsocketBackpressurePolicy = "dropOldest"
+	// WebsocketBackpressureDropNewest drops the new message
+	// and returns ErrWSClientSendBufferFull
+	WebsocketBackpressureDropNewest WebsocketBackpressurePolicy = "dropNewest"
+	// WebsocketBackpressureDisconnect closes the client's connection
+	// and returns ErrWSClientSendBufferFull
+	WebsocketBackpressureDisconnect WebsocketBackpressurePolicy = "disconnect"
+)
+
+// WebsocketConcurrencyPolicy defines the behavior of a websocket
+// client once MaxConcurrentRequests messages are being processed
+type WebsocketConcurrencyPolicy string
+
+const (
+	// WebsocketConcurrencyQueue buffers excess messages (up to SendBufferSize)
+	// until a message has been processed; the connection keeps being read
+	// (e.g. pongs), hence long running calls do not exceed PongWait.
+	// Once the buffer is full, reading stops until a message has been processed.
+	WebsocketConcurrencyQueue WebsocketConcurrencyPolicy = "queue"
+	// WebsocketConcurrencyReject responds to excess calls with ErrTooManyRequests
+	// without processing them; excess notifications will be dropped
+	WebsocketConcurrencyReject WebsocketConcurrencyPolicy = "reject"
+)
+
+var (
+	// ErrWSClientSendBufferFull will be returned in case a message
+	// could not be sent due to a full send buffer
+	ErrWSClientSendBufferFull = errors.New("wsClient: send buffer full")
+	// ErrWSClientClosed will be returned in case a message
+	// could not be sent due to a closed client
+	ErrWSClientClosed = errors.New("wsClient: closed")
+	// ErrNoNotificationChannel will be returned by Context.Notify in case
+	// the transport of the ongoing call cannot push notifications
+	ErrNoNotificationChannel = errors.New("notify: no notification channel")
+)
+
+const defaultWSClientSendBufferSize = 512
+
 func NewWebsocketOptions() *WebsocketOptions {
 	return &WebsocketOptions{
 		Upgrader: &websocket.Upgrader{
@@ -47,6 +143,10 @@ func NewWebsocketOptions() *WebsocketOptions {
 		PongWait:       60 * time.Second,
 		PingPeriod:     (60 * time.Second * 9) / 10,
 		MaxMessageSize: 1 << 22,
+
+		SendBufferSize:     defaultWSClientSendBufferSize,
+		BackpressurePolicy: WebsocketBackpressureBlock,
+		ConcurrencyPolicy:  WebsocketConcurrencyQueue,
 	}
 }
 
@@ -55,6 +155,9 @@ func NewWebsocketHandler(
 	path string,
 	options *WebsocketOptions,
 ) *WebsocketHandler {
+	if options == nil {
+		options = NewWebsocketOptions()
+	}
 
 	return &WebsocketHandler{
 		path:          path,
@@ -88,19 +191,63 @@ type WSClient struct {
 	conn          *websocket.Conn
 	httpRequest   *http.Request
 	send          chan []byte
+	// inFlight limits the number of concurrently
+	// processed messages; nil in case of no limit
+	inFlight chan struct{}
+	// ordered receives the messages to be processed
+	// one after another; nil in case of concurrent processing
+	ordered chan []byte
+	// queued receives the messages to be processed once a slot
+	// is available, see WebsocketConcurrencyQueue; nil in case
+	// of no limit or ordered processing
+	queued chan []byte
+	// lastSeen is the time of the last message
+	// or pong received in unix nanoseconds
+	lastSeen atomic.Int64
+
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 func NewWSClient(ws *WebsocketHandler, methodHandler *MethodHandler, conn *websocket.Conn, r *http.Request) *WSClient {
-	return &WSClient{
+	size := ws.options.SendBufferSize
+	if size <= 0 {
+		size = defaultWSClientSendBufferSize
+	}
+	var inFlight chan struct{}
+	if ws.options.MaxConcurrentRequests > 0 {
+		inFlight = make(chan struct{}, ws.options.MaxConcurrentRequests)
+	}
+	var ordered, queued chan []byte
+	if ws.options.OrderedResponses {
+		// keep reading (e.g. pongs) while a call is being processed
+		ordered = make(chan []byte, size)
+	} else if inFlight != nil && ws.options.ConcurrencyPolicy != WebsocketConcurrencyReject {
+		// keep reading (e.g. pongs) while all slots are busy
+		queued = make(chan []byte, size)
+	}
+	client := &WSClient{
 		ws:            ws,
 		methodHandler: methodHandler,
 		conn:          conn,
 		httpRequest:   r,
-		send:          make(chan []byte, 512),
+		send:          make(chan []byte, size),
+		inFlight:      inFlight,
+		ordered:       ordered,
+		queued:        queued,
+		done:          make(chan struct{}),
 	}
+	client.seen()
+	return client
 }
 
 func (w *WSClient) run() {
+	if w.ordered != nil {
+		go w.processor()
+	}
+	if w.queued != nil {
+		go w.dispatcher()
+	}
 	go w.reader()
 	// we need to keep the run method blocking
 	w.writer()
@@ -109,12 +256,26 @@ func (w *WSClient) run() {
 func (w *WSClient) reader() {
 	defer func() {
 		w.conn.Close()
+		if w.ordered != nil {
+			close(w.ordered)
+		}
+		if w.queued != nil {
+			close(w.queued)
+		}
+		// make sure the writer stops as well
+		w.closeOnce.Do(func() {
+			close(w.done)
+		})
 	}()
 
 	w.conn.SetReadLimit(w.ws.options.MaxMessageSize)
 	w.conn.SetReadDeadline(time.Now().Add(w.ws.options.PongWait))
 	w.conn.SetPongHandler(func(string) error {
 		w.conn.SetReadDeadline(time.Now().Add(w.ws.options.PongWait))
+		w.seen()
+		if w.ws.options.OnPong != nil {
+			go w.ws.options.OnPong(w)
+		}
 		return nil
 	})
 
@@ -126,31 +287,159 @@ func (w *WSClient) reader() {
 			}
 			return
 		}
+		w.seen()
 
 		if messageType == websocket.TextMessage || messageType == websocket.BinaryMessage {
+			if w.queued != nil {
+				select {
+				case w.queued <- p:
+				case <-w.done:
+				}
+				continue
+			}
+			if !w.acquire(p) {
+				continue
+			}
+			if w.ordered != nil {
+				select {
+				case w.ordered <- p:
+				case <-w.done:
+					w.release()
+				}
+				continue
+			}
 			go func() {
-				resp, batch := w.methodHandler.processRpcMessages(RpcSourceWs, RpcHttpMethodPost, w.httpRequest, nil, w, p)
+				defer w.release()
+				w.process(p)
+			}()
+		}
+	}
+}
 
-				if len(resp) == 0 {
-					// nothing to return but obviously everything was ok
-					return
-				}
+// processor processes the messages received by
+// the reader one after another, see OrderedResponses
+func (w *WSClient) processor() {
+	for p := range w.ordered {
+		select {
+		case <-w.done:
+			// do not process messages of closed clients
+		default:
+			w.process(p)
+		}
+		w.release()
+	}
+}
 
-				if !batch {
-					// single response
-					b, _ := json.Marshal(resp[0])
-					w.send <- b
-					return
-				}
+// dispatcher processes the messages queued by the reader
+// once a slot is available, see WebsocketConcurrencyQueue
+func (w *WSClient) dispatcher() {
+	for p := range w.queued {
+		select {
+		case <-w.done:
+			// do not process messages of closed clients
+			continue
+		default:
+		}
+		if !w.acquire(p) {
+			continue
+		}
+		go func() {
+			defer w.release()
+			w.process(p)
+		}()
+	}
+}
 
-				// batch response
-				b, _ := json.Marshal(resp)
-				w.send <- b
-			}()
+// process processes the message and enqueues the response
+func (w *WSClient) process(p []byte) {
+	resp, batch := w.methodHandler.processRpcMessages(RpcSourceWs, RpcHttpMethodPost, w.httpRequest, nil, nil, w, p)
+
+	if len(resp) == 0 {
+		// nothing to return but obviously everything was ok
+		return
+	}
+
+	var b []byte
+	if !batch {
+		// single response
+		b, _ = json.Marshal(resp[0])
+	} else {
+		// batch response
+		b, _ = json.Marshal(resp)
+	}
+	w.methodHandler.releaseResponses(resp)
+
+	if err := w.enqueue(b); err != nil {
+		w.methodHandler.logger.Warn("wsClient.reader: failed to send response", "error", err)
+	}
+}
+
+// acquire reserves a slot for processing the message, respecting
+// MaxConcurrentRequests. In case the message will not be processed,
+// false will be returned.
+func (w *WSClient) acquire(msg []byte) bool {
+	if w.inFlight == nil {
+		return true
+	}
+	if w.ws.options.ConcurrencyPolicy == WebsocketConcurrencyReject {
+		select {
+		case w.inFlight <- struct{}{}:
+			return true
+		default:
 		}
+		if b := tooManyRequestsResponse(msg); b != nil {
+			if err := w.enqueue(b); err != nil {
+				w.methodHandler.logger.Warn("wsClient.reader: failed to send response", "error", err)
+			}
+		}
+		return false
+	}
+	select {
+	case w.inFlight <- struct{}{}:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *WSClient) release() {
+	if w.inFlight != nil {
+		<-w.inFlight
 	}
 }
 
+// tooManyRequestsResponse returns ErrTooManyRequests for each call
+// contained within the message; in case the message only contains
+// notifications, nil will be returned
+func tooManyRequestsResponse(msg []byte) []byte {
+	var (
+		single *RpcRequest
+		batch  []*RpcRequest
+	)
+	if err := json.Unmarshal(msg, &batch); err != nil {
+		if err := json.Unmarshal(msg, &single); err != nil || single == nil {
+			b, _ := json.Marshal(NewRpcErrorResponse(nil, ErrTooManyRequests))
+			return b
+		}
+		if single.ID == nil {
+			return nil
+		}
+		b, _ := json.Marshal(NewRpcErrorResponse(single.ID, ErrTooManyRequests))
+		return b
+	}
+	resp := []*RpcErrorResponse{}
+	for _, v := range batch {
+		if v != nil && v.ID != nil {
+			resp = append(resp, NewRpcErrorResponse(v.ID, ErrTooManyRequests))
+		}
+	}
+	if len(resp) == 0 {
+		return nil
+	}
+	b, _ := json.Marshal(resp)
+	return b
+}
+
 func (w *WSClient) writer() {
 	ticker := time.NewTicker(w.ws.options.PingPeriod)
 	defer func() {
@@ -160,6 +449,9 @@ func (w *WSClient) writer() {
 
 	for {
 		select {
+		case <-w.done:
+			return
+
 		case next, ok := <-w.send:
 			w.conn.SetWriteDeadline(time.Now().Add(w.ws.options.WriteWait))
 			if !ok {
@@ -182,6 +474,9 @@ func (w *WSClient) writer() {
 				}
 				return
 			}
+			if w.ws.options.OnPing != nil {
+				go w.ws.options.OnPing(w)
+			}
 		}
 	}
 }
@@ -198,21 +493,125 @@ func (w *WSClient) SendNotification(msg *RpcNotification) (err error) {
 	}()
 
 	raw, _ := json.Marshal(msg)
-	w.send <- raw
+	return w.enqueue(raw)
+}
+
+// enqueue adds a message to the send buffer
+// respecting the configured backpressure policy
+func (w *WSClient) enqueue(msg []byte) error {
+	select {
+	case <-w.done:
+		return ErrWSClientClosed
+	default:
+	}
+
+	switch w.ws.options.BackpressurePolicy {
+	case WebsocketBackpressureDropNewest:
+		select {
+		case w.send <- msg:
+			return nil
+		default:
+			return ErrWSClientSendBufferFull
+		}
+
+	case WebsocketBackpressureDropOldest:
+		for {
+			select {
+			case w.send <- msg:
+				return nil
+			default:
+			}
+			// make room by dropping the oldest message
+			select {
+			case <-w.send:
+			default:
+			}
+		}
+
+	case WebsocketBackpressureDisconnect:
+		select {
+		case w.send <- msg:
+			return nil
+		default:
+			w.Close(websocket.ClosePolicyViolation, "send buffer full")
+			return ErrWSClientSendBufferFull
+		}
+
+	default:
+		var timeout <-chan time.Time
+		if w.ws.options.WriteWait > 0 {
+			timer := time.NewTimer(w.ws.options.WriteWait)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case w.send <- msg:
+			return nil
+		case <-w.done:
+			return ErrWSClientClosed
+		case <-timeout:
+			return ErrWSClientSendBufferFull
+		}
+	}
+}
+
+func (w *WSClient) seen() {
+	w.lastSeen.Store(time.Now().UnixNano())
+}
+
+// LastSeen returns the time the client sent its last message or pong,
+// e.g. to disconnect idle clients
+func (w *WSClient) LastSeen() time.Time {
+	return time.Unix(0, w.lastSeen.Load())
+}
+
+// Close closes the connection sending a close frame
+// with the given close code and reason to the client.
+// Close can be called multiple times; only the first
+// call will have an effect.
+func (w *WSClient) Close(code int, reason string) (err error) {
+	w.closeOnce.Do(func() {
+		if w.conn != nil {
+			err = w.conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(code, reason),
+				time.Now().Add(w.ws.options.WriteWait),
+			)
+		}
+		// unblocks the writer which closes the connection
+		close(w.done)
+	})
 	return
 }
 
+// Subprotocol returns the negotiated subprotocol
+func (w *WSClient) Subprotocol() string {
+	if w.conn == nil {
+		return ""
+	}
+	return w.conn.Subprotocol()
+}
+
+// RemoteAddr returns the remote network address
+// of the underlying connection
+func (w *WSClient) RemoteAddr() net.Addr {
+	if w.conn == nil {
+		return nil
+	}
+	return w.conn.RemoteAddr()
+}
+
 // IPAddress returns the request's ip address
 func IPAddress(r *http.Request) string {
 	//gets comma-space separated forwarding list (client, proxy1, proxy2, ...)
 	//Note: the X-FORWARDED-FOR header can be set by the client so this assumes we are using a trusted proxy that
 	//strips this header from client requests
 	ipsStr := r.Header.Get("X-FORWARDED-FOR")
-	ips := strings.SplitN(ipsStr, ", ", 1)
 
 	//return first client if present
-	if len(ips) > 0 {
-		return ips[0]
+	if ipsStr != "" {
+		ips := strings.SplitN(ipsStr, ",", 2)
+		return strings.TrimSpace(ips[0])
 	}
 
 	//fallback to remote address
@@ -0,0 +1,439 @@
+package jonson
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWSClientBackpressure(t *testing.T) {
+	factory := NewFactory()
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+
+	// newStalledClient returns a client without a running writer
+	newStalledClient := func(policy WebsocketBackpressurePolicy) *WSClient {
+		opts := NewWebsocketOptions()
+		opts.SendBufferSize = 2
+		opts.BackpressurePolicy = policy
+		opts.WriteWait = 10 * time.Millisecond
+		req, _ := http.NewRequest("GET", "/ws", nil)
+		return NewWSClient(NewWebsocketHandler(methodHandler, "/ws", opts), methodHandler, nil, req)
+	}
+
+	fill := func(client *WSClient, n int) []error {
+		errs := []error{}
+		for i := 0; i < n; i++ {
+			errs = append(errs, client.SendNotification(NewRpcNotification("test", i)))
+		}
+		return errs
+	}
+
+	readParams := func(client *WSClient) []string {
+		out := []string{}
+		for len(client.send) > 0 {
+			n := &RpcNotification{}
+			json.Unmarshal(<-client.send, n)
+			out = append(out, string(n.Params))
+		}
+		return out
+	}
+
+	t.Run("block returns an error once the buffer does not drain", func(t *testing.T) {
+		client := newStalledClient(WebsocketBackpressureBlock)
+		errs := fill(client, 3)
+		if errs[0] != nil || errs[1] != nil {
+			t.Fatalf("expected first messages to be buffered, got: %v", errs)
+		}
+		if errs[2] != ErrWSClientSendBufferFull {
+			t.Fatalf("expected send buffer full error, got: %v", errs[2])
+		}
+	})
+
+	t.Run("drop newest keeps buffered messages", func(t *testing.T) {
+		client := newStalledClient(WebsocketBackpressureDropNewest)
+		errs := fill(client, 3)
+		if errs[2] != ErrWSClientSendBufferFull {
+			t.Fatalf("expected send buffer full error, got: %v", errs[2])
+		}
+		if params := readParams(client); params[0] != "0" || params[1] != "1" {
+			t.Fatalf("expected oldest messages to be kept, got: %v", params)
+		}
+	})
+
+	t.Run("drop oldest keeps newest messages", func(t *testing.T) {
+		client := newStalledClient(WebsocketBackpressureDropOldest)
+		for _, err := range fill(client, 3) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if params := readParams(client); params[0] != "1" || params[1] != "2" {
+			t.Fatalf("expected newest messages to be kept, got: %v", params)
+		}
+	})
+
+	t.Run("disconnect returns an error", func(t *testing.T) {
+		client := newStalledClient(WebsocketBackpressureDisconnect)
+		errs := fill(client, 3)
+		if errs[2] != ErrWSClientSendBufferFull {
+			t.Fatalf("expected send buffer full error, got: %v", errs[2])
+		}
+	})
+}
+
+type WSSystem struct {
+}
+
+func (w *WSSystem) RemoteAddrV1(ctx *Context) (string, error) {
+	return RequireWSClient(ctx).RemoteAddr().String(), nil
+}
+
+func (w *WSSystem) KickV1(ctx *Context) error {
+	client := RequireWSClient(ctx)
+	client.Close(4000, "kicked")
+	// closing twice must not have any effect
+	return client.Close(4001, "kicked twice")
+}
+
+func (w *WSSystem) ProgressV1(ctx *Context) error {
+	return ctx.Notify("ws-system/progress", 50)
+}
+
+func TestWSClient(t *testing.T) {
+	factory := NewFactory()
+	methodHandler := NewMethodHandler(factory, NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(&WSSystem{})
+
+	srv := httptest.NewServer(NewServer(NewWebsocketHandler(methodHandler, "/ws", nil)))
+	defer srv.Close()
+
+	dial := func(t *testing.T) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+
+	t.Run("exposes the remote address", func(t *testing.T) {
+		conn := dial(t)
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"ws-system/remote-addr.v1"}`))
+		resp := &rpcTestResponse{}
+		if err := conn.ReadJSON(resp); err != nil {
+			t.Fatal(err)
+		}
+		var addr string
+		json.Unmarshal(*resp.Result, &addr)
+		if addr != conn.LocalAddr().String() {
+			t.Fatalf("expected remote address to equal %s, got: %s", conn.LocalAddr().String(), addr)
+		}
+	})
+
+	t.Run("closes the connection with code", func(t *testing.T) {
+		conn := dial(t)
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"ws-system/kick.v1"}`))
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			_, _, err := conn.ReadMessage()
+			if err == nil {
+				continue
+			}
+			if !websocket.IsCloseError(err, 4000) {
+				t.Fatalf("expected close error with code 4000, got: %v", err)
+			}
+			break
+		}
+	})
+
+	t.Run("closed clients do not accept messages", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/ws", nil)
+		client := NewWSClient(NewWebsocketHandler(methodHandler, "/ws", nil), methodHandler, nil, req)
+		client.Close(websocket.CloseNormalClosure, "")
+		if err := client.SendNotification(NewRpcNotification("test", nil)); err != ErrWSClientClosed {
+			t.Fatalf("expected client closed error, got: %v", err)
+		}
+	})
+}
+
+type WSBlockingSystem struct {
+	started chan int
+	release chan struct{}
+}
+
+type WSBlockingBlockV1Params struct {
+	Params
+	Call int `json:"call"`
+}
+
+func (w *WSBlockingSystem) BlockV1(ctx *Context, params *WSBlockingBlockV1Params) (int, error) {
+	w.started <- params.Call
+	<-w.release
+	return params.Call, nil
+}
+
+func newWSBlockingConn(t *testing.T, opts *WebsocketOptions) (*WSBlockingSystem, *websocket.Conn) {
+	system := &WSBlockingSystem{
+		started: make(chan int, 10),
+		release: make(chan struct{}),
+	}
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	methodHandler.RegisterSystem(system)
+
+	srv := httptest.NewServer(NewServer(NewWebsocketHandler(methodHandler, "/ws", opts)))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return system, conn
+}
+
+func (w *WSBlockingSystem) call(conn *websocket.Conn, i int) {
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":`+strconv.Itoa(i)+`,"method":"ws-blocking-system/block.v1","params":{"call":`+strconv.Itoa(i)+`}}`))
+}
+
+func (w *WSBlockingSystem) waitStarted(t *testing.T) int {
+	t.Helper()
+	select {
+	case i := <-w.started:
+		return i
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected call to be started")
+	}
+	return 0
+}
+
+func TestWSClientMaxConcurrentRequests(t *testing.T) {
+	setup := func(t *testing.T, policy WebsocketConcurrencyPolicy) (*WSBlockingSystem, *websocket.Conn) {
+		opts := NewWebsocketOptions()
+		opts.MaxConcurrentRequests = 2
+		opts.ConcurrencyPolicy = policy
+		return newWSBlockingConn(t, opts)
+	}
+
+	t.Run("rejects excess calls", func(t *testing.T) {
+		system, conn := setup(t, WebsocketConcurrencyReject)
+		system.call(conn, 1)
+		system.call(conn, 2)
+		system.waitStarted(t)
+		system.waitStarted(t)
+		system.call(conn, 3)
+
+		resp := &rpcTestResponse{}
+		if err := conn.ReadJSON(resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error == nil || resp.Error.Code != ErrTooManyRequests.Code || string(resp.ID) != "3" {
+			t.Fatalf("expected third call to be rejected, got: %s, %v", resp.ID, resp.Error)
+		}
+		select {
+		case i := <-system.started:
+			t.Fatalf("expected call %d not to be started", i)
+		default:
+		}
+		close(system.release)
+	})
+
+	t.Run("queues excess calls", func(t *testing.T) {
+		system, conn := setup(t, WebsocketConcurrencyQueue)
+		system.call(conn, 1)
+		system.call(conn, 2)
+		system.call(conn, 3)
+		system.waitStarted(t)
+		system.waitStarted(t)
+		select {
+		case i := <-system.started:
+			t.Fatalf("expected call %d to be queued", i)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		system.release <- struct{}{}
+		if i := system.waitStarted(t); i != 3 {
+			t.Fatalf("expected queued call to be started, got: %d", i)
+		}
+		close(system.release)
+		for i := 0; i < 3; i++ {
+			resp := &rpcTestResponse{}
+			if err := conn.ReadJSON(resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error != nil {
+				t.Fatalf("expected calls to succeed, got: %v", resp.Error)
+			}
+		}
+	})
+
+	t.Run("keeps reading pongs while calls are queued", func(t *testing.T) {
+		pong := make(chan struct{}, 1)
+		opts := NewWebsocketOptions()
+		opts.MaxConcurrentRequests = 1
+		opts.OnPong = func(client *WSClient) {
+			select {
+			case pong <- struct{}{}:
+			default:
+			}
+		}
+		system, conn := newWSBlockingConn(t, opts)
+		system.call(conn, 1)
+		system.waitStarted(t)
+		system.call(conn, 2)
+
+		// all slots are busy: the pong must be read nonetheless
+		if err := conn.WriteControl(websocket.PongMessage, nil, time.Now().Add(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-pong:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected pong to be read while calls are queued")
+		}
+
+		close(system.release)
+		if i := system.waitStarted(t); i != 2 {
+			t.Fatalf("expected queued call to be started, got: %d", i)
+		}
+	})
+}
+
+func TestWSClientOrderedResponses(t *testing.T) {
+	t.Run("sends responses in order", func(t *testing.T) {
+		opts := NewWebsocketOptions()
+		opts.OrderedResponses = true
+		system, conn := newWSBlockingConn(t, opts)
+		system.call(conn, 1)
+		system.call(conn, 2)
+		if i := system.waitStarted(t); i != 1 {
+			t.Fatalf("expected first call to be started, got: %d", i)
+		}
+		select {
+		case i := <-system.started:
+			t.Fatalf("expected call %d to wait for the first call", i)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(system.release)
+		for i := 1; i <= 2; i++ {
+			resp := &rpcTestResponse{}
+			if err := conn.ReadJSON(resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error != nil || string(resp.ID) != strconv.Itoa(i) {
+				t.Fatalf("expected response %d, got: %s, %v", i, resp.ID, resp.Error)
+			}
+		}
+	})
+
+	t.Run("processes calls concurrently by default", func(t *testing.T) {
+		system, conn := newWSBlockingConn(t, NewWebsocketOptions())
+		system.call(conn, 1)
+		system.call(conn, 2)
+		system.waitStarted(t)
+		system.waitStarted(t)
+		close(system.release)
+	})
+}
+
+func TestWSClientHeartbeat(t *testing.T) {
+	methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+	pinged := make(chan *WSClient, 10)
+	ponged := make(chan *WSClient, 10)
+	opts := NewWebsocketOptions()
+	opts.PingPeriod = 10 * time.Millisecond
+	opts.OnPing = func(client *WSClient) {
+		pinged <- client
+	}
+	opts.OnPong = func(client *WSClient) {
+		ponged <- client
+	}
+	srv := httptest.NewServer(NewServer(NewWebsocketHandler(methodHandler, "/ws", opts)))
+	defer srv.Close()
+
+	start := time.Now()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// reading answers pings
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, ch := range []chan *WSClient{pinged, ponged} {
+		select {
+		case client := <-ch:
+			if !client.LastSeen().After(start) {
+				t.Fatalf("expected last seen to be after %v, got: %v", start, client.LastSeen())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected heartbeat callbacks to be called")
+		}
+	}
+}
+
+func TestContextNotify(t *testing.T) {
+	t.Run("sends notifications over websockets", func(t *testing.T) {
+		methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		methodHandler.RegisterSystem(&WSSystem{})
+		srv := httptest.NewServer(NewServer(NewWebsocketHandler(methodHandler, "/ws", nil)))
+		defer srv.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"ws-system/progress.v1"}`))
+		notification := &RpcNotification{}
+		if err := conn.ReadJSON(notification); err != nil {
+			t.Fatal(err)
+		}
+		if notification.Method != "ws-system/progress" || string(notification.Params) != "50" {
+			t.Fatalf("expected progress notification, got: %s %s", notification.Method, notification.Params)
+		}
+		resp := &rpcTestResponse{}
+		if err := conn.ReadJSON(resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != nil {
+			t.Fatalf("expected call to succeed, got: %v", resp.Error)
+		}
+	})
+
+	t.Run("drops notifications without channel by default", func(t *testing.T) {
+		methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), nil)
+		ctx := NewContext(context.Background(), methodHandler.factory, methodHandler)
+		if err := ctx.Notify("test", nil); err != nil {
+			t.Fatalf("expected notification to be dropped, got: %v", err)
+		}
+	})
+
+	t.Run("returns an error without channel if required", func(t *testing.T) {
+		methodHandler := NewMethodHandler(NewFactory(), NewDebugSecret(), &MethodHandlerOptions{
+			RequireNotificationChannel: true,
+		})
+		ctx := NewContext(context.Background(), methodHandler.factory, methodHandler)
+		if err := ctx.Notify("test", nil); err != ErrNoNotificationChannel {
+			t.Fatalf("expected no notification channel error, got: %v", err)
+		}
+	})
+}